@@ -8,7 +8,6 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"sync"
 	"syscall"
 	"time"
 
@@ -20,36 +19,65 @@ import (
 )
 
 func main() {
-	// Initialize user manager
-	userManager, err := services.NewUserManager(getDataDir())
+	storage, err := openStorage()
 	if err != nil {
-		log.Fatal("Failed to initialize user manager:", err)
+		log.Fatal("Failed to initialize storage:", err)
 	}
-	settingsService, err := services.NewSettingsService(getDataDir())
+	defer storage.close()
+	blobStore, err := openBlobStore()
+	if err != nil {
+		log.Fatal("Failed to initialize file storage:", err)
+	}
+	clipboardStore, err := enableEncryption(storage.clipboardStore, blobStore)
+	if err != nil {
+		log.Fatal("Failed to initialize encryption:", err)
+	}
+	searchIndex := services.NewSearchIndex()
+	clipboardStore = services.NewIndexedClipboardStore(clipboardStore, searchIndex)
+	uploadStore, err := services.NewResumableUploadStore(filepath.Join(getDataDir(), "tus"))
+	if err != nil {
+		log.Fatal("Failed to initialize upload storage:", err)
+	}
+	shareStore, err := services.NewFileShareStore(getDataDir())
+	if err != nil {
+		log.Fatal("Failed to initialize share storage:", err)
+	}
+	collectionStore, err := services.NewFileCollectionStore(getDataDir())
 	if err != nil {
-		log.Fatal("Failed to initialize settings service:", err)
+		log.Fatal("Failed to initialize collection storage:", err)
 	}
-	authService := services.NewAuthService(userManager)
+	userManager := storage.userManager
+	settingsService := storage.settingsService
+	authService := storage.authService
 
 	app := &models.App{
-		ClipboardData:   make(map[string]*models.ClipboardItem),
-		DataMutex:       &sync.RWMutex{},
-		TempDir:         getTempDir(),
+		ClipboardStore:  clipboardStore,
+		Blobs:           blobStore,
+		Uploads:         uploadStore,
+		Shares:          shareStore,
+		Collections:     collectionStore,
 		Security:        services.NewSecurityService(),
 		RateLimiter:     services.NewRateLimitService(),
 		UserManager:     userManager,
 		AuthService:     authService,
 		SettingsService: settingsService,
+		Search:          searchIndex,
 		OAuthService:    services.NewOAuthServiceFromSettings(userManager, authService, settingsService),
 	}
 
-	initTempDir(app.TempDir)
+	if err := handlers.ReconcileItems(app); err != nil {
+		log.Fatal("Failed to reconcile stored items:", err)
+	}
 
+	// The timeouts suit ordinary API calls; routes that stream files lift
+	// them with their own deadline (see transferTimeout).
 	server := &http.Server{
-		Addr:         ":5000",
-		Handler:      setupRouter(app),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		Addr:              ":5000",
+		Handler:           setupRouter(app),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
 	}
 
 	go func() {
@@ -88,6 +116,7 @@ func setupRouter(app *models.App) *gin.Engine {
 
 	// Create handler
 	handler := &handlers.Handler{App: app}
+	transfer := middleware.TransferDeadlineMiddleware(transferTimeout)
 
 	// Public auth endpoints
 	auth := router.Group("/api/auth")
@@ -107,9 +136,36 @@ func setupRouter(app *models.App) *gin.Engine {
 	{
 		api.POST("/text", handler.SaveText)
 		api.GET("/text/:id", handler.GetText)
-		api.POST("/file", handler.SaveFile)
-		api.GET("/file/:id", handler.GetFile)
+		api.PUT("/text/:id", handler.UpdateText)
+		api.GET("/text/:id/revisions", handler.ListTextRevisions)
+		api.GET("/text/:id/revisions/:revision", handler.GetTextRevision)
+		api.POST("/file", transfer, handler.SaveFile)
+		api.GET("/file/:id", transfer, handler.GetFile)
+		api.GET("/file/:id/thumbnail", handler.GetThumbnail)
+		api.POST("/bundle", transfer, handler.SaveBundle)
+		api.GET("/bundle/:id", handler.GetBundle)
+		api.GET("/bundle/:id/zip", transfer, handler.DownloadBundle)
+		api.GET("/bundle/:id/files/*path", transfer, handler.GetBundleFile)
+		api.POST("/secret", transfer, handler.SaveSecret)
+		api.GET("/secret/:id", transfer, handler.GetSecret)
 		api.GET("/items", handler.ListRecentItems)
+		api.GET("/items/received", handler.ListReceivedItems)
+		api.GET("/items/history", handler.ListHistory)
+		api.PATCH("/items/:id", handler.UpdateItem)
+		api.POST("/items/:id/recipients", handler.SendItem)
+		api.POST("/items/:id/shares", handler.CreateShare)
+		api.GET("/items/:id/shares", handler.ListShares)
+		api.DELETE("/items/:id/shares/:token", handler.DeleteShare)
+		api.GET("/collections", handler.ListCollections)
+		api.POST("/collections", handler.CreateCollection)
+		api.PATCH("/collections/:id", handler.UpdateCollection)
+		api.DELETE("/collections/:id", handler.DeleteCollection)
+		api.GET("/usage", handler.GetUsage)
+		api.GET("/search", handler.Search)
+		api.POST("/uploads", handler.CreateUpload)
+		api.HEAD("/uploads/:id", handler.GetUploadOffset)
+		api.PATCH("/uploads/:id", transfer, handler.PatchUpload)
+		api.DELETE("/uploads/:id", handler.DeleteUpload)
 		api.DELETE("/:id", handler.DeleteItem)
 		api.PUT("/users/:id/password", handler.ChangeUserPassword)
 		api.GET("/settings", middleware.AdminMiddleware(app), handler.GetSettings)
@@ -130,6 +186,15 @@ func setupRouter(app *models.App) *gin.Engine {
 	// Admin-only cleanup endpoint
 	api.GET("/cleanup", middleware.AdminMiddleware(app), handler.Cleanup)
 
+	// Public share links work without an account, so they get their own,
+	// tighter rate limit on top of the global one.
+	shares := router.Group("/s")
+	shares.Use(middleware.BucketRateLimitMiddleware(app, models.ShareRateLimitEndpoint))
+	{
+		shares.GET("/:token", transfer, handler.OpenShare)
+		shares.POST("/:token", transfer, handler.OpenShare)
+	}
+
 	router.Static("/assets", "./frontend/dist/assets")
 	router.StaticFile("/favicon.ico", "./frontend/dist/favicon.ico")
 
@@ -147,29 +212,174 @@ func setupRouter(app *models.App) *gin.Engine {
 	return router
 }
 
-func getTempDir() string {
-	return filepath.Join(os.TempDir(), "web-clipboard-go")
+// transferTimeout bounds a single file upload or download: enough for the
+// largest upload over a slow mobile connection.
+const transferTimeout = 30 * time.Minute
+
+const (
+	storageBackendJSON = "json"
+	storageBackendBolt = "bolt"
+)
+
+// storageServices groups the services whose persistence depends on the
+// configured storage backend.
+type storageServices struct {
+	userManager     *services.UserManager
+	settingsService *services.SettingsService
+	authService     *services.AuthService
+	clipboardStore  models.ClipboardStore
+	close           func()
 }
 
-func getDataDir() string {
-	if value := os.Getenv("WEB_CLIPBOARD_DATA_DIR"); value != "" {
+func openStorage() (*storageServices, error) {
+	switch backend := getStorageBackend(); backend {
+	case storageBackendJSON:
+		return openJSONStorage()
+	case storageBackendBolt:
+		return openBoltStorage()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+func openJSONStorage() (*storageServices, error) {
+	userManager, err := services.NewUserManager(getDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("user manager: %w", err)
+	}
+	settingsService, err := services.NewSettingsService(getDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("settings service: %w", err)
+	}
+	clipboardStore, err := services.NewFileClipboardStore(getDataDir())
+	if err != nil {
+		return nil, fmt.Errorf("clipboard store: %w", err)
+	}
+	return &storageServices{
+		userManager:     userManager,
+		settingsService: settingsService,
+		authService:     services.NewAuthService(userManager),
+		clipboardStore:  clipboardStore,
+		close:           func() {},
+	}, nil
+}
+
+func openBoltStorage() (*storageServices, error) {
+	storage, err := services.OpenBoltStorage(getDataDir())
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*storageServices, error) {
+		storage.Close()
+		return nil, err
+	}
+
+	imported, err := storage.ImportJSONData(getDataDir())
+	if err != nil {
+		return fail(err)
+	}
+	if imported {
+		fmt.Println("Imported existing JSON data files into the database")
+	}
+
+	userManager, err := services.NewBoltUserManager(storage)
+	if err != nil {
+		return fail(fmt.Errorf("user manager: %w", err))
+	}
+	settingsService, err := services.NewBoltSettingsService(storage)
+	if err != nil {
+		return fail(fmt.Errorf("settings service: %w", err))
+	}
+	authService, err := services.NewBoltAuthService(userManager, storage)
+	if err != nil {
+		return fail(fmt.Errorf("auth service: %w", err))
+	}
+	return &storageServices{
+		userManager:     userManager,
+		settingsService: settingsService,
+		authService:     authService,
+		clipboardStore:  services.NewBoltClipboardStore(storage),
+		close: func() {
+			if err := storage.Close(); err != nil {
+				log.Printf("Failed to close database: %v", err)
+			}
+		},
+	}, nil
+}
+
+// getStorageBackend selects where users, settings, sessions and clipboard
+// metadata are kept: "json" files (default) or the embedded "bolt" database.
+func getStorageBackend() string {
+	if value := os.Getenv("WEB_CLIPBOARD_STORAGE"); value != "" {
 		return value
 	}
-	return "/data"
+	return storageBackendJSON
 }
 
-func initTempDir(tempDir string) {
-	err := os.MkdirAll(tempDir, 0755)
+const (
+	blobBackendLocal = "local"
+	blobBackendS3    = "s3"
+)
+
+// openBlobStore picks where uploaded file contents live, from
+// WEB_CLIPBOARD_BLOB_BACKEND: "local" disk (default) or an "s3"-compatible
+// bucket configured through the WEB_CLIPBOARD_S3_* variables.
+func openBlobStore() (*services.ContentBlobStore, error) {
+	switch backend := os.Getenv("WEB_CLIPBOARD_BLOB_BACKEND"); backend {
+	case "", blobBackendLocal:
+		return services.NewLocalBlobStore(getFileDir())
+	case blobBackendS3:
+		return services.NewS3BlobStore(services.S3Config{
+			Endpoint:         os.Getenv("WEB_CLIPBOARD_S3_ENDPOINT"),
+			Region:           os.Getenv("WEB_CLIPBOARD_S3_REGION"),
+			Bucket:           os.Getenv("WEB_CLIPBOARD_S3_BUCKET"),
+			AccessKey:        os.Getenv("WEB_CLIPBOARD_S3_ACCESS_KEY"),
+			SecretKey:        os.Getenv("WEB_CLIPBOARD_S3_SECRET_KEY"),
+			Prefix:           os.Getenv("WEB_CLIPBOARD_S3_PREFIX"),
+			PresignDownloads: os.Getenv("WEB_CLIPBOARD_S3_PRESIGN") == "true",
+		}, getDataDir())
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", backend)
+	}
+}
+
+// enableEncryption turns on encryption at rest when a master key is set in
+// WEB_CLIPBOARD_MASTER_KEY_FILE or WEB_CLIPBOARD_MASTER_KEY. Existing data is
+// moved onto the primary key at startup, so a retired key can be dropped
+// after one restart with both keys configured.
+func enableEncryption(clipboardStore models.ClipboardStore, blobStore *services.ContentBlobStore) (models.ClipboardStore, error) {
+	keys, err := services.LoadKeyRing(os.Getenv("WEB_CLIPBOARD_MASTER_KEY_FILE"), os.Getenv("WEB_CLIPBOARD_MASTER_KEY"))
+	if err != nil || keys == nil {
+		return clipboardStore, err
+	}
+
+	encrypted := services.NewEncryptedClipboardStore(clipboardStore, keys)
+	items, err := encrypted.RewrapKeys()
 	if err != nil {
-		panic("Failed to create temp directory: " + err.Error())
+		return nil, fmt.Errorf("clipboard items: %w", err)
+	}
+	blobStore.UseKeyRing(keys)
+	blobs, err := blobStore.RewrapKeys()
+	if err != nil {
+		return nil, fmt.Errorf("file blobs: %w", err)
+	}
+	if items > 0 || blobs > 0 {
+		fmt.Printf("Re-encrypted %d clipboard items and %d file keys with master key %s\n", items, blobs, keys.PrimaryID())
 	}
+	return encrypted, nil
+}
 
-	files, err := filepath.Glob(filepath.Join(tempDir, "*"))
-	if err == nil {
-		for _, file := range files {
-			os.Remove(file)
-		}
+// getFileDir returns the blob store root for uploaded file contents. It sits
+// inside the data directory so files outlive restarts with their metadata.
+func getFileDir() string {
+	return filepath.Join(getDataDir(), "files")
+}
+
+func getDataDir() string {
+	if value := os.Getenv("WEB_CLIPBOARD_DATA_DIR"); value != "" {
+		return value
 	}
+	return "/data"
 }
 
 func startCleanupService(app *models.App) {
@@ -188,25 +398,31 @@ func stopCleanupService(app *models.App) {
 }
 
 func performCleanup(app *models.App) {
-	removedCount := 0
-	now := time.Now().UTC()
-
-	app.DataMutex.Lock()
-	for id, item := range app.ClipboardData {
-		if models.ClipboardItemExpired(item, now) {
-			if item.Type == "file" && item.FilePath != "" {
-				os.Remove(item.FilePath)
-			}
-			delete(app.ClipboardData, id)
-			removedCount++
-		}
+	removedCount, err := handlers.RemoveExpiredItems(app, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to clean up expired items: %v", err)
 	}
-	app.DataMutex.Unlock()
 
 	if removedCount > 0 {
 		fmt.Printf("Cleaned up %d expired items\n", removedCount)
 	}
 
+	removedUploads, err := app.Uploads.ExpireBefore(time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to clean up abandoned uploads: %v", err)
+	}
+	if removedUploads > 0 {
+		fmt.Printf("Cleaned up %d abandoned uploads\n", removedUploads)
+	}
+
+	removedShares, err := app.Shares.ExpireBefore(time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to clean up expired share links: %v", err)
+	}
+	if removedShares > 0 {
+		fmt.Printf("Cleaned up %d expired share links\n", removedShares)
+	}
+
 	app.Security.CleanupExpired()
 	app.RateLimiter.CleanupExpired()
 	app.AuthService.CleanupExpiredSessions()
@@ -3,7 +3,6 @@ package main
 import (
 	"net/http"
 	"net/http/httptest"
-	"sync"
 	"testing"
 
 	"web-clipboard-go/backend/internal/models"
@@ -29,9 +28,13 @@ func TestSettingsRouteRequiresAdminUser(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	clipboardStore, err := services.NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	router := setupRouter(&models.App{
-		ClipboardData:   map[string]*models.ClipboardItem{},
-		DataMutex:       &sync.RWMutex{},
+		ClipboardStore:  clipboardStore,
 		RateLimiter:     services.NewRateLimitService(),
 		Security:        services.NewSecurityService(),
 		UserManager:     userManager,
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/middleware"
+)
+
+func TestTransferRoutesOutlastTheServerWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	slowDownload := func(c *gin.Context) {
+		for range 4 {
+			c.Writer.WriteString(strings.Repeat("x", 1024))
+			c.Writer.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	router.GET("/plain", slowDownload)
+	router.GET("/transfer", middleware.TransferDeadlineMiddleware(time.Minute), slowDownload)
+
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	download := func(path string) int {
+		response, err := http.Get(server.URL + path)
+		if err != nil {
+			return 0
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		return len(body)
+	}
+	if size := download("/plain"); size == 4*1024 {
+		t.Fatal("expected the server write timeout to cut off an ordinary route")
+	}
+	if size := download("/transfer"); size != 4*1024 {
+		t.Fatalf("expected the whole download, got %d bytes", size)
+	}
+}
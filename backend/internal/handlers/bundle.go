@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const (
+	maxBundleFiles      = 1000
+	maxBundlePathLength = 1024
+)
+
+// SaveBundle stores every "file" part of a multipart request as one bundle
+// item. Each part's filename may be a relative path such as "logs/app.log",
+// so a whole folder keeps its layout. Item options and an optional "name"
+// field must come before the first file. Like SaveFile, parts stream
+// straight into the blob store, and the size limit applies to the bundle as
+// a whole.
+func (h *Handler) SaveBundle(c *gin.Context) {
+	if !h.App.Security.ValidateFileRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	if !h.checkQuota(c, user, 0) {
+		return
+	}
+
+	// Leave room for the boundaries and headers of every part.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, models.MaxFileSize+1024*1024)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	var files []models.BundleFile
+	saved := false
+	defer func() {
+		if !saved {
+			for _, file := range files {
+				if err := h.App.Blobs.Release(file.Hash); err != nil {
+					log.Printf("Failed to release file of an unsaved bundle: %v", err)
+				}
+			}
+		}
+	}()
+
+	fields := make(map[string]string)
+	var options itemOptions
+	var total int64
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.bundleUploadFailed(c, err)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			if len(files) == 0 && part.FileName() == "" && len(fields) < 16 {
+				value, _ := io.ReadAll(io.LimitReader(part, 1024))
+				fields[part.FormName()] = string(value)
+			}
+			part.Close()
+			continue
+		}
+
+		if len(files) == 0 {
+			options, err = parseItemOptions(func(key string) string { return fields[key] })
+			if err == nil {
+				err = h.checkItemOptions(user, options)
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		file, err := h.storeBundleFile(part, files, models.MaxFileSize-total)
+		part.Close()
+		if err != nil {
+			h.bundleUploadFailed(c, err)
+			return
+		}
+		files = append(files, file)
+		total += file.Size
+	}
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	item, err := h.newItem(user, "bundle", options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item.FileName = bundleName(fields["name"], files)
+	item.Files = files
+	item.FileSize = total
+	if err := h.App.ClipboardStore.Put(item); err != nil {
+		log.Printf("Failed to save bundle item: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bundle"})
+		return
+	}
+	saved = true
+
+	if !h.enforceQuota(c, user, 0, 0) {
+		if removed, _ := h.App.ClipboardStore.Delete(item.ID); removed != nil {
+			releaseItem(h.App, removed)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toBundleResponse(item))
+}
+
+// errInvalidBundleFile marks uploaded files a bundle cannot take. Like
+// errInvalidItemOption they are reported back as 400s with the error text.
+var errInvalidBundleFile = errors.New("invalid bundle file")
+
+func invalidBundleFile(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errInvalidBundleFile, fmt.Sprintf(format, args...))
+}
+
+// storeBundleFile puts one uploaded part in the blob store, checking its
+// path against the files already in the bundle. limit is how many bytes the
+// bundle still has room for.
+func (h *Handler) storeBundleFile(part *multipart.Part, files []models.BundleFile, limit int64) (models.BundleFile, error) {
+	if len(files) >= maxBundleFiles {
+		return models.BundleFile{}, invalidBundleFile("a bundle can hold at most %d files", maxBundleFiles)
+	}
+	filePath, err := bundleFilePath(part)
+	if err != nil {
+		return models.BundleFile{}, err
+	}
+	if !h.App.Security.ValidateFileType(path.Base(filePath)) {
+		return models.BundleFile{}, invalidBundleFile("file type not allowed: %s", filePath)
+	}
+	for _, file := range files {
+		if strings.EqualFold(file.Path, filePath) {
+			return models.BundleFile{}, invalidBundleFile("%s was uploaded twice", filePath)
+		}
+	}
+
+	sniffer := &contentSniffer{}
+	hash, size, err := h.App.Blobs.Put(io.TeeReader(&sizeLimitedReader{r: part, limit: limit}, sniffer))
+	if err != nil {
+		return models.BundleFile{}, err
+	}
+	return models.BundleFile{
+		Path:        filePath,
+		Hash:        hash,
+		Size:        size,
+		ContentType: sniffer.ContentType(part.Header.Get("Content-Type")),
+	}, nil
+}
+
+// bundleFilePath returns the relative path a part was uploaded under.
+// multipart.Part.FileName keeps only the last element, so the filename
+// parameter is read directly. Paths that are absolute or climb out of the
+// bundle are refused rather than cleaned up, since a zip of them could
+// write outside the folder it is extracted to.
+func bundleFilePath(part *multipart.Part) (string, error) {
+	_, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	filePath := strings.ReplaceAll(params["filename"], `\`, "/")
+	if err != nil || filePath == "" || len(filePath) > maxBundlePathLength || !utf8.ValidString(filePath) {
+		return "", invalidBundleFile("file names must be 1 to %d bytes of UTF-8", maxBundlePathLength)
+	}
+	for _, element := range strings.Split(filePath, "/") {
+		if element == "" || element == "." || element == ".." || strings.ContainsFunc(element, isControlRune) {
+			return "", invalidBundleFile("%q is not a relative path", filePath)
+		}
+	}
+	return filePath, nil
+}
+
+func isControlRune(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// bundleUploadFailed answers an upload that stopped partway through.
+func (h *Handler) bundleUploadFailed(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errFileTooLarge) || errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Bundle too large (max 50MB in total)"})
+	case errors.Is(err, errInvalidBundleFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Failed to save uploaded bundle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bundle"})
+	}
+}
+
+// bundleName is the name given for a bundle, or else the folder all of its
+// files were uploaded from.
+func bundleName(name string, files []models.BundleFile) string {
+	if name = strings.TrimSpace(name); name != "" && utf8.RuneCountInString(name) <= 255 && !strings.ContainsAny(name, `/\`) && !strings.ContainsFunc(name, isControlRune) {
+		return name
+	}
+	folder, _, found := strings.Cut(files[0].Path, "/")
+	for _, file := range files {
+		if !found || !strings.HasPrefix(file.Path, folder+"/") {
+			return "bundle"
+		}
+	}
+	return folder
+}
+
+// GetBundle lists a bundle's files. Listing does not count as a read.
+func (h *Handler) GetBundle(c *gin.Context) {
+	item, ok := h.viewableBundle(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, toBundleResponse(item))
+}
+
+// GetBundleFile downloads one file of a bundle by its path.
+func (h *Handler) GetBundleFile(c *gin.Context) {
+	item, ok := h.viewableBundle(c)
+	if !ok {
+		return
+	}
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+	index := -1
+	for i, file := range item.Files {
+		if file.Path == filePath {
+			index = i
+		}
+	}
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found in bundle"})
+		return
+	}
+
+	item, lastView, ok := h.readBundle(c, item)
+	if !ok {
+		return
+	}
+	if lastView {
+		defer releaseItem(h.App, item)
+	}
+
+	file := item.Files[index]
+	content, err := h.App.Blobs.Open(file.Hash)
+	if err != nil {
+		h.App.Security.LogAccess(c, item.ID, "bundle", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on disk"})
+		return
+	}
+	defer content.Close()
+
+	h.App.Security.LogAccess(c, item.ID, "bundle", true)
+	h.recordOpened(c, item)
+	name := path.Base(file.Path)
+	c.Header("Content-Disposition", contentDispositionHeader(name))
+	serveItemContent(c, item, lastView, name, content)
+}
+
+// DownloadBundle streams a bundle as a zip archive. The archive is written
+// as it is read from the blob store, so it is never held in memory or on
+// disk; its length is not known up front and no Content-Length is sent.
+func (h *Handler) DownloadBundle(c *gin.Context) {
+	item, ok := h.viewableBundle(c)
+	if !ok {
+		return
+	}
+	item, lastView, ok := h.readBundle(c, item)
+	if !ok {
+		return
+	}
+	if lastView {
+		defer releaseItem(h.App, item)
+		c.Header(clipboardLastViewHeader, "true")
+	}
+
+	h.App.Security.LogAccess(c, item.ID, "bundle", true)
+	h.recordOpened(c, item)
+	c.Header("Cache-Control", "no-store")
+	writeBundleZip(c, h.App, item)
+}
+
+// writeBundleZip sends item's files as a zip archive. Once the first byte
+// is out a failure can only cut the archive short, which leaves it without
+// its central directory so unzip tools report it as broken.
+func writeBundleZip(c *gin.Context, app *models.App, item *models.ClipboardItem) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", contentDispositionHeader(item.FileName+".zip"))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	for _, file := range item.Files {
+		if err := writeBundleZipEntry(archive, app, item, file); err != nil {
+			log.Printf("Failed to write %s of bundle %s: %v", file.Path, item.ID, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Failed to finish zip of bundle %s: %v", item.ID, err)
+	}
+}
+
+func writeBundleZipEntry(archive *zip.Writer, app *models.App, item *models.ClipboardItem, file models.BundleFile) error {
+	content, err := app.Blobs.Open(file.Hash)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	header := &zip.FileHeader{Name: file.Path, Method: zip.Deflate, Modified: item.CreatedAt}
+	if !compressible(file.ContentType) {
+		header.Method = zip.Store
+	}
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, content)
+	return err
+}
+
+// compressible reports whether deflating content of this type is worth it.
+// Images, audio, video and archives are already compressed.
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/bmp" && mediaType != "image/svg+xml",
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/zip", mediaType == "application/x-gzip", mediaType == "application/x-rar-compressed":
+		return false
+	}
+	return true
+}
+
+// viewableBundle looks up the bundle named in the URL for the caller,
+// answering 404 for bundles they cannot see and asking for the item
+// password when it has one.
+func (h *Handler) viewableBundle(c *gin.Context) (*models.ClipboardItem, bool) {
+	id := strings.ToLower(c.Param("id"))
+	if !h.App.Security.ValidateAccessRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	item, exists := h.App.ClipboardStore.Get(id)
+	if !exists || item.Type != "bundle" || models.ClipboardItemExpired(item, time.Now().UTC()) || !canViewItem(c, item) {
+		h.App.Security.LogAccess(c, id, "bundle", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return nil, false
+	}
+	if !h.checkItemPassword(c, item) {
+		return nil, false
+	}
+	return item, true
+}
+
+// readBundle counts a download of item against its read limit.
+func (h *Handler) readBundle(c *gin.Context, item *models.ClipboardItem) (*models.ClipboardItem, bool, bool) {
+	id := item.ID
+	item, lastView, err := h.consumeRead(item)
+	if err != nil {
+		log.Printf("Failed to record read of %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download bundle"})
+		return nil, false, false
+	}
+	if item == nil {
+		h.App.Security.LogAccess(c, id, "bundle", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return nil, false, false
+	}
+	return item, lastView, true
+}
+
+func toBundleResponse(item *models.ClipboardItem) models.SaveBundleResponse {
+	files := make([]models.BundleFileResponse, 0, len(item.Files))
+	for _, file := range item.Files {
+		files = append(files, models.BundleFileResponse{
+			Path:        file.Path,
+			Size:        file.Size,
+			ContentType: file.ContentType,
+		})
+	}
+	return models.SaveBundleResponse{
+		ID:        item.ID,
+		Name:      item.FileName,
+		Files:     files,
+		Size:      item.FileSize,
+		ExpiresAt: item.ExpiresAt,
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// uploadBundleAs posts files, keyed by the path each is uploaded under.
+func uploadBundleAs(router http.Handler, username string, fields map[string]string, paths []string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	for _, path := range paths {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="`+path+`"`)
+		part, _ := writer.CreatePart(header)
+		part.Write([]byte(files[path]))
+	}
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/bundle", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return serveAs(router, username, request)
+}
+
+func TestBundleKeepsFolderLayoutAndDownloadsFilesAndZip(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+	files := map[string]string{
+		"logs/app.log":       "started\nstopped\n",
+		"logs/nested/db.log": "connected\n",
+	}
+	recorder := uploadBundleAs(router, "alice", map[string]string{"expiresIn": "1d"}, []string{"logs/app.log", "logs/nested/db.log"}, files)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var saved models.SaveBundleResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "logs" || len(saved.Files) != 2 || saved.Size != int64(len(files["logs/app.log"])+len(files["logs/nested/db.log"])) {
+		t.Fatalf("unexpected bundle: %#v", saved)
+	}
+	if item, _ := app.ClipboardStore.Get(saved.ID); item.Type != "bundle" || models.ClipboardItemSize(item) != saved.Size {
+		t.Fatalf("expected a bundle item counting its total size, got %#v", item)
+	}
+
+	recorder = sendAs(router, "alice", http.MethodGet, "/api/bundle/"+saved.ID, "")
+	var listed models.SaveBundleResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listed); err != nil || listed.Files[1].Path != "logs/nested/db.log" {
+		t.Fatalf("unexpected listing %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = sendAs(router, "alice", http.MethodGet, "/api/bundle/"+saved.ID+"/files/logs/nested/db.log", "")
+	if recorder.Code != http.StatusOK || recorder.Body.String() != files["logs/nested/db.log"] {
+		t.Fatalf("unexpected file download %d: %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = sendAs(router, "alice", http.MethodGet, "/api/bundle/"+saved.ID+"/zip", "")
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("unexpected zip download %d: %v", recorder.Code, recorder.Header())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.File) != 2 {
+		t.Fatalf("expected 2 zip entries, got %d", len(archive.File))
+	}
+	for _, entry := range archive.File {
+		content, err := entry.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(content)
+		content.Close()
+		if string(data) != files[entry.Name] {
+			t.Fatalf("zip entry %s holds %q", entry.Name, data)
+		}
+	}
+}
+
+func TestBundleRefusesPathsOutsideTheBundle(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+	for _, path := range []string{"../etc/passwd", "/etc/passwd", "logs//app.log", "logs/./app.log"} {
+		recorder := uploadBundleAs(router, "alice", nil, []string{"ok.txt", path}, map[string]string{"ok.txt": "ok", path: "x"})
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %q, got %d: %s", path, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder := uploadBundleAs(router, "alice", nil, []string{"a.txt", "A.txt"}, map[string]string{"a.txt": "1", "A.txt": "2"})
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a duplicate path, got %d", recorder.Code)
+	}
+	if items := app.ClipboardStore.ListAll(); len(items) != 0 {
+		t.Fatalf("expected no items after refused uploads, got %d", len(items))
+	}
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	cryptoRand "crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math/big"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
@@ -25,29 +29,36 @@ func (h *Handler) SaveText(c *gin.Context) {
 		return
 	}
 
+	options, err := newItemOptions(request.ItemOptions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if !h.App.Security.ValidateContentRequest(c, request.Content) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
 		return
 	}
 
-	id := h.generateShortID()
 	user := c.MustGet("user").(*models.User)
-	createdAt := time.Now().UTC()
-	item := &models.ClipboardItem{
-		ID:        id,
-		Type:      "text",
-		UserID:    user.ID,
-		Content:   request.Content,
-		CreatedAt: createdAt,
-		ExpiresAt: h.clipboardExpiresAt(createdAt),
+	if !h.checkQuota(c, user, int64(len(request.Content))) {
+		return
+	}
+
+	item, err := h.newItem(user, "text", options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	item.Content = request.Content
 
-	h.App.DataMutex.Lock()
-	h.App.ClipboardData[id] = item
-	h.App.DataMutex.Unlock()
+	if err := h.App.ClipboardStore.Put(item); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save text"})
+		return
+	}
 
 	c.JSON(http.StatusOK, models.SaveTextResponse{
-		ID:        id,
+		ID:        item.ID,
 		ExpiresAt: item.ExpiresAt,
 	})
 }
@@ -61,110 +72,161 @@ func (h *Handler) GetText(c *gin.Context) {
 		return
 	}
 
-	h.App.DataMutex.RLock()
-	item, exists := h.App.ClipboardData[id]
-	h.App.DataMutex.RUnlock()
+	item, exists := h.App.ClipboardStore.Get(id)
+
+	if !exists || item.Type != "text" || models.ClipboardItemExpired(item, time.Now().UTC()) || !canViewItem(c, item) {
+		h.App.Security.LogAccess(c, id, "text", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	if !h.checkItemPassword(c, item) {
+		return
+	}
 
-	if !exists || item.Type != "text" || models.ClipboardItemExpired(item, time.Now().UTC()) {
+	item, lastView, err := h.consumeRead(item)
+	if err != nil {
+		log.Printf("Failed to record read of %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load text"})
+		return
+	}
+	if item == nil {
 		h.App.Security.LogAccess(c, id, "text", false)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
 		return
 	}
 
 	h.App.Security.LogAccess(c, id, "text", true)
+	h.recordOpened(c, item)
+	c.Header("Cache-Control", "no-store")
+	c.Header("ETag", textETag(item))
 	c.JSON(http.StatusOK, models.GetTextResponse{
 		Content:   item.Content,
 		CreatedAt: item.CreatedAt,
+		Revision:  models.CurrentTextRevision(item),
+		LastView:  lastView,
 	})
 }
 
-// SaveFile handles saving a file to clipboard
+// SaveFile handles saving a file to clipboard. The multipart body is read as
+// a stream: the file part goes straight into the blob store, which hashes it
+// on the way, while the first bytes are kept for content sniffing. Uploads
+// over the size limit are cut off as soon as the limit is crossed.
 func (h *Handler) SaveFile(c *gin.Context) {
 	if !h.App.Security.ValidateFileRequest(c) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
 		return
 	}
 
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+	user := c.MustGet("user").(*models.User)
+	if !h.checkQuota(c, user, 0) {
 		return
 	}
-	defer file.Close()
 
-	if !h.App.Security.ValidateFileType(header.Filename) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File type not allowed"})
+	// Leave room for multipart boundaries and headers around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, models.MaxFileSize+64*1024)
+	part, fields, err := nextFilePart(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
 		return
 	}
+	defer part.Close()
 
-	if header.Size > 50*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 50MB)"})
-		return
+	options, err := parseItemOptions(func(key string) string { return fields[key] })
+	if err == nil {
+		err = h.checkItemOptions(user, options)
 	}
-
-	contentType, err := detectUploadedContentType(file, header.Header.Get("Content-Type"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to inspect file"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	id := h.generateShortID()
-	user := c.MustGet("user").(*models.User)
-	filePath := filepath.Join(h.App.TempDir, fmt.Sprintf("%s_%s", id, header.Filename))
-
-	dst, err := os.Create(filePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+	fileName := part.FileName()
+	if !h.App.Security.ValidateFileType(fileName) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File type not allowed"})
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		os.Remove(filePath)
+	sniffer := &contentSniffer{}
+	content := io.TeeReader(&sizeLimitedReader{r: part, limit: models.MaxFileSize}, sniffer)
+	item, err := h.storeFileItem(user, fileName, content, func() string {
+		return sniffer.ContentType(part.Header.Get("Content-Type"))
+	}, options)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, errFileTooLarge) || errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large (max 50MB)"})
+			return
+		}
+		if errors.Is(err, errInvalidItemOption) || errors.Is(err, errUnreadableImage) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Failed to save uploaded file: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
 
-	createdAt := time.Now().UTC()
-	item := &models.ClipboardItem{
-		ID:          id,
-		Type:        "file",
-		UserID:      user.ID,
-		FileName:    header.Filename,
-		FilePath:    filePath,
-		ContentType: contentType,
-		CreatedAt:   createdAt,
-		ExpiresAt:   h.clipboardExpiresAt(createdAt),
+	// The real size is only known now; undo the upload if it broke the quota.
+	if !h.enforceQuota(c, user, 0, 0) {
+		if removed, _ := h.App.ClipboardStore.Delete(item.ID); removed != nil {
+			releaseItem(h.App, removed)
+		}
+		return
 	}
 
-	h.App.DataMutex.Lock()
-	h.App.ClipboardData[id] = item
-	h.App.DataMutex.Unlock()
-
 	c.JSON(http.StatusOK, models.SaveFileResponse{
-		ID:          id,
-		FileName:    header.Filename,
-		ContentType: contentType,
-		ExpiresAt:   item.ExpiresAt,
+		ID:               item.ID,
+		FileName:         fileName,
+		ContentType:      item.ContentType,
+		ExpiresAt:        item.ExpiresAt,
+		MetadataStripped: item.MetadataStripped,
 	})
 }
 
-// ListRecentItems returns the current user's unexpired clipboard items.
+// nextFilePart skips ahead to the "file" field of a multipart request
+// without buffering it in memory or on disk. Short form fields sent before
+// the file, such as item options, are collected on the way.
+func nextFilePart(request *http.Request) (*multipart.Part, map[string]string, error) {
+	reader, err := request.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := make(map[string]string)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, nil, err
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			return part, fields, nil
+		}
+		if part.FileName() == "" && len(fields) < 16 {
+			value, _ := io.ReadAll(io.LimitReader(part, 1024))
+			fields[part.FormName()] = string(value)
+		}
+		part.Close()
+	}
+}
+
+// ListRecentItems returns the current user's unexpired clipboard items,
+// optionally only those with a tag or in a collection.
 func (h *Handler) ListRecentItems(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 	now := time.Now().UTC()
+	tag, collection := c.Query("tag"), c.Query("collection")
 	items := make([]models.RecentItemResponse, 0)
 
-	h.App.DataMutex.RLock()
-	for _, item := range h.App.ClipboardData {
-		if item.UserID != user.ID || models.ClipboardItemExpired(item, now) {
+	for _, item := range h.App.ClipboardStore.ListByUser(user.ID) {
+		if models.ClipboardItemExpired(item, now) || !inTagAndCollection(item, tag, collection) {
 			continue
 		}
-		items = append(items, toRecentItemResponse(item))
+		items = append(items, h.toRecentItemResponse(item))
 	}
-	h.App.DataMutex.RUnlock()
 
 	sort.Slice(items, func(i, j int) bool {
+		if items[i].Pinned != items[j].Pinned {
+			return items[i].Pinned
+		}
 		return items[i].CreatedAt.After(items[j].CreatedAt)
 	})
 	if len(items) > 10 {
@@ -174,7 +236,7 @@ func (h *Handler) ListRecentItems(c *gin.Context) {
 	c.JSON(http.StatusOK, models.ListRecentItemsResponse{Items: items})
 }
 
-func toRecentItemResponse(item *models.ClipboardItem) models.RecentItemResponse {
+func (h *Handler) toRecentItemResponse(item *models.ClipboardItem) models.RecentItemResponse {
 	description := item.FileName
 	if item.Type == "text" {
 		description = textDescription(item.Content)
@@ -187,28 +249,109 @@ func toRecentItemResponse(item *models.ClipboardItem) models.RecentItemResponse
 		ContentType: item.ContentType,
 		CreatedAt:   item.CreatedAt,
 		ExpiresAt:   item.ExpiresAt,
+		MaxReads:    item.MaxReads,
+		ReadCount:   item.ReadCount,
+		Size:        models.ClipboardItemSize(item),
+		Width:       item.Width,
+		Height:      item.Height,
+		Thumbnail:   item.Thumbnail != nil,
+		Viewable:    inlineContentType(item) != "",
+
+		MetadataStripped:  item.MetadataStripped,
+		PasswordProtected: item.PasswordHash != "",
+		Pinned:            item.Pinned,
+		Tags:              item.Tags,
+		Collection:        item.CollectionID,
+		Visibility:        models.ItemVisibility(item),
+		SharedWith:        h.sharedWithUsernames(item),
+		OpenedBy:          h.openedByUsernames(item),
+	}
+}
+
+// canViewItem reports whether the caller may see item. Callers answer for
+// hidden items exactly as for missing ones, so item IDs cannot be probed.
+func canViewItem(c *gin.Context, item *models.ClipboardItem) bool {
+	user, ok := c.Get("user")
+	return ok && models.CanViewItem(item, user.(*models.User))
+}
+
+// storeFileItem puts content in the blob store and records it as a file
+// item owned by user. contentType is called once content has been read, so
+// it can sniff the bytes that went past. Images lose their metadata on the
+// way in, if the settings say so, and get their thumbnail made in the
+// background.
+func (h *Handler) storeFileItem(user *models.User, fileName string, content io.Reader, contentType func() string, options itemOptions) (*models.ClipboardItem, error) {
+	item, err := h.newItem(user, "file", options)
+	if err != nil {
+		return nil, err
+	}
+	if h.systemSettings().Clipboard.StripImageMetadata {
+		stripped, ok, err := stripImageMetadata(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file contents: %w", err)
+		}
+		defer stripped.Close()
+		content = stripped
+		item.MetadataStripped = ok
 	}
+	hash, size, err := h.App.Blobs.Put(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file contents: %w", err)
+	}
+	item.FileName = fileName
+	item.FileHash = hash
+	item.FileSize = size
+	item.ContentType = contentType()
+	if err := h.App.ClipboardStore.Put(item); err != nil {
+		releaseItem(h.App, item)
+		return nil, fmt.Errorf("failed to save file item: %w", err)
+	}
+	h.queueThumbnail(item)
+	return item, nil
+}
+
+var errFileTooLarge = errors.New("file exceeds the upload size limit")
+
+// sizeLimitedReader fails with errFileTooLarge as soon as more than limit
+// bytes have been read.
+type sizeLimitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
 }
 
-func detectUploadedContentType(file interface {
-	io.Reader
-	io.Seeker
-}, fallback string) (string, error) {
-	buffer := make([]byte, 512)
-	n, err := file.Read(buffer)
-	if err != nil && err != io.EOF {
-		return "", err
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, errFileTooLarge
 	}
-	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
-		return "", seekErr
+	return n, err
+}
+
+// contentSniffer keeps the first bytes written to it so the content type
+// can be detected without a second read of the upload.
+type contentSniffer struct {
+	head []byte
+}
+
+func (s *contentSniffer) Write(p []byte) (int, error) {
+	if room := 512 - len(s.head); room > 0 {
+		s.head = append(s.head, p[:min(room, len(p))]...)
 	}
-	if n > 0 {
-		return http.DetectContentType(buffer[:n]), nil
+	return len(p), nil
+}
+
+// ContentType detects the type from the sniffed bytes, falling back to the
+// client's claim only for empty files.
+func (s *contentSniffer) ContentType(fallback string) string {
+	if len(s.head) > 0 {
+		return http.DetectContentType(s.head)
 	}
 	if fallback != "" {
-		return fallback, nil
+		return fallback
 	}
-	return "application/octet-stream", nil
+	return "application/octet-stream"
 }
 
 func textDescription(content string) string {
@@ -219,7 +362,9 @@ func textDescription(content string) string {
 	return string([]rune(content)[:50]) + "..."
 }
 
-// GetFile handles retrieving a file from clipboard
+// GetFile handles retrieving a file from clipboard. With ?inline=true, files
+// of a safe type are shown in the browser instead of downloaded; any other
+// file is still sent as an attachment.
 func (h *Handler) GetFile(c *gin.Context) {
 	id := strings.ToLower(c.Param("id"))
 
@@ -228,30 +373,146 @@ func (h *Handler) GetFile(c *gin.Context) {
 		return
 	}
 
-	h.App.DataMutex.RLock()
-	item, exists := h.App.ClipboardData[id]
-	h.App.DataMutex.RUnlock()
+	item, exists := h.App.ClipboardStore.Get(id)
+
+	if !exists || item.Type != "file" || models.ClipboardItemExpired(item, time.Now().UTC()) || !canViewItem(c, item) {
+		h.App.Security.LogAccess(c, id, "file", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	if !h.checkItemPassword(c, item) {
+		return
+	}
+	var inlineType string
+	if wantsInline(c) {
+		inlineType = inlineContentType(item)
+	}
+
+	// A presigned URL could be reused, so read-limited files are always
+	// served through here where each download is counted. Inline views are
+	// too, since the storage service would not send their sandbox headers.
+	if signer, ok := h.App.Blobs.(models.BlobURLSigner); ok && item.FilePath == "" && item.MaxReads == 0 && inlineType == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(item.FileName))
+		downloadURL, err := signer.DownloadURL(item.FileHash, contentDispositionHeader(item.FileName), contentType)
+		if err != nil {
+			log.Printf("Failed to presign download for %s: %v", id, err)
+		} else if downloadURL != "" {
+			h.App.Security.LogAccess(c, id, "file", true)
+			h.recordOpened(c, item)
+			c.Header("Cache-Control", "no-store")
+			c.Redirect(http.StatusFound, downloadURL)
+			return
+		}
+	}
 
-	if !exists || item.Type != "file" || models.ClipboardItemExpired(item, time.Now().UTC()) {
+	item, lastView, err := h.consumeRead(item)
+	if err != nil {
+		log.Printf("Failed to record read of %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download file"})
+		return
+	}
+	if item == nil {
 		h.App.Security.LogAccess(c, id, "file", false)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
 		return
 	}
+	if lastView {
+		defer releaseItem(h.App, item)
+	}
 
-	if _, err := os.Stat(item.FilePath); os.IsNotExist(err) {
+	content, err := openItemFile(h.App, item)
+	if err != nil {
 		h.App.Security.LogAccess(c, id, "file", false)
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on disk"})
 		return
 	}
+	defer content.Close()
 
 	h.App.Security.LogAccess(c, id, "file", true)
-	c.Header("Content-Disposition", contentDispositionHeader(item.FileName))
-	c.File(item.FilePath)
+	h.recordOpened(c, item)
+	if inlineType != "" {
+		setInlineHeaders(c, inlineType, item.FileName)
+	} else {
+		c.Header("Content-Disposition", contentDispositionHeader(item.FileName))
+	}
+	serveItemContent(c, item, lastView, item.FileName, content)
+}
+
+// serveItemContent writes an item's contents. Every request for a
+// read-limited item counts as a read, so those always get the whole body
+// rather than a range, and the last one is marked with Clipboard-Last-View.
+func serveItemContent(c *gin.Context, item *models.ClipboardItem, lastView bool, name string, content io.ReadSeeker) {
+	if item.MaxReads > 0 {
+		c.Request.Header.Del("Range")
+		c.Header("Cache-Control", "no-store")
+	}
+	if lastView {
+		c.Header(clipboardLastViewHeader, "true")
+	}
+	http.ServeContent(c.Writer, c.Request, name, item.CreatedAt, content)
+}
+
+// openItemFile opens a file item's contents from the blob store, or from its
+// own path for items saved before the blob store existed.
+func openItemFile(app *models.App, item *models.ClipboardItem) (io.ReadSeekCloser, error) {
+	if item.FileHash == "" {
+		return os.Open(item.FilePath)
+	}
+	return app.Blobs.Open(item.FileHash)
+}
+
+// releaseItem lets go of what a removed item held: its share links and its
+// references to file contents. Shared blobs stay on disk until the last item
+// referencing them is gone.
+func releaseItem(app *models.App, item *models.ClipboardItem) {
+	if app.Shares != nil {
+		if err := app.Shares.DeleteByItem(item.ID); err != nil {
+			log.Printf("Failed to revoke shares of item %s: %v", item.ID, err)
+		}
+	}
+	for _, hash := range itemBlobHashes(item) {
+		if err := app.Blobs.Release(hash); err != nil {
+			log.Printf("Failed to release blob %s of item %s: %v", hash, item.ID, err)
+		}
+	}
+	if (item.Type == "file" || models.IsSecretItemType(item.Type)) && item.FileHash == "" && item.FilePath != "" {
+		os.Remove(item.FilePath)
+	}
+}
+
+// itemBlobHashes lists the blob references item holds: the files of a
+// bundle, or the contents and thumbnail of a file. A hash appears once per
+// reference.
+func itemBlobHashes(item *models.ClipboardItem) []string {
+	hashes := make([]string, 0)
+	if item.Type == "bundle" {
+		for _, file := range item.Files {
+			hashes = append(hashes, file.Hash)
+		}
+		return hashes
+	}
+	if item.Type != "file" && !models.IsSecretItemType(item.Type) {
+		return hashes
+	}
+	if item.Thumbnail != nil {
+		hashes = append(hashes, item.Thumbnail.Hash)
+	}
+	if item.FileHash != "" {
+		hashes = append(hashes, item.FileHash)
+	}
+	return hashes
 }
 
 func contentDispositionHeader(fileName string) string {
+	return dispositionHeader("attachment", fileName)
+}
+
+// dispositionHeader builds a Content-Disposition of the given type with an
+// ASCII file name for old clients and the exact one for the rest.
+func dispositionHeader(disposition, fileName string) string {
 	return fmt.Sprintf(
-		"attachment; filename=\"%s\"; filename*=UTF-8''%s",
+		"%s; filename=\"%s\"; filename*=UTF-8''%s",
+		disposition,
 		asciiFallbackFileName(fileName),
 		url.PathEscape(fileName),
 	)
@@ -280,57 +541,238 @@ func isSafeASCIIFileName(fileName string) bool {
 	return true
 }
 
-// DeleteItem handles deleting a clipboard item
+// DeleteItem handles deleting a clipboard item. Only the owner or an admin
+// may delete it; anyone else is told it does not exist.
 func (h *Handler) DeleteItem(c *gin.Context) {
 	id := strings.ToLower(c.Param("id"))
 
-	h.App.DataMutex.Lock()
-	item, exists := h.App.ClipboardData[id]
-	if exists {
-		delete(h.App.ClipboardData, id)
+	user := c.MustGet("user").(*models.User)
+	if item, exists := h.App.ClipboardStore.Get(id); !exists || !models.CanEditItem(item, user) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+
+	item, err := h.App.ClipboardStore.Delete(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete item"})
+		return
 	}
-	h.App.DataMutex.Unlock()
 
-	if exists && item.Type == "file" && item.FilePath != "" {
-		os.Remove(item.FilePath)
+	if item != nil {
+		releaseItem(h.App, item)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Item deleted"})
 }
 
-// Cleanup handles cleaning up expired items
-func (h *Handler) Cleanup(c *gin.Context) {
-	removedCount := 0
+// UpdateItem changes an existing item's settings: whether it is pinned,
+// when it expires, who may see it, and its tags and collection. Only the
+// owner or an admin may change an item, and the new expiration is held to
+// the caller's retention cap counted from when the item was created. The
+// request is checked first and then applied in one store update, so reads
+// and opens recorded meanwhile are kept.
+func (h *Handler) UpdateItem(c *gin.Context) {
+	id := strings.ToLower(c.Param("id"))
+
+	var request models.UpdateItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	item, ok := h.editableItem(c, id)
+	if !ok {
+		return
+	}
+
 	now := time.Now().UTC()
+	var unpinnedExpiresAt time.Time
+	if request.Pinned != nil && *request.Pinned && !item.Pinned && !h.checkPinLimit(c, item.UserID) {
+		return
+	}
+	if request.Pinned != nil && !*request.Pinned {
+		// An item whose expiry passed while it was pinned gets a fresh
+		// default lifetime, rather than vanishing at the next cleanup.
+		var err error
+		if unpinnedExpiresAt, err = h.itemExpiresAt(user, now, models.ItemExpiration{}); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var expiresAt time.Time
+	if request.Expiration != nil {
+		var err error
+		expiresAt, err = h.itemExpiresAt(user, item.CreatedAt, *request.Expiration)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var visibility string
+	var sharedWith []string
+	if request.Visibility != nil || request.SharedWith != nil {
+		var err error
+		visibility, sharedWith, err = h.resolveVisibility(item, request.Visibility, request.SharedWith)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var tags []string
+	if request.Tags != nil {
+		var err error
+		if tags, err = normalizeTags(*request.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var collection string
+	if request.Collection != nil {
+		// The item goes into one of its owner's collections, also when an
+		// admin moves it.
+		collection = strings.TrimSpace(*request.Collection)
+		if _, ok := h.ownCollection(item.UserID, collection); collection != "" && !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown collection %q", collection)})
+			return
+		}
+	}
 
-	h.App.DataMutex.Lock()
-	for id, item := range h.App.ClipboardData {
-		if models.ClipboardItemExpired(item, now) {
-			if item.Type == "file" && item.FilePath != "" {
-				os.Remove(item.FilePath)
+	updated, err := h.App.ClipboardStore.Update(id, func(item *models.ClipboardItem) error {
+		if request.Pinned != nil && *request.Pinned != item.Pinned {
+			item.Pinned = *request.Pinned
+			if !item.Pinned && models.ClipboardItemExpired(item, now) {
+				item.ExpiresAt = unpinnedExpiresAt
 			}
-			delete(h.App.ClipboardData, id)
-			removedCount++
 		}
+		if request.Expiration != nil {
+			item.ExpiresAt = expiresAt
+		}
+		if request.Visibility != nil || request.SharedWith != nil {
+			item.Visibility = visibility
+			item.SharedWith = sharedWith
+			item.OpenedAt = openedByRecipients(item.OpenedAt, sharedWith)
+		}
+		if request.Tags != nil {
+			item.Tags = tags
+		}
+		if request.Collection != nil {
+			item.CollectionID = collection
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update item"})
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toRecentItemResponse(updated))
+}
+
+// checkPinLimit writes the error response and returns false when the owner
+// already has as many pinned items as allowed.
+func (h *Handler) checkPinLimit(c *gin.Context, ownerID string) bool {
+	maxPinned := h.systemSettings().Clipboard.MaxPinnedItems
+	if maxPinned > 0 && h.pinnedItemCount(ownerID) >= maxPinned {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("At most %d items can be pinned, unpin one first", maxPinned)})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) pinnedItemCount(userID string) int {
+	count := 0
+	for _, item := range h.App.ClipboardStore.ListByUser(userID) {
+		if item.Pinned {
+			count++
+		}
+	}
+	return count
+}
+
+// resolveVisibility works out the visibility and recipient IDs a change
+// gives item. Either part may be left out: a visibility alone keeps the
+// current users unless it is private, and a user list alone switches a
+// private item to the "users" visibility (or a "users" item back to
+// private when empty).
+func (h *Handler) resolveVisibility(item *models.ClipboardItem, visibility *string, sharedWith *[]string) (string, []string, error) {
+	next := models.ItemVisibility(item)
+	if visibility != nil {
+		next = *visibility
+	}
+	var usernames []string
+	switch {
+	case sharedWith != nil:
+		usernames = *sharedWith
+	case next != models.VisibilityPrivate:
+		usernames = h.sharedWithUsernames(item)
+	}
+	if visibility == nil && next != models.VisibilityEveryone {
+		next = models.VisibilityPrivate
+		if len(usernames) > 0 {
+			next = models.VisibilityUsers
+		}
+	}
+	if err := checkVisibility(next, usernames); err != nil {
+		return "", nil, err
+	}
+	ids, err := h.resolveSharedWith(usernames)
+	if err != nil {
+		return "", nil, err
+	}
+	return next, ids, nil
+}
+
+// editableItem loads an unexpired item the caller may change: their own, or
+// any item for admins. Other items are reported as missing.
+func (h *Handler) editableItem(c *gin.Context, id string) (*models.ClipboardItem, bool) {
+	user := c.MustGet("user").(*models.User)
+	item, exists := h.App.ClipboardStore.Get(id)
+	if !exists || !models.CanEditItem(item, user) || models.ClipboardItemExpired(item, time.Now().UTC()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return nil, false
+	}
+	return item, true
+}
+
+// Cleanup handles cleaning up expired items
+func (h *Handler) Cleanup(c *gin.Context) {
+	removedCount, err := RemoveExpiredItems(h.App, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clean up expired items"})
+		return
 	}
-	h.App.DataMutex.Unlock()
 
 	c.JSON(http.StatusOK, models.CleanupResponse{
 		RemovedCount: removedCount,
 	})
 }
 
+// RemoveExpiredItems deletes items that expired at now along with their
+// files. It backs both the admin cleanup endpoint and the cleanup ticker.
+func RemoveExpiredItems(app *models.App, now time.Time) (int, error) {
+	expired, err := app.ClipboardStore.ExpireBefore(now)
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range expired {
+		releaseItem(app, item)
+	}
+	return len(expired), nil
+}
+
 // generateShortID generates a unique short ID for clipboard items
 func (h *Handler) generateShortID() string {
-	h.App.DataMutex.RLock()
-	defer h.App.DataMutex.RUnlock()
-
 	for attempt := 0; attempt < 100; attempt++ {
 		id, err := generateRandomString(4)
 		if err != nil {
 			continue
 		}
-		if _, exists := h.App.ClipboardData[id]; !exists {
+		if _, exists := h.App.ClipboardStore.Get(id); !exists {
 			return id
 		}
 	}
@@ -339,12 +781,11 @@ func (h *Handler) generateShortID() string {
 	return id
 }
 
-func (h *Handler) clipboardExpiresAt(now time.Time) time.Time {
-	settings := models.DefaultSystemSettings()
-	if h.App.SettingsService != nil {
-		settings = h.App.SettingsService.GetSettings()
+func (h *Handler) systemSettings() models.SystemSettings {
+	if h.App.SettingsService == nil {
+		return models.DefaultSystemSettings()
 	}
-	return settings.Clipboard.ExpiresAt(now)
+	return h.App.SettingsService.GetSettings()
 }
 
 // generateRandomString generates a random alphanumeric string
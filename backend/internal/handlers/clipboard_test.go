@@ -10,7 +10,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"testing"
 	"time"
 
@@ -27,8 +26,32 @@ func (allowSecurityService) ValidateFileType(fileName string) bool
 func (allowSecurityService) ValidateAccessRequest(c interface{}) bool                   { return true }
 func (allowSecurityService) LogAccess(c interface{}, id, itemType string, success bool) {}
 func (allowSecurityService) CleanupExpired()                                            {}
+func (allowSecurityService) RecordFailedAttempt(c interface{}, reason string)           {}
 func (allowSecurityService) GetClientIP(c interface{}) string                           { return "127.0.0.1" }
 
+func newTestClipboardStore(t *testing.T, items ...*models.ClipboardItem) *services.FileClipboardStore {
+	t.Helper()
+	store, err := services.NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if err := store.Put(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func newTestBlobStore(t *testing.T) *services.ContentBlobStore {
+	t.Helper()
+	blobs, err := services.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blobs
+}
+
 func TestGetFileUsesRFC5987FilenameForUnicodeDownloads(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	tempDir := t.TempDir()
@@ -38,23 +61,22 @@ func TestGetFileUsesRFC5987FilenameForUnicodeDownloads(t *testing.T) {
 	}
 
 	app := &models.App{
-		ClipboardData: map[string]*models.ClipboardItem{
-			"abc1": {
-				ID:        "abc1",
-				Type:      "file",
-				FileName:  "中文 报告.txt",
-				FilePath:  filePath,
-				ExpiresAt: time.Now().UTC().Add(time.Minute),
-			},
-		},
-		DataMutex: &sync.RWMutex{},
-		Security:  allowSecurityService{},
+		ClipboardStore: newTestClipboardStore(t, &models.ClipboardItem{
+			ID:        "abc1",
+			Type:      "file",
+			UserID:    "user-1",
+			FileName:  "中文 报告.txt",
+			FilePath:  filePath,
+			ExpiresAt: time.Now().UTC().Add(time.Minute),
+		}),
+		Security: allowSecurityService{},
 	}
 	handler := &Handler{App: app}
 	recorder := httptest.NewRecorder()
 	context, _ := gin.CreateTestContext(recorder)
 	context.Request = httptest.NewRequest("GET", "/api/file/abc1", nil)
 	context.Params = gin.Params{{Key: "id", Value: "abc1"}}
+	context.Set("user", &models.User{ID: "user-1", Role: "user"})
 
 	handler.GetFile(context)
 
@@ -67,6 +89,53 @@ func TestGetFileUsesRFC5987FilenameForUnicodeDownloads(t *testing.T) {
 	}
 }
 
+// presigningBlobStore wraps a real store and hands out direct URLs.
+type presigningBlobStore struct {
+	*services.ContentBlobStore
+}
+
+func (presigningBlobStore) DownloadURL(hash, contentDisposition, contentType string) (string, error) {
+	return "https://objects.example.com/" + hash + "?ct=" + contentType, nil
+}
+
+func TestGetFileRedirectsToPresignedURLWhenAvailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	blobs := presigningBlobStore{newTestBlobStore(t)}
+	hash, size, err := blobs.Put(strings.NewReader("report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &models.App{
+		ClipboardStore: newTestClipboardStore(t, &models.ClipboardItem{
+			ID:        "abc2",
+			Type:      "file",
+			UserID:    "user-1",
+			FileName:  "report.txt",
+			FileHash:  hash,
+			FileSize:  size,
+			ExpiresAt: time.Now().UTC().Add(time.Minute),
+		}),
+		Blobs:    blobs,
+		Security: allowSecurityService{},
+	}
+	handler := &Handler{App: app}
+	recorder := httptest.NewRecorder()
+	context, _ := gin.CreateTestContext(recorder)
+	context.Request = httptest.NewRequest("GET", "/api/file/abc2", nil)
+	context.Params = gin.Params{{Key: "id", Value: "abc2"}}
+	context.Set("user", &models.User{ID: "user-1", Role: "user"})
+
+	handler.GetFile(context)
+
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", recorder.Code)
+	}
+	if location := recorder.Header().Get("Location"); !strings.HasPrefix(location, "https://objects.example.com/"+hash+"?ct=text/plain") {
+		t.Fatalf("unexpected redirect target %q", location)
+	}
+}
+
 func TestSaveFileDetectsImageContentTypeFromFileContent(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	body := &bytes.Buffer{}
@@ -83,10 +152,9 @@ func TestSaveFileDetectsImageContentTypeFromFileContent(t *testing.T) {
 	}
 
 	app := &models.App{
-		ClipboardData: map[string]*models.ClipboardItem{},
-		DataMutex:     &sync.RWMutex{},
-		TempDir:       t.TempDir(),
-		Security:      allowSecurityService{},
+		ClipboardStore: newTestClipboardStore(t),
+		Blobs:          newTestBlobStore(t),
+		Security:       allowSecurityService{},
 	}
 	handler := &Handler{App: app}
 	recorder := httptest.NewRecorder()
@@ -107,11 +175,11 @@ func TestSaveFileDetectsImageContentTypeFromFileContent(t *testing.T) {
 	if response.ContentType != "image/png" {
 		t.Fatalf("expected image/png response content type, got %#v", response)
 	}
-	for _, item := range app.ClipboardData {
+	for _, item := range app.ClipboardStore.ListByUser("user-1") {
 		if item.ContentType != "image/png" {
 			t.Fatalf("expected stored image/png content type, got %#v", item)
 		}
-		stored, err := os.Open(item.FilePath)
+		stored, err := app.Blobs.Open(item.FileHash)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -128,12 +196,83 @@ func TestSaveFileDetectsImageContentTypeFromFileContent(t *testing.T) {
 	t.Fatal("saved file item missing")
 }
 
+// countingReader counts how much of a request body the server consumed.
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+func TestSaveFileAbortsOversizedUploadWhileStreaming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	total := models.MaxFileSize * 2
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	go func() {
+		part, err := writer.CreateFormFile("file", "huge.bin")
+		if err == nil {
+			_, err = io.CopyN(part, zeroReader{}, total)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	body := &countingReader{r: pipeReader}
+
+	blobDir := t.TempDir()
+	blobs, err := services.NewLocalBlobStore(blobDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &models.App{
+		ClipboardStore: newTestClipboardStore(t),
+		Blobs:          blobs,
+		Security:       allowSecurityService{},
+	}
+	handler := &Handler{App: app}
+	recorder := httptest.NewRecorder()
+	context, _ := gin.CreateTestContext(recorder)
+	context.Request = httptest.NewRequest(http.MethodPost, "/api/file", body)
+	context.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	context.Set("user", &models.User{ID: "user-1", Username: "uploader"})
+
+	handler.SaveFile(context)
+	pipeReader.Close()
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if body.read >= total {
+		t.Fatalf("server read the whole %d byte body before rejecting it", body.read)
+	}
+	if len(app.ClipboardStore.ListByUser("user-1")) != 0 {
+		t.Fatal("oversized upload should not create an item")
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(blobDir, ".upload-*"))
+	if len(leftovers) != 0 {
+		t.Fatalf("partial upload left behind: %v", leftovers)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
 func TestListRecentItemsShowsCurrentUsersUnexpiredItemsAcrossSessions(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	now := time.Now().UTC()
 	app := &models.App{
-		ClipboardData: map[string]*models.ClipboardItem{
-			"same1": {
+		ClipboardStore: newTestClipboardStore(t,
+			&models.ClipboardItem{
 				ID:        "same1",
 				Type:      "text",
 				UserID:    "user-1",
@@ -141,7 +280,7 @@ func TestListRecentItemsShowsCurrentUsersUnexpiredItemsAcrossSessions(t *testing
 				CreatedAt: now.Add(-1 * time.Minute),
 				ExpiresAt: now.Add(9 * time.Minute),
 			},
-			"same2": {
+			&models.ClipboardItem{
 				ID:          "same2",
 				Type:        "file",
 				UserID:      "user-1",
@@ -150,7 +289,7 @@ func TestListRecentItemsShowsCurrentUsersUnexpiredItemsAcrossSessions(t *testing
 				CreatedAt:   now.Add(-2 * time.Minute),
 				ExpiresAt:   now.Add(8 * time.Minute),
 			},
-			"other": {
+			&models.ClipboardItem{
 				ID:        "other",
 				Type:      "text",
 				UserID:    "user-2",
@@ -158,7 +297,7 @@ func TestListRecentItemsShowsCurrentUsersUnexpiredItemsAcrossSessions(t *testing
 				CreatedAt: now,
 				ExpiresAt: now.Add(10 * time.Minute),
 			},
-			"expired": {
+			&models.ClipboardItem{
 				ID:        "expired",
 				Type:      "text",
 				UserID:    "user-1",
@@ -166,9 +305,8 @@ func TestListRecentItemsShowsCurrentUsersUnexpiredItemsAcrossSessions(t *testing
 				CreatedAt: now.Add(-11 * time.Minute),
 				ExpiresAt: now.Add(-1 * time.Minute),
 			},
-		},
-		DataMutex: &sync.RWMutex{},
-		Security:  allowSecurityService{},
+		),
+		Security: allowSecurityService{},
 	}
 	handler := &Handler{App: app}
 	recorder := httptest.NewRecorder()
@@ -215,8 +353,7 @@ func TestSaveTextUsesConfiguredClipboardExpiration(t *testing.T) {
 		t.Fatal(err)
 	}
 	app := &models.App{
-		ClipboardData:   map[string]*models.ClipboardItem{},
-		DataMutex:       &sync.RWMutex{},
+		ClipboardStore:  newTestClipboardStore(t),
 		Security:        allowSecurityService{},
 		SettingsService: settingsService,
 	}
@@ -234,7 +371,7 @@ func TestSaveTextUsesConfiguredClipboardExpiration(t *testing.T) {
 	if recorder.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
 	}
-	for _, item := range app.ClipboardData {
+	for _, item := range app.ClipboardStore.ListByUser("user-1") {
 		min := before.Add(2 * time.Hour)
 		max := after.Add(2 * time.Hour)
 		if item.ExpiresAt.Before(min) || item.ExpiresAt.After(max) {
@@ -249,24 +386,23 @@ func TestCleanupKeepsNeverExpiringItems(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	now := time.Now().UTC()
 	app := &models.App{
-		ClipboardData: map[string]*models.ClipboardItem{
-			"never": {
+		ClipboardStore: newTestClipboardStore(t,
+			&models.ClipboardItem{
 				ID:        "never",
 				Type:      "text",
 				UserID:    "user-1",
 				Content:   "keep",
 				ExpiresAt: time.Time{},
 			},
-			"expired": {
+			&models.ClipboardItem{
 				ID:        "expired",
 				Type:      "text",
 				UserID:    "user-1",
 				Content:   "remove",
 				ExpiresAt: now.Add(-time.Minute),
 			},
-		},
-		DataMutex: &sync.RWMutex{},
-		Security:  allowSecurityService{},
+		),
+		Security: allowSecurityService{},
 	}
 	handler := &Handler{App: app}
 	recorder := httptest.NewRecorder()
@@ -275,10 +411,10 @@ func TestCleanupKeepsNeverExpiringItems(t *testing.T) {
 
 	handler.Cleanup(context)
 
-	if _, exists := app.ClipboardData["never"]; !exists {
+	if _, exists := app.ClipboardStore.Get("never"); !exists {
 		t.Fatal("never-expiring item should remain after cleanup")
 	}
-	if _, exists := app.ClipboardData["expired"]; exists {
+	if _, exists := app.ClipboardStore.Get("expired"); exists {
 		t.Fatal("expired item should be removed by cleanup")
 	}
 }
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const (
+	maxTagLength            = 32
+	maxTagsPerItem          = 20
+	maxCollectionNameLength = 64
+)
+
+// normalizeTags lower-cases and trims tags, dropping empty and duplicate
+// ones, so "Work" and "work " are the same tag.
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "" || slices.Contains(normalized, tag):
+			continue
+		case strings.Contains(tag, ","):
+			return nil, invalidItemOption("tags cannot contain commas")
+		case utf8.RuneCountInString(tag) > maxTagLength:
+			return nil, invalidItemOption("tags can be at most %d characters", maxTagLength)
+		}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerItem {
+		return nil, invalidItemOption("an item can have at most %d tags", maxTagsPerItem)
+	}
+	return normalized, nil
+}
+
+// applyCollectionDefaults checks that the collection options name is one of
+// user's and fills in its default expiration when the request gave none.
+func (h *Handler) applyCollectionDefaults(user *models.User, options itemOptions) (itemOptions, error) {
+	if options.Collection == "" {
+		return options, nil
+	}
+	collection, ok := h.ownCollection(user.ID, options.Collection)
+	if !ok {
+		return itemOptions{}, invalidItemOption("unknown collection %q", options.Collection)
+	}
+	if options.Expiration == (models.ItemExpiration{}) {
+		options.Expiration.ExpiresIn = collection.ExpiresIn
+	}
+	return options, nil
+}
+
+// ownCollection returns the collection with the given ID if userID owns it.
+func (h *Handler) ownCollection(userID, id string) (*models.Collection, bool) {
+	if h.App.Collections == nil {
+		return nil, false
+	}
+	collection, exists := h.App.Collections.Get(id)
+	if !exists || collection.UserID != userID {
+		return nil, false
+	}
+	return collection, true
+}
+
+// inTagAndCollection reports whether item carries tag and is filed in
+// collection; an empty filter matches every item.
+func inTagAndCollection(item *models.ClipboardItem, tag, collection string) bool {
+	if tag != "" && !slices.Contains(item.Tags, strings.ToLower(strings.TrimSpace(tag))) {
+		return false
+	}
+	return collection == "" || item.CollectionID == collection
+}
+
+// ListCollections returns the caller's collections by name, with how many
+// unexpired items each holds.
+func (h *Handler) ListCollections(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	now := time.Now().UTC()
+	counts := make(map[string]int)
+	for _, item := range h.App.ClipboardStore.ListByUser(user.ID) {
+		if item.CollectionID != "" && !models.ClipboardItemExpired(item, now) {
+			counts[item.CollectionID]++
+		}
+	}
+
+	collections := make([]models.CollectionResponse, 0)
+	for _, collection := range h.App.Collections.ListByUser(user.ID) {
+		response := toCollectionResponse(collection)
+		response.Items = counts[collection.ID]
+		collections = append(collections, response)
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		return strings.ToLower(collections[i].Name) < strings.ToLower(collections[j].Name)
+	})
+
+	c.JSON(http.StatusOK, models.ListCollectionsResponse{Collections: collections})
+}
+
+// CreateCollection adds a collection for the caller.
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Name == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	collection := &models.Collection{UserID: user.ID}
+	if !h.applyCollectionRequest(c, user, collection, request) {
+		return
+	}
+	if err := h.App.Collections.Create(collection); err != nil {
+		log.Printf("Failed to create collection: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toCollectionResponse(collection))
+}
+
+// UpdateCollection renames a collection or changes its default expiration.
+// Items already in it keep the expiration they were saved with.
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	collection, ok := h.ownCollection(user.ID, c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if !h.applyCollectionRequest(c, user, collection, request) {
+		return
+	}
+	if err := h.App.Collections.Put(collection); err != nil {
+		log.Printf("Failed to update collection %s: %v", collection.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toCollectionResponse(collection))
+}
+
+// DeleteCollection removes a collection. Its items are kept and simply no
+// longer filed anywhere.
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	collection, ok := h.ownCollection(user.ID, c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err := h.App.Collections.Delete(collection.ID); err != nil {
+		log.Printf("Failed to delete collection %s: %v", collection.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+
+	for _, item := range h.App.ClipboardStore.ListByUser(user.ID) {
+		if item.CollectionID != collection.ID {
+			continue
+		}
+		_, err := h.App.ClipboardStore.Update(item.ID, func(item *models.ClipboardItem) error {
+			if item.CollectionID == collection.ID {
+				item.CollectionID = ""
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to take item %s out of deleted collection %s: %v", item.ID, collection.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+}
+
+// applyCollectionRequest validates the fields request sets and copies them
+// onto collection, writing a 400 or 409 when they are not acceptable.
+func (h *Handler) applyCollectionRequest(c *gin.Context, user *models.User, collection *models.Collection, request models.CollectionRequest) bool {
+	if request.Name != nil {
+		name := strings.TrimSpace(*request.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Collection names must be 1 to 64 characters"})
+			return false
+		}
+		for _, other := range h.App.Collections.ListByUser(user.ID) {
+			if other.ID != collection.ID && strings.EqualFold(other.Name, name) {
+				c.JSON(http.StatusConflict, gin.H{"error": "You already have a collection with that name"})
+				return false
+			}
+		}
+		collection.Name = name
+	}
+	if request.ExpiresIn != nil {
+		expiresIn := strings.TrimSpace(*request.ExpiresIn)
+		// Check the default against the caller's retention cap as an item
+		// saved now would be.
+		if expiresIn != "" {
+			if _, err := h.itemExpiresAt(user, time.Now().UTC(), models.ItemExpiration{ExpiresIn: expiresIn}); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+		}
+		collection.ExpiresIn = expiresIn
+	}
+	return true
+}
+
+func toCollectionResponse(collection *models.Collection) models.CollectionResponse {
+	return models.CollectionResponse{
+		ID:        collection.ID,
+		Name:      collection.Name,
+		ExpiresIn: collection.ExpiresIn,
+		CreatedAt: collection.CreatedAt,
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestCollectionsFileItemsAndApplyTheirDefaults(t *testing.T) {
+	app := newTestApp(t, func(settings *models.SystemSettings) { settings.Retention.User.MaxMinutes = 7 * 24 * 60 })
+	collections := app.Collections
+	router := newTestRouter(app)
+	collections.Create(&models.Collection{UserID: "user-2", Name: "Theirs"})
+	theirs := collections.ListByUser("user-2")[0]
+
+	recorder := sendAs(router, "user-1", http.MethodPost, "/api/collections", `{"name":"Snippets","expiresIn":"1d"}`)
+	var snippets models.CollectionResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &snippets); err != nil || recorder.Code != http.StatusCreated {
+		t.Fatalf("create failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	for body, want := range map[string]int{
+		`{"name":"snippets"}`:                  http.StatusConflict,
+		`{"name":"  "}`:                        http.StatusBadRequest,
+		`{"name":"Forever","expiresIn":"30d"}`: http.StatusBadRequest,
+	} {
+		if recorder := sendAs(router, "user-1", http.MethodPost, "/api/collections", body); recorder.Code != want {
+			t.Fatalf("%s: expected %d, got %d", body, want, recorder.Code)
+		}
+	}
+
+	save := func(body string) models.SaveTextResponse {
+		t.Helper()
+		recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", body)
+		var saved models.SaveTextResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || recorder.Code != http.StatusOK {
+			t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+		}
+		return saved
+	}
+	filed := save(`{"content":"SELECT 1","tags":["SQL"," work ","sql"],"collection":"` + snippets.ID + `"}`)
+	if lifetime := time.Until(filed.ExpiresAt); lifetime < 23*time.Hour || lifetime > 25*time.Hour {
+		t.Fatalf("expected the collection's one day default, got %v", lifetime)
+	}
+	explicit := save(`{"content":"SELECT 2","collection":"` + snippets.ID + `","expiresIn":"1h"}`)
+	if lifetime := time.Until(explicit.ExpiresAt); lifetime > 2*time.Hour {
+		t.Fatalf("expected an explicit expiration to win over the default, got %v", lifetime)
+	}
+	save(`{"content":"unfiled","tags":["work"]}`)
+	if recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", `{"content":"x","collection":"`+theirs.ID+`"}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected another user's collection to be refused, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/"+explicit.ID, `{"tags":["a,b"]}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected a tag with a comma to be refused, got %d", recorder.Code)
+	}
+
+	listIDs := func(query string) []string {
+		t.Helper()
+		var list models.ListRecentItemsResponse
+		json.Unmarshal(sendAs(router, "user-1", http.MethodGet, "/api/items"+query, "").Body.Bytes(), &list)
+		ids := make([]string, 0, len(list.Items))
+		for _, item := range list.Items {
+			ids = append(ids, item.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got := listIDs("?tag=Work"); len(got) != 2 || !slices.Contains(got, filed.ID) {
+		t.Fatalf("expected both work items, got %v", got)
+	}
+	want := []string{filed.ID, explicit.ID}
+	slices.Sort(want)
+	if got := listIDs("?collection=" + snippets.ID); !slices.Equal(got, want) {
+		t.Fatalf("expected the filed items %v, got %v", want, got)
+	}
+	if got := listIDs("?collection=" + snippets.ID + "&tag=sql"); !slices.Equal(got, []string{filed.ID}) {
+		t.Fatalf("expected filters to combine, got %v", got)
+	}
+
+	var list models.ListCollectionsResponse
+	json.Unmarshal(sendAs(router, "user-1", http.MethodGet, "/api/collections", "").Body.Bytes(), &list)
+	if len(list.Collections) != 1 || list.Collections[0].Items != 2 {
+		t.Fatalf("expected one collection with two items, got %+v", list.Collections)
+	}
+	if recorder := sendAs(router, "user-1", http.MethodDelete, "/api/collections/"+theirs.ID, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected another user's collection to look missing, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "user-1", http.MethodDelete, "/api/collections/"+snippets.ID, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("delete failed: %d", recorder.Code)
+	}
+	item, exists := app.ClipboardStore.Get(filed.ID)
+	if !exists || item.CollectionID != "" || !slices.Equal(item.Tags, []string{"sql", "work"}) {
+		t.Fatalf("expected the item to stay, out of the collection and with its tags, got %+v", item)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+	"web-clipboard-go/backend/internal/services"
+)
+
+// newTestApp builds an App whose stores live in temporary directories, with
+// the users alice, bob and carol besides the default admin. configure, when
+// not nil, changes the system settings before the test starts.
+func newTestApp(t *testing.T, configure func(*models.SystemSettings), items ...*models.ClipboardItem) *models.App {
+	t.Helper()
+	settingsService, err := services.NewSettingsService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configure != nil {
+		settings := settingsService.GetSettings()
+		configure(&settings)
+		if err := settingsService.SaveSettings(settings); err != nil {
+			t.Fatal(err)
+		}
+	}
+	userManager, err := services.NewUserManager(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := userManager.CreateUser(name, "password-123", name+"@example.com", "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shares, err := services.NewFileShareStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads, err := services.NewResumableUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	collections, err := services.NewFileCollectionStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Thumbnails are made in the background; let them finish before the
+	// temporary directories go.
+	t.Cleanup(pendingThumbnails.Wait)
+	return &models.App{
+		ClipboardStore:  newTestClipboardStore(t, items...),
+		Blobs:           newTestBlobStore(t),
+		Uploads:         uploads,
+		Shares:          shares,
+		Collections:     collections,
+		RateLimiter:     services.NewRateLimitService(),
+		Security:        allowSecurityService{},
+		UserManager:     userManager,
+		SettingsService: settingsService,
+	}
+}
+
+// newTestRouter serves the API routes of main to the user named in the
+// X-Test-User header. A name with no account is taken as the ID of a plain
+// user, so tests can act for the owners of the items they start with.
+func newTestRouter(app *models.App) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := &Handler{App: app}
+	router := gin.New()
+	api := router.Group("/api", func(c *gin.Context) {
+		name := c.GetHeader("X-Test-User")
+		if user := app.UserManager.GetUserByUsername(name); user != nil {
+			c.Set("user", user)
+			return
+		}
+		c.Set("user", &models.User{ID: name, Username: name, Role: "user"})
+	})
+	api.POST("/text", handler.SaveText)
+	api.GET("/text/:id", handler.GetText)
+	api.PUT("/text/:id", handler.UpdateText)
+	api.GET("/text/:id/revisions", handler.ListTextRevisions)
+	api.GET("/text/:id/revisions/:revision", handler.GetTextRevision)
+	api.POST("/file", handler.SaveFile)
+	api.GET("/file/:id", handler.GetFile)
+	api.GET("/file/:id/thumbnail", handler.GetThumbnail)
+	api.POST("/bundle", handler.SaveBundle)
+	api.GET("/bundle/:id", handler.GetBundle)
+	api.GET("/bundle/:id/zip", handler.DownloadBundle)
+	api.GET("/bundle/:id/files/*path", handler.GetBundleFile)
+	api.POST("/secret", handler.SaveSecret)
+	api.GET("/secret/:id", handler.GetSecret)
+	api.GET("/items", handler.ListRecentItems)
+	api.GET("/items/received", handler.ListReceivedItems)
+	api.GET("/items/history", handler.ListHistory)
+	api.PATCH("/items/:id", handler.UpdateItem)
+	api.POST("/items/:id/recipients", handler.SendItem)
+	api.POST("/items/:id/shares", handler.CreateShare)
+	api.GET("/items/:id/shares", handler.ListShares)
+	api.DELETE("/items/:id/shares/:token", handler.DeleteShare)
+	api.GET("/collections", handler.ListCollections)
+	api.POST("/collections", handler.CreateCollection)
+	api.PATCH("/collections/:id", handler.UpdateCollection)
+	api.DELETE("/collections/:id", handler.DeleteCollection)
+	api.GET("/usage", handler.GetUsage)
+	api.GET("/search", handler.Search)
+	api.POST("/uploads", handler.CreateUpload)
+	api.HEAD("/uploads/:id", handler.GetUploadOffset)
+	api.PATCH("/uploads/:id", handler.PatchUpload)
+	api.DELETE("/uploads/:id", handler.DeleteUpload)
+	api.DELETE("/:id", handler.DeleteItem)
+	router.GET("/s/:token", handler.OpenShare)
+	router.POST("/s/:token", handler.OpenShare)
+	return router
+}
+
+// serveAs sends request as the named user, or with no X-Test-User header
+// when username is empty, and records the response.
+func serveAs(router http.Handler, username string, request *http.Request) *httptest.ResponseRecorder {
+	if username != "" {
+		request.Header.Set("X-Test-User", username)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// sendAs sends body to target as JSON from the named user.
+func sendAs(router http.Handler, username, method, target, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	return serveAs(router, username, request)
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// historyOrder sorts history by one number per item, ties broken by ID so
+// every item has a fixed place for cursors to point at.
+type historyOrder struct {
+	key        func(item *models.ClipboardItem) int64
+	descending bool
+}
+
+var historyOrders = map[string]historyOrder{
+	"newest":   {key: createdKey, descending: true},
+	"oldest":   {key: createdKey},
+	"largest":  {key: models.ClipboardItemSize, descending: true},
+	"smallest": {key: models.ClipboardItemSize},
+	"expiring": {key: expiresKey},
+}
+
+func createdKey(item *models.ClipboardItem) int64 {
+	return item.CreatedAt.UnixNano()
+}
+
+// expiresKey puts items that never expire, pinned ones included, last.
+func expiresKey(item *models.ClipboardItem) int64 {
+	if item.Pinned || item.ExpiresAt.IsZero() {
+		return math.MaxInt64
+	}
+	return item.ExpiresAt.UnixNano()
+}
+
+// historyPosition is where an item sits in a history order.
+type historyPosition struct {
+	Key int64  `json:"k"`
+	ID  string `json:"i"`
+}
+
+func (o historyOrder) position(item *models.ClipboardItem) historyPosition {
+	return historyPosition{Key: o.key(item), ID: item.ID}
+}
+
+// before reports whether a comes before b in this order.
+func (o historyOrder) before(a, b historyPosition) bool {
+	if o.descending {
+		a, b = b, a
+	}
+	if a.Key != b.Key {
+		return a.Key < b.Key
+	}
+	return a.ID < b.ID
+}
+
+// historyCursor is the position of the last item on a page, and the order it
+// was taken from so it cannot be used with another one. It is handed to
+// clients as opaque base64.
+type historyCursor struct {
+	Sort string `json:"s"`
+	historyPosition
+}
+
+func encodeHistoryCursor(cursor historyCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHistoryCursor(value, sort string) (historyPosition, error) {
+	var cursor historyCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.Sort != sort {
+		return historyPosition{}, errors.New("cursor is invalid or belongs to another sort order")
+	}
+	return cursor.historyPosition, nil
+}
+
+// historyQuery is a parsed history request. Unset filters match everything;
+// from is inclusive and to exclusive.
+type historyQuery struct {
+	types       []string
+	contentType string // a full type, or a prefix such as "image/"
+	from, to    time.Time
+	hasFile     *bool
+	pinned      *bool
+	tag         string
+	collection  string
+	sort        string
+	limit       int
+	after       *historyPosition
+}
+
+func parseHistoryQuery(c *gin.Context) (historyQuery, error) {
+	query := historyQuery{sort: "newest", limit: defaultHistoryLimit, tag: c.Query("tag"), collection: c.Query("collection")}
+
+	if raw := c.Query("type"); raw != "" {
+		for _, itemType := range strings.Split(raw, ",") {
+			itemType = strings.TrimSpace(itemType)
+			if !slices.Contains([]string{"text", "file", "bundle", "secret-text", "secret-file"}, itemType) {
+				return historyQuery{}, errors.New("type must be text, file, bundle, secret-text or secret-file")
+			}
+			query.types = append(query.types, itemType)
+		}
+	}
+	if raw := strings.ToLower(strings.TrimSpace(c.Query("contentType"))); raw != "" {
+		query.contentType = strings.TrimSuffix(raw, "*")
+	}
+	for _, bound := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &query.from}, {"to", &query.to}} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return historyQuery{}, fmt.Errorf("%s must be an RFC 3339 time", bound.name)
+		}
+		*bound.value = parsed
+	}
+	for _, flag := range []struct {
+		name  string
+		value **bool
+	}{{"hasFile", &query.hasFile}, {"pinned", &query.pinned}} {
+		raw := c.Query(flag.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return historyQuery{}, fmt.Errorf("%s must be true or false", flag.name)
+		}
+		*flag.value = &parsed
+	}
+	if raw := c.Query("sort"); raw != "" {
+		if _, ok := historyOrders[raw]; !ok {
+			return historyQuery{}, errors.New("sort must be newest, oldest, largest, smallest or expiring")
+		}
+		query.sort = raw
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxHistoryLimit {
+			return historyQuery{}, fmt.Errorf("limit must be between 1 and %d", maxHistoryLimit)
+		}
+		query.limit = limit
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		after, err := decodeHistoryCursor(raw, query.sort)
+		if err != nil {
+			return historyQuery{}, err
+		}
+		query.after = &after
+	}
+	return query, nil
+}
+
+func (q historyQuery) matches(item *models.ClipboardItem) bool {
+	if len(q.types) > 0 && !slices.Contains(q.types, item.Type) {
+		return false
+	}
+	if q.contentType != "" {
+		contentType, _, _ := mime.ParseMediaType(item.ContentType)
+		if strings.HasSuffix(q.contentType, "/") {
+			if !strings.HasPrefix(contentType, q.contentType) {
+				return false
+			}
+		} else if contentType != q.contentType {
+			return false
+		}
+	}
+	if !q.from.IsZero() && item.CreatedAt.Before(q.from) {
+		return false
+	}
+	if !q.to.IsZero() && !item.CreatedAt.Before(q.to) {
+		return false
+	}
+	if q.hasFile != nil && *q.hasFile != (item.Type == "file" || item.Type == "bundle" || item.Type == "secret-file") {
+		return false
+	}
+	if q.pinned != nil && *q.pinned != item.Pinned {
+		return false
+	}
+	return inTagAndCollection(item, q.tag, q.collection)
+}
+
+// ListHistory pages through all of the current user's unexpired items.
+// Filters narrow the items down, sort picks the order, and the nextCursor
+// of one page fetches the next; a cursor keeps its place when items are
+// added or removed in between.
+func (h *Handler) ListHistory(c *gin.Context) {
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	now := time.Now().UTC()
+	order := historyOrders[query.sort]
+	items := make([]*models.ClipboardItem, 0)
+	for _, item := range h.App.ClipboardStore.ListByUser(user.ID) {
+		if models.ClipboardItemExpired(item, now) || !query.matches(item) {
+			continue
+		}
+		if query.after != nil && !order.before(*query.after, order.position(item)) {
+			continue
+		}
+		items = append(items, item)
+	}
+	slices.SortFunc(items, func(a, b *models.ClipboardItem) int {
+		if order.before(order.position(a), order.position(b)) {
+			return -1
+		}
+		return 1
+	})
+
+	response := models.ListHistoryResponse{Items: make([]models.RecentItemResponse, 0, min(len(items), query.limit))}
+	if len(items) > query.limit {
+		items = items[:query.limit]
+		response.NextCursor = encodeHistoryCursor(historyCursor{
+			Sort:            query.sort,
+			historyPosition: order.position(items[len(items)-1]),
+		})
+	}
+	for _, item := range items {
+		response.Items = append(response.Items, h.toRecentItemResponse(item))
+	}
+	c.JSON(http.StatusOK, response)
+}
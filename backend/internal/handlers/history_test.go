@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func listHistory(t *testing.T, router http.Handler, query url.Values) models.ListHistoryResponse {
+	t.Helper()
+	recorder := sendAs(router, "user-1", http.MethodGet, "/api/items/history?"+query.Encode(), "")
+	var page models.ListHistoryResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("history failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	return page
+}
+
+func historyIDs(page models.ListHistoryResponse) []string {
+	ids := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestHistoryPagesThroughFilteredItemsWithStableCursors(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := time.Now().UTC().Add(time.Hour)
+	app := newTestApp(t, nil,
+		&models.ClipboardItem{ID: "t1", Type: "text", UserID: "user-1", Content: "a", CreatedAt: base, ExpiresAt: later},
+		&models.ClipboardItem{ID: "f1", Type: "file", UserID: "user-1", FileName: "cat.png", ContentType: "image/png", FileSize: 300, CreatedAt: base.Add(time.Hour), ExpiresAt: later},
+		&models.ClipboardItem{ID: "t2", Type: "text", UserID: "user-1", Content: "bb", CreatedAt: base.Add(2 * time.Hour), Pinned: true},
+		&models.ClipboardItem{ID: "f2", Type: "file", UserID: "user-1", FileName: "doc.pdf", ContentType: "application/pdf", FileSize: 100, CreatedAt: base.Add(3 * time.Hour), ExpiresAt: later},
+		&models.ClipboardItem{ID: "t3", Type: "text", UserID: "user-1", Content: "ccc", CreatedAt: base.Add(4 * time.Hour), ExpiresAt: later},
+		&models.ClipboardItem{ID: "gone", Type: "text", UserID: "user-1", Content: "old", CreatedAt: base.Add(5 * time.Hour), ExpiresAt: base},
+		&models.ClipboardItem{ID: "o1", Type: "text", UserID: "user-2", Content: "other", CreatedAt: base, ExpiresAt: later},
+	)
+	router := newTestRouter(app)
+
+	var seen []string
+	query := url.Values{"limit": {"2"}}
+	for page := 0; ; page++ {
+		result := listHistory(t, router, query)
+		seen = append(seen, historyIDs(result)...)
+		if result.NextCursor == "" {
+			break
+		}
+		if page == 0 {
+			// Items added after the first page must not shift the next one.
+			app.ClipboardStore.Put(&models.ClipboardItem{ID: "new", Type: "text", UserID: "user-1", Content: "new", CreatedAt: time.Now().UTC()})
+		}
+		query.Set("cursor", result.NextCursor)
+	}
+	if want := []string{"t3", "f2", "t2", "f1", "t1"}; !slices.Equal(seen, want) {
+		t.Fatalf("expected %v across pages, got %v", want, seen)
+	}
+
+	for _, tc := range []struct {
+		query url.Values
+		want  []string
+	}{
+		{url.Values{"type": {"file"}, "sort": {"largest"}}, []string{"f1", "f2"}},
+		{url.Values{"contentType": {"image/*"}}, []string{"f1"}},
+		{url.Values{"hasFile": {"false"}, "sort": {"oldest"}}, []string{"t1", "t2", "t3", "new"}},
+		{url.Values{"pinned": {"true"}}, []string{"t2"}},
+		{url.Values{"from": {base.Add(time.Hour).Format(time.RFC3339)}, "to": {base.Add(3 * time.Hour).Format(time.RFC3339)}}, []string{"t2", "f1"}},
+		{url.Values{"sort": {"expiring"}, "type": {"text"}}, []string{"t1", "t3", "new", "t2"}},
+	} {
+		if got := historyIDs(listHistory(t, router, tc.query)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: expected %v, got %v", tc.query, tc.want, got)
+		}
+	}
+
+	first := listHistory(t, router, url.Values{"limit": {"1"}})
+	if recorder := sendAs(router, "user-1", http.MethodGet, "/api/items/history?sort=oldest&cursor="+first.NextCursor, ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected a cursor from another sort order to be rejected, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "user-1", http.MethodGet, "/api/items/history?limit=500", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an oversized limit to be rejected, got %d", recorder.Code)
+	}
+}
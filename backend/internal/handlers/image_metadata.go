@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+)
+
+var errUnreadableImage = errors.New("image is malformed, so its metadata could not be removed")
+
+const (
+	jpegSOS  = 0xDA
+	jpegEOI  = 0xD9
+	jpegEXIF = 0xE1
+
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// pngMetadataChunks are the PNG chunks that carry text, Exif or time
+// stamps rather than anything needed to draw the image.
+var pngMetadataChunks = []string{"tEXt", "zTXt", "iTXt", "eXIf", "tIME"}
+
+// stripImageMetadata sniffs content and, for a JPEG, PNG or WebP image,
+// returns a reader that yields the image without its metadata, and true.
+// The image is filtered as it streams, so it is never held in memory;
+// other content is returned unchanged. The reader must be closed once
+// done with, and fails with errUnreadableImage if the image is malformed.
+func stripImageMetadata(content io.Reader) (io.ReadCloser, bool, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, false, err
+	}
+	content = io.MultiReader(bytes.NewReader(head[:n]), content)
+
+	var strip func(io.Writer, io.Reader) error
+	switch http.DetectContentType(head[:n]) {
+	case "image/jpeg":
+		strip = stripJPEGMetadata
+	case "image/png":
+		strip = stripPNGMetadata
+	case "image/webp":
+		strip = stripWebPMetadata
+	default:
+		return io.NopCloser(content), false, nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		err := strip(writer, content)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = errUnreadableImage
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader, true, nil
+}
+
+// stripJPEGMetadata copies a JPEG without its Exif, XMP, IPTC and comment
+// segments. The JFIF header, colour profile and Adobe colour transform
+// stay, and so does the Exif orientation, as a segment of its own, so
+// photos are not shown on their side. Anything after the end of the image,
+// such as the extra pictures some phones append, is dropped.
+func stripJPEGMetadata(dst io.Writer, src io.Reader) error {
+	r, w := bufio.NewReader(src), bufio.NewWriter(dst)
+	if _, err := io.CopyN(w, r, 2); err != nil {
+		return err
+	}
+
+	keptOrientation := false
+	marker, err := readJPEGMarker(r)
+	for err == nil && marker != jpegEOI {
+		if marker == 0x01 || marker >= 0xD0 && marker <= 0xD7 {
+			// Standalone markers have no length or payload.
+			_, err = w.Write([]byte{0xFF, marker})
+		} else {
+			var payload []byte
+			payload, err = readJPEGSegment(r)
+			if err == nil && marker == jpegEXIF && !keptOrientation {
+				if orientation := exifOrientation(payload); orientation > 1 {
+					err = writeJPEGSegment(w, jpegEXIF, orientationExif(orientation))
+					keptOrientation = true
+				}
+			}
+			if err == nil && keepJPEGSegment(marker, payload) {
+				err = writeJPEGSegment(w, marker, payload)
+			}
+		}
+		if err != nil {
+			break
+		}
+		if marker == jpegSOS {
+			marker, err = copyJPEGScan(w, r)
+		} else {
+			marker, err = readJPEGMarker(r)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write([]byte{0xFF, jpegEOI}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// keepJPEGSegment reports whether a segment is needed to show the image
+// rather than describe it.
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xE0:
+		return bytes.HasPrefix(payload, []byte("JFIF\x00"))
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false
+	}
+	return true
+}
+
+// readJPEGMarker reads the next marker, skipping any fill bytes before it.
+func readJPEGMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, errUnreadableImage
+	}
+	return jpegMarkerAfterFF(r)
+}
+
+func jpegMarkerAfterFF(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != 0xFF {
+			return b, err
+		}
+	}
+}
+
+func readJPEGSegment(r *bufio.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.BigEndian.Uint16(length[:]))
+	if size < 2 {
+		return nil, errUnreadableImage
+	}
+	payload := make([]byte, size-2)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func writeJPEGSegment(w *bufio.Writer, marker byte, payload []byte) error {
+	header := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(payload)+2))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// copyJPEGScan copies the entropy-coded data that follows a start of scan
+// and returns the marker that ends it. Stuffed zero bytes and restart
+// markers are part of the data.
+func copyJPEGScan(w *bufio.Writer, r *bufio.Reader) (byte, error) {
+	for {
+		data, err := r.ReadSlice(0xFF)
+		if err == bufio.ErrBufferFull {
+			if _, err := w.Write(data); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(data[:len(data)-1]); err != nil {
+			return 0, err
+		}
+		marker, err := jpegMarkerAfterFF(r)
+		if err != nil {
+			return 0, err
+		}
+		if marker != 0x00 && (marker < 0xD0 || marker > 0xD7) {
+			return marker, nil
+		}
+		if _, err := w.Write([]byte{0xFF, marker}); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// exifOrientation returns the Orientation tag of an Exif segment, or 0
+// if it has none.
+func exifOrientation(payload []byte) uint16 {
+	tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00"))
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	offset := uint64(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > uint64(len(tiff)) {
+		return 0
+	}
+	entries := tiff[offset+2:]
+	for range order.Uint16(tiff[offset:]) {
+		if len(entries) < 12 {
+			return 0
+		}
+		// Orientation is tag 0x0112, a single SHORT.
+		if order.Uint16(entries) == 0x0112 && order.Uint16(entries[2:]) == 3 {
+			if value := order.Uint16(entries[8:]); value <= 8 {
+				return value
+			}
+			return 0
+		}
+		entries = entries[12:]
+	}
+	return 0
+}
+
+// orientationExif builds an Exif segment payload holding nothing but the
+// orientation.
+func orientationExif(orientation uint16) []byte {
+	return []byte{
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big-endian TIFF header, IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0,
+		0, 0, 0, 0, // no next IFD
+	}
+}
+
+// stripPNGMetadata copies a PNG without its text, Exif and time chunks.
+// Colour chunks such as iCCP and gAMA stay. Anything after IEND is dropped.
+func stripPNGMetadata(dst io.Writer, src io.Reader) error {
+	r, w := bufio.NewReader(src), bufio.NewWriter(dst)
+	if _, err := io.CopyN(w, r, 8); err != nil {
+		return err
+	}
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > math.MaxInt32 {
+			return errUnreadableImage
+		}
+		// The data is followed by a four-byte CRC.
+		size := int64(length) + 4
+		chunkType := string(header[4:])
+		if slices.Contains(pngMetadataChunks, chunkType) {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+		if chunkType == "IEND" {
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+	}
+}
+
+// stripWebPMetadata copies a WebP with its EXIF and XMP chunks blanked.
+// The container's length comes before those chunks, which sit at the end,
+// so rather than being removed while streaming they are renamed to an
+// unknown chunk, which decoders skip, and filled with zeros. The flags
+// announcing them are cleared.
+func stripWebPMetadata(dst io.Writer, src io.Reader) error {
+	r, w := bufio.NewReader(src), bufio.NewWriter(dst)
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	remaining := int64(binary.LittleEndian.Uint32(header[4:8])) - 4
+	for remaining > 0 {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return err
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		size += size & 1 // chunks are padded to an even length
+		if 8+size > remaining {
+			return errUnreadableImage
+		}
+		remaining -= 8 + size
+
+		switch string(chunk[:4]) {
+		case "VP8X":
+			if size != 10 {
+				return errUnreadableImage
+			}
+			var payload [10]byte
+			if _, err := io.ReadFull(r, payload[:]); err != nil {
+				return err
+			}
+			payload[0] &^= webpFlagEXIF | webpFlagXMP
+			if _, err := w.Write(chunk[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(payload[:]); err != nil {
+				return err
+			}
+		case "EXIF", "XMP ":
+			copy(chunk[:4], "JUNK")
+			if _, err := w.Write(chunk[:]); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return err
+			}
+			if err := writeZeros(w, size); err != nil {
+				return err
+			}
+		default:
+			if _, err := w.Write(chunk[:]); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(w, r, size); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeZeros(w io.Writer, n int64) error {
+	var zeros [4096]byte
+	for n > 0 {
+		written, err := w.Write(zeros[:min(n, int64(len(zeros)))])
+		if err != nil {
+			return err
+		}
+		n -= int64(written)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+)
+
+const secretLocation = "GPS 48.8584 N 2.2945 E"
+
+// photoWithExif encodes a JPEG and inserts an Exif segment with the given
+// orientation and a comment after its start of image.
+func photoWithExif(t *testing.T, orientation uint16) []byte {
+	t.Helper()
+	picture := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			picture.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 8), B: 100, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, picture, nil); err != nil {
+		t.Fatal(err)
+	}
+	exif := append(orientationExif(orientation), secretLocation...)
+	var segments bytes.Buffer
+	for _, segment := range []struct {
+		marker  byte
+		payload []byte
+	}{{0xE1, exif}, {0xFE, []byte(secretLocation)}} {
+		header := []byte{0xFF, segment.marker, 0, 0}
+		binary.BigEndian.PutUint16(header[2:], uint16(len(segment.payload)+2))
+		segments.Write(header)
+		segments.Write(segment.payload)
+	}
+	data := encoded.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segments.Bytes()...), data[2:]...)
+}
+
+// screenshotWithText encodes a PNG and inserts a tEXt chunk after IHDR.
+func screenshotWithText(t *testing.T) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	chunk := []byte("tEXtLocation\x00" + secretLocation)
+	var text bytes.Buffer
+	binary.Write(&text, binary.BigEndian, uint32(len(chunk)-4))
+	text.Write(chunk)
+	binary.Write(&text, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	data := encoded.Bytes()
+	afterIHDR := 8 + 8 + 13 + 4
+	return append(append(append([]byte{}, data[:afterIHDR]...), text.Bytes()...), data[afterIHDR:]...)
+}
+
+func TestUploadedImagesLoseTheirMetadataWhenEnabled(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	stored := func(id string) []byte {
+		t.Helper()
+		item, _ := app.ClipboardStore.Get(id)
+		content, err := app.Blobs.Open(item.FileHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer content.Close()
+		data, _ := io.ReadAll(content)
+		return data
+	}
+
+	kept := uploadFileAs(t, router, "alice", "photo.jpg", photoWithExif(t, 6), nil)
+	if kept.MetadataStripped || !bytes.Contains(stored(kept.ID), []byte(secretLocation)) {
+		t.Fatal("expected uploads to be stored as sent while the setting is off")
+	}
+
+	settings := app.SettingsService.GetSettings()
+	settings.Clipboard.StripImageMetadata = true
+	if err := app.SettingsService.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	photo := uploadFileAs(t, router, "alice", "photo.jpg", photoWithExif(t, 6), nil)
+	data := stored(photo.ID)
+	if !photo.MetadataStripped || bytes.Contains(data, []byte(secretLocation)) {
+		t.Fatalf("expected the photo's metadata to be removed, stripped=%v", photo.MetadataStripped)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("stripped photo no longer decodes: %v", err)
+	}
+	if start := bytes.Index(data, []byte("Exif\x00\x00")); start < 0 || exifOrientation(data[start:]) != 6 {
+		t.Fatal("expected the photo to keep its orientation")
+	}
+
+	screenshot := uploadFileAs(t, router, "alice", "screenshot.png", screenshotWithText(t), nil)
+	data = stored(screenshot.ID)
+	if !screenshot.MetadataStripped || bytes.Contains(data, []byte(secretLocation)) {
+		t.Fatal("expected the screenshot's text chunk to be removed")
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("stripped screenshot no longer decodes: %v", err)
+	}
+	if item, _ := app.ClipboardStore.Get(screenshot.ID); !item.MetadataStripped {
+		t.Fatal("expected the item to record that its metadata was removed")
+	}
+
+	if text := uploadFileAs(t, router, "alice", "notes.txt", []byte(secretLocation), nil); text.MetadataStripped {
+		t.Fatal("expected files other than images to be left alone")
+	}
+
+	truncated := photoWithExif(t, 1)[:200]
+	recorder := postFileAs(router, "alice", "broken.jpg", truncated, nil)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed image, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestWebPMetadataIsBlankedInPlace(t *testing.T) {
+	chunk := func(fourCC string, payload []byte) []byte {
+		header := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+		if len(payload)%2 == 1 {
+			payload = append(payload, 0)
+		}
+		return append(header, payload...)
+	}
+	var body []byte
+	body = append(body, chunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP | 0x10, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte{0x2F, 1, 2, 3, 4})...)
+	body = append(body, chunk("EXIF", []byte(secretLocation))...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta>"+secretLocation+"</x:xmpmeta>"))...)
+	original := append([]byte("RIFF\x00\x00\x00\x00WEBP"), body...)
+	binary.LittleEndian.PutUint32(original[4:], uint32(len(body)+4))
+
+	var stripped bytes.Buffer
+	if err := stripWebPMetadata(&stripped, bytes.NewReader(original)); err != nil {
+		t.Fatal(err)
+	}
+	data := stripped.Bytes()
+	if len(data) != len(original) || bytes.Contains(data, []byte(secretLocation)) || bytes.Count(data, []byte("JUNK")) != 2 {
+		t.Fatalf("expected EXIF and XMP blanked in place, got %q", data)
+	}
+	if flags := data[20]; flags != 0x10 {
+		t.Fatalf("expected only the alpha flag to remain, got %#x", flags)
+	}
+}
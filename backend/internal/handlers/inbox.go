@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// SendItem sends an item to other users by username. The users are added
+// to the item's recipients, so it shows in their received list, and a
+// private item becomes visible to them.
+func (h *Handler) SendItem(c *gin.Context) {
+	id := strings.ToLower(c.Param("id"))
+
+	var request models.SendItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil || len(request.Usernames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name at least one user to send the item to"})
+		return
+	}
+
+	item, ok := h.editableItem(c, id)
+	if !ok {
+		return
+	}
+	if models.IsSecretItemType(item.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End-to-end encrypted items can only be shared by their link"})
+		return
+	}
+	recipients, err := h.resolveSharedWith(request.Usernames)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Recipients are added in one store update, so opens and reads recorded
+	// since the item was loaded are kept.
+	updated, err := h.App.ClipboardStore.Update(id, func(item *models.ClipboardItem) error {
+		for _, recipient := range recipients {
+			if recipient != item.UserID && !slices.Contains(item.SharedWith, recipient) {
+				item.SharedWith = append(slices.Clip(item.SharedWith), recipient)
+			}
+		}
+		if models.ItemVisibility(item) == models.VisibilityPrivate && len(item.SharedWith) > 0 {
+			item.Visibility = models.VisibilityUsers
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send item"})
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toRecentItemResponse(updated))
+}
+
+// ListReceivedItems returns the unexpired items other users sent to the
+// current user, newest first.
+func (h *Handler) ListReceivedItems(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	now := time.Now().UTC()
+	items := make([]models.RecentItemResponse, 0)
+
+	for _, item := range h.App.ClipboardStore.ListSharedWith(user.ID) {
+		if models.ClipboardItemExpired(item, now) {
+			continue
+		}
+		items = append(items, h.toReceivedItemResponse(item, user))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+
+	c.JSON(http.StatusOK, models.ListRecentItemsResponse{Items: items})
+}
+
+// toReceivedItemResponse describes an item as its recipient sees it: who
+// sent it and when they first opened it, but not the other recipients.
+func (h *Handler) toReceivedItemResponse(item *models.ClipboardItem, recipient *models.User) models.RecentItemResponse {
+	response := h.toRecentItemResponse(item)
+	// Who else got it and how the owner filed it is the owner's business.
+	response.SharedWith = nil
+	response.OpenedBy = nil
+	response.Tags = nil
+	response.Collection = ""
+	// Thumbnails of read-limited items are only served to the owner.
+	if item.MaxReads > 0 {
+		response.Thumbnail = false
+	}
+	if owner := h.App.UserManager.GetUser(item.UserID); owner != nil {
+		response.From = owner.Username
+	}
+	if openedAt, opened := item.OpenedAt[recipient.ID]; opened {
+		response.OpenedAt = &openedAt
+	}
+	return response
+}
+
+// recordOpened notes the first time a recipient opens an item sent to them.
+// It is called after the content was served, so failures are only logged.
+func (h *Handler) recordOpened(c *gin.Context, item *models.ClipboardItem) {
+	user, ok := c.Get("user")
+	if !ok {
+		return
+	}
+	userID := user.(*models.User).ID
+	if !slices.Contains(item.SharedWith, userID) {
+		return
+	}
+	if _, opened := item.OpenedAt[userID]; opened {
+		return
+	}
+	if err := h.App.ClipboardStore.MarkOpened(item.ID, userID, time.Now().UTC()); err != nil {
+		log.Printf("Failed to record that %s opened %s: %v", userID, item.ID, err)
+	}
+}
+
+// openedByUsernames maps the item's first-opened times to recipients'
+// usernames, skipping users that no longer exist.
+func (h *Handler) openedByUsernames(item *models.ClipboardItem) map[string]time.Time {
+	if len(item.OpenedAt) == 0 {
+		return nil
+	}
+	opened := make(map[string]time.Time, len(item.OpenedAt))
+	for id, openedAt := range item.OpenedAt {
+		if user := h.App.UserManager.GetUser(id); user != nil {
+			opened[user.Username] = openedAt
+		}
+	}
+	return opened
+}
+
+// openedByRecipients keeps the first-opened times of users that are still
+// recipients. It builds a new map, as stored items may share the old one.
+func openedByRecipients(openedAt map[string]time.Time, recipients []string) map[string]time.Time {
+	var kept map[string]time.Time
+	for id, at := range openedAt {
+		if !slices.Contains(recipients, id) {
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string]time.Time)
+		}
+		kept[id] = at
+	}
+	return kept
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func listItemsAs(t *testing.T, router http.Handler, username, target string) []models.RecentItemResponse {
+	t.Helper()
+	recorder := sendAs(router, username, http.MethodGet, target, "")
+	var list models.ListRecentItemsResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil {
+		t.Fatalf("list failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	return list.Items
+}
+
+func TestSentItemsShowInTheRecipientsInboxWithFirstOpenTime(t *testing.T) {
+	router := newTestRouter(newTestApp(t, nil))
+	id := saveVisibilityTestText(t, router, "alice", `{"content":"deploy checklist"}`)
+
+	if recorder := sendAs(router, "bob", http.MethodPost, "/api/items/"+id+"/recipients", `{"usernames":["bob"]}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected only the owner to send the item, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "alice", http.MethodPost, "/api/items/"+id+"/recipients", `{"usernames":["bob","alice"]}`); recorder.Code != http.StatusOK {
+		t.Fatalf("send failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	received := listItemsAs(t, router, "bob", "/api/items/received")
+	if len(received) != 1 || received[0].ID != id || received[0].From != "alice" || received[0].OpenedAt != nil || received[0].SharedWith != nil {
+		t.Fatalf("unexpected inbox %+v", received)
+	}
+	if received := listItemsAs(t, router, "carol", "/api/items/received"); len(received) != 0 {
+		t.Fatalf("expected carol's inbox to be empty, got %+v", received)
+	}
+	if received := listItemsAs(t, router, "alice", "/api/items/received"); len(received) != 0 {
+		t.Fatalf("the sender should not receive their own item, got %+v", received)
+	}
+
+	for range 2 {
+		if recorder := sendAs(router, "bob", http.MethodGet, "/api/text/"+id, ""); recorder.Code != http.StatusOK {
+			t.Fatalf("expected bob to read the item, got %d", recorder.Code)
+		}
+	}
+	received = listItemsAs(t, router, "bob", "/api/items/received")
+	if received[0].OpenedAt == nil {
+		t.Fatal("expected the first open to be recorded")
+	}
+	own := listItemsAs(t, router, "alice", "/api/items")
+	if openedAt, ok := own[0].OpenedBy["bob"]; !ok || !openedAt.Equal(*received[0].OpenedAt) {
+		t.Fatalf("expected the sender to see when bob first opened it, got %+v", own[0].OpenedBy)
+	}
+}
+
+func TestSendingKeepsOpensRecordedMeanwhile(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+	id := saveVisibilityTestText(t, router, "alice", `{"content":"deploy checklist","sharedWith":["bob"]}`)
+	snapshot, _ := app.ClipboardStore.Get(id)
+	bob := app.UserManager.GetUserByUsername("bob")
+	if err := app.ClipboardStore.MarkOpened(id, bob.ID, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	store := app.ClipboardStore
+	app.ClipboardStore = staleClipboardStore{ClipboardStore: store, snapshot: *snapshot}
+
+	if recorder := sendAs(router, "alice", http.MethodPost, "/api/items/"+id+"/recipients", `{"usernames":["carol"]}`); recorder.Code != http.StatusOK {
+		t.Fatalf("send failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if item, _ := store.Get(id); len(item.SharedWith) != 2 || item.OpenedAt[bob.ID].IsZero() {
+		t.Fatalf("expected carol added and bob's open kept, got %#v", item)
+	}
+}
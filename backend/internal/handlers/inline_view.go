@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// inlineContentTypes are the sniffed content types a file may be shown as in
+// the browser. Types that can carry script, such as HTML, SVG and XML, are
+// left out and always downloaded as attachments.
+var inlineContentTypes = []string{
+	"application/pdf",
+	"text/plain",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+	"audio/mpeg",
+	"audio/wave",
+	"audio/ogg",
+	"video/mp4",
+	"video/webm",
+	"application/ogg",
+}
+
+// inlineFilePolicy keeps an inline file from running script or loading
+// anything, should a browser render it as something other than its type.
+const inlineFilePolicy = "default-src 'none'; img-src 'self'; media-src 'self'; style-src 'unsafe-inline'; sandbox"
+
+// inlineContentType returns the type to show item in the browser as, or ""
+// when it must be downloaded. It goes by the type sniffed from the
+// contents at upload time, never by the file name.
+func inlineContentType(item *models.ClipboardItem) string {
+	if item.Type != "file" {
+		return ""
+	}
+	mediaType, params, err := mime.ParseMediaType(item.ContentType)
+	if err != nil || !slices.Contains(inlineContentTypes, mediaType) {
+		return ""
+	}
+	if mediaType == "text/plain" {
+		charset := strings.ToLower(params["charset"])
+		if charset == "" {
+			charset = "utf-8"
+		}
+		return mime.FormatMediaType(mediaType, map[string]string{"charset": charset})
+	}
+	return mediaType
+}
+
+// wantsInline reports whether the request asked to view the file in the
+// browser rather than download it.
+func wantsInline(c *gin.Context) bool {
+	inline, _ := strconv.ParseBool(c.Query("inline"))
+	return inline
+}
+
+// setInlineHeaders marks a response as a file to show in the browser as
+// contentType, sandboxed and without content sniffing.
+func setInlineHeaders(c *gin.Context, contentType, fileName string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", dispositionHeader("inline", fileName))
+	c.Header("Content-Security-Policy", inlineFilePolicy)
+	c.Header("X-Content-Type-Options", "nosniff")
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetFileShowsOnlySafeTypesInline(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	cases := []struct {
+		fileName, content string
+		contentType       string // empty when the file must be downloaded
+	}{
+		{"report.pdf", "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n", "application/pdf"},
+		// The name does not matter, only what the contents are.
+		{"notes.html", "just some notes\n", "text/plain; charset=utf-8"},
+		{"page.txt", "<!DOCTYPE html><script>alert(1)</script>", ""},
+		{"drawing.svg", `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, ""},
+	}
+	for _, tc := range cases {
+		saved := uploadFileAs(t, router, "alice", tc.fileName, []byte(tc.content), nil)
+
+		recorder := sendAs(router, "alice", http.MethodGet, "/api/file/"+saved.ID+"?inline=true", "")
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tc.fileName, recorder.Code)
+		}
+		disposition := recorder.Header().Get("Content-Disposition")
+		if tc.contentType == "" {
+			if !strings.HasPrefix(disposition, "attachment;") {
+				t.Fatalf("%s: expected an attachment, got %q", tc.fileName, disposition)
+			}
+			continue
+		}
+		if !strings.HasPrefix(disposition, "inline;") || recorder.Header().Get("Content-Type") != tc.contentType {
+			t.Fatalf("%s: expected inline %s, got %q as %q", tc.fileName, tc.contentType, disposition, recorder.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(recorder.Header().Get("Content-Security-Policy"), "sandbox") || recorder.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Fatalf("%s: inline view is missing its sandbox headers: %v", tc.fileName, recorder.Header())
+		}
+
+		recorder = sendAs(router, "alice", http.MethodGet, "/api/file/"+saved.ID, "")
+		if disposition := recorder.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment;") {
+			t.Fatalf("%s: expected a download without ?inline, got %q", tc.fileName, disposition)
+		}
+	}
+}
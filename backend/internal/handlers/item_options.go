@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// errInvalidItemOption marks errors caused by the uploader's item options.
+// They are reported back as 400s with the error text.
+var errInvalidItemOption = errors.New("invalid item option")
+
+func invalidItemOption(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errInvalidItemOption, fmt.Sprintf(format, args...))
+}
+
+// itemOptionKeys are the option names accepted as form fields, query
+// parameters and tus metadata, besides "password", which is hashed as soon
+// as it is read.
+var itemOptionKeys = []string{"maxReads", "burnAfterRead", "expiresIn", "expiresAt", "visibility", "sharedWith", "tags", "collection"}
+
+// itemOptions are the validated models.ItemOptions. Text requests carry
+// them as JSON fields; multipart form fields, query parameters and tus
+// metadata carry them as strings.
+type itemOptions struct {
+	MaxReads     int
+	Expiration   models.ItemExpiration
+	PasswordHash string
+	Visibility   string
+	SharedWith   []string // usernames, resolved when the item is created
+	Tags         []string
+	Collection   string // collection ID, checked when the item is created
+}
+
+func newItemOptions(request models.ItemOptions) (itemOptions, error) {
+	maxReads := request.MaxReads
+	if maxReads < 0 {
+		return itemOptions{}, invalidItemOption("maxReads cannot be negative")
+	}
+	if request.BurnAfterRead {
+		if maxReads > 1 {
+			return itemOptions{}, invalidItemOption("burnAfterRead cannot be combined with maxReads above 1")
+		}
+		maxReads = 1
+	}
+	if _, err := parseExpiration(request.ItemExpiration); err != nil {
+		return itemOptions{}, err
+	}
+	if err := checkVisibility(request.Visibility, request.SharedWith); err != nil {
+		return itemOptions{}, err
+	}
+	tags, err := normalizeTags(request.Tags)
+	if err != nil {
+		return itemOptions{}, err
+	}
+	passwordHash, err := hashItemPassword(request.Password)
+	if err != nil {
+		return itemOptions{}, err
+	}
+	return itemOptions{
+		MaxReads:     maxReads,
+		Expiration:   request.ItemExpiration,
+		PasswordHash: passwordHash,
+		Visibility:   request.Visibility,
+		SharedWith:   request.SharedWith,
+		Tags:         tags,
+		Collection:   strings.TrimSpace(request.Collection),
+	}, nil
+}
+
+// parseItemOptions reads options from string values, where value returns ""
+// for options that were not given.
+func parseItemOptions(value func(key string) string) (itemOptions, error) {
+	maxReads := 0
+	if raw := value("maxReads"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return itemOptions{}, invalidItemOption("maxReads must be a whole number")
+		}
+		maxReads = parsed
+	}
+	burnAfterRead := false
+	if raw := value("burnAfterRead"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return itemOptions{}, invalidItemOption("burnAfterRead must be true or false")
+		}
+		burnAfterRead = parsed
+	}
+	return newItemOptions(models.ItemOptions{
+		MaxReads:      maxReads,
+		BurnAfterRead: burnAfterRead,
+		Password:      value("password"),
+		Visibility:    value("visibility"),
+		SharedWith:    splitList(value("sharedWith")),
+		Tags:          splitList(value("tags")),
+		Collection:    value("collection"),
+		ItemExpiration: models.ItemExpiration{
+			ExpiresIn: value("expiresIn"),
+			ExpiresAt: value("expiresAt"),
+		},
+	})
+}
+
+// splitList reads a comma-separated option, dropping empty entries.
+func splitList(value string) []string {
+	var entries []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// newItem starts a clipboard item owned by user with the uploader's options
+// applied; callers fill in the content.
+func (h *Handler) newItem(user *models.User, itemType string, options itemOptions) (*models.ClipboardItem, error) {
+	options, err := h.applyCollectionDefaults(user, options)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := time.Now().UTC()
+	expiresAt, err := h.itemExpiresAt(user, createdAt, options.Expiration)
+	if err != nil {
+		return nil, err
+	}
+	sharedWith, err := h.resolveSharedWith(options.SharedWith)
+	if err != nil {
+		return nil, err
+	}
+	item := &models.ClipboardItem{
+		ID:           h.generateShortID(),
+		Type:         itemType,
+		UserID:       user.ID,
+		MaxReads:     options.MaxReads,
+		PasswordHash: options.PasswordHash,
+		Visibility:   options.Visibility,
+		SharedWith:   sharedWith,
+		Tags:         options.Tags,
+		CollectionID: options.Collection,
+		CreatedAt:    createdAt,
+		ExpiresAt:    expiresAt,
+	}
+	if len(sharedWith) > 0 && item.Visibility == "" {
+		item.Visibility = models.VisibilityUsers
+	}
+	item.Visibility = models.ItemVisibility(item)
+	return item, nil
+}
+
+// checkItemOptions validates options for an item user is about to create,
+// so uploads can be refused before their body is read.
+func (h *Handler) checkItemOptions(user *models.User, options itemOptions) error {
+	options, err := h.applyCollectionDefaults(user, options)
+	if err != nil {
+		return err
+	}
+	if _, err := h.itemExpiresAt(user, time.Now().UTC(), options.Expiration); err != nil {
+		return err
+	}
+	_, err = h.resolveSharedWith(options.SharedWith)
+	return err
+}
+
+// checkVisibility validates a visibility and the usernames the item is sent
+// to. Naming users without a visibility picks the "users" visibility; with
+// "everyone" the named users still get the item in their inbox.
+func checkVisibility(visibility string, sharedWith []string) error {
+	switch {
+	case visibility != "" && !models.ValidVisibility(visibility):
+		return invalidItemOption("visibility must be private, users or everyone")
+	case visibility == models.VisibilityUsers && len(sharedWith) == 0:
+		return invalidItemOption("name at least one user to share the item with")
+	case visibility == models.VisibilityPrivate && len(sharedWith) > 0:
+		return invalidItemOption("a private item cannot be shared with other users")
+	}
+	return nil
+}
+
+// resolveSharedWith turns usernames into user IDs, dropping duplicates.
+func (h *Handler) resolveSharedWith(usernames []string) ([]string, error) {
+	var ids []string
+	for _, username := range usernames {
+		user := h.App.UserManager.GetUserByUsername(strings.TrimSpace(username))
+		if user == nil {
+			return nil, invalidItemOption("unknown user %q", username)
+		}
+		if !slices.Contains(ids, user.ID) {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+// sharedWithUsernames maps the user IDs an item is shared with back to
+// usernames, skipping users that no longer exist.
+func (h *Handler) sharedWithUsernames(item *models.ClipboardItem) []string {
+	var usernames []string
+	for _, id := range item.SharedWith {
+		if user := h.App.UserManager.GetUser(id); user != nil {
+			usernames = append(usernames, user.Username)
+		}
+	}
+	return usernames
+}
+
+// expirationChoice is a parsed models.ItemExpiration.
+type expirationChoice struct {
+	set      bool
+	never    bool
+	lifetime time.Duration
+	at       time.Time
+}
+
+func parseExpiration(expiration models.ItemExpiration) (expirationChoice, error) {
+	in, at := strings.TrimSpace(expiration.ExpiresIn), strings.TrimSpace(expiration.ExpiresAt)
+	switch {
+	case in != "" && at != "":
+		return expirationChoice{}, invalidItemOption("give either expiresIn or expiresAt, not both")
+	case in == "never":
+		return expirationChoice{set: true, never: true}, nil
+	case in != "":
+		lifetime, err := parseLifetime(in)
+		if err != nil || lifetime <= 0 {
+			return expirationChoice{}, invalidItemOption("expiresIn must be a duration such as 30m, 12h or 7d, or never")
+		}
+		return expirationChoice{set: true, lifetime: lifetime}, nil
+	case at != "":
+		parsed, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return expirationChoice{}, invalidItemOption("expiresAt must be an RFC 3339 time")
+		}
+		return expirationChoice{set: true, at: parsed.UTC()}, nil
+	}
+	return expirationChoice{}, nil
+}
+
+// parseLifetime accepts Go durations plus whole days such as "7d".
+func parseLifetime(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// itemExpiresAt works out when an item created at createdAt expires. An
+// explicit choice must be in the future and within the role's retention
+// cap; the configured default is quietly shortened to fit the cap instead.
+func (h *Handler) itemExpiresAt(user *models.User, createdAt time.Time, expiration models.ItemExpiration) (time.Time, error) {
+	choice, err := parseExpiration(expiration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	settings := h.systemSettings()
+	maxLifetime := settings.Retention.LimitFor(user.Role).MaxLifetime()
+	exceedsCap := func(expiresAt time.Time) bool {
+		return maxLifetime > 0 && (expiresAt.IsZero() || expiresAt.Sub(createdAt) > maxLifetime)
+	}
+
+	if !choice.set {
+		expiresAt := settings.Clipboard.ExpiresAt(createdAt)
+		if exceedsCap(expiresAt) {
+			expiresAt = createdAt.Add(maxLifetime)
+		}
+		return expiresAt, nil
+	}
+
+	now := time.Now().UTC()
+	var expiresAt time.Time
+	switch {
+	case choice.never:
+	case choice.lifetime > 0:
+		expiresAt = now.Add(choice.lifetime)
+	default:
+		expiresAt = choice.at
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		return time.Time{}, invalidItemOption("expiration must be in the future")
+	}
+	if exceedsCap(expiresAt) {
+		return time.Time{}, invalidItemOption("items can be kept at most %s after creation", formatLifetime(maxLifetime))
+	}
+	return expiresAt, nil
+}
+
+func formatLifetime(lifetime time.Duration) string {
+	if lifetime%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", lifetime/(24*time.Hour))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(lifetime.String(), "0s"), "0m")
+}
+
+// consumeRead counts a read of a read-limited item and reports whether it
+// was the last one, in which case the item is already deleted. It returns
+// nil when a concurrent reader used up the item first.
+func (h *Handler) consumeRead(item *models.ClipboardItem) (*models.ClipboardItem, bool, error) {
+	if item.MaxReads <= 0 {
+		return item, false, nil
+	}
+	return h.App.ClipboardStore.ConsumeRead(item.ID)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestBurnAfterReadTextIsGoneAfterTheFirstView(t *testing.T) {
+	router := newTestRouter(newTestApp(t, nil))
+
+	recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", `{"content":"otp 123456","burnAfterRead":true}`)
+	var saved models.SaveTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || saved.ID == "" {
+		t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = sendAs(router, "user-1", http.MethodGet, "/api/text/"+saved.ID, "")
+	var read models.GetTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &read); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || read.Content != "otp 123456" || !read.LastView {
+		t.Fatalf("expected the last view of the text, got %d %#v", recorder.Code, read)
+	}
+
+	if recorder := sendAs(router, "user-1", http.MethodGet, "/api/text/"+saved.ID, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after the last view, got %d", recorder.Code)
+	}
+
+	if recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", `{"content":"x","maxReads":3,"burnAfterRead":true}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected conflicting options to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestReadLimitedFileReleasesItsBlobOnTheLastDownload(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("maxReads", "2")
+	part, _ := writer.CreateFormFile("file", "key.pem")
+	part.Write([]byte("-----BEGIN KEY-----"))
+	writer.Close()
+	request := httptest.NewRequest(http.MethodPost, "/api/file", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	recorder := serveAs(router, "user-1", request)
+	var saved models.SaveFileResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || saved.ID == "" {
+		t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	item, _ := app.ClipboardStore.Get(saved.ID)
+
+	for download := 1; download <= 2; download++ {
+		request := httptest.NewRequest(http.MethodGet, "/api/file/"+saved.ID, nil)
+		request.Header.Set("Range", "bytes=0-3")
+		recorder := serveAs(router, "user-1", request)
+		if recorder.Code != http.StatusOK || recorder.Body.String() != "-----BEGIN KEY-----" {
+			t.Fatalf("download %d: expected the whole file, got %d %q", download, recorder.Code, recorder.Body.String())
+		}
+		if last := recorder.Header().Get("Clipboard-Last-View") == "true"; last != (download == 2) {
+			t.Fatalf("download %d: unexpected Clipboard-Last-View %q", download, recorder.Header().Get("Clipboard-Last-View"))
+		}
+	}
+
+	if _, err := app.Blobs.Open(item.FileHash); err == nil {
+		t.Fatal("blob should be released after the last download")
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/file/"+saved.ID, nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after the last download, got %d", recorder.Code)
+	}
+}
+
+func TestSaveTextHoldsChosenExpirationToTheRoleCap(t *testing.T) {
+	router := newTestRouter(newTestApp(t, func(settings *models.SystemSettings) {
+		settings.Retention.User.MaxMinutes = 24 * 60
+	}))
+
+	for body, want := range map[string]int{
+		`{"content":"a","expiresIn":"12h"}`:                                   http.StatusOK,
+		`{"content":"a","expiresIn":"2d"}`:                                    http.StatusBadRequest,
+		`{"content":"a","expiresIn":"never"}`:                                 http.StatusBadRequest,
+		`{"content":"a","expiresIn":"soon"}`:                                  http.StatusBadRequest,
+		`{"content":"a","expiresAt":"2001-01-01T00:00:00Z"}`:                  http.StatusBadRequest,
+		`{"content":"a","expiresIn":"1h","expiresAt":"2999-01-01T00:00:00Z"}`: http.StatusBadRequest,
+	} {
+		if recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", body); recorder.Code != want {
+			t.Fatalf("%s: expected %d, got %d %s", body, want, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	// Admins have no cap by default, so they may keep items forever.
+	recorder := sendAs(router, "admin", http.MethodPost, "/api/text", `{"content":"a","expiresIn":"never"}`)
+	var saved models.SaveTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || recorder.Code != http.StatusOK || !saved.ExpiresAt.IsZero() {
+		t.Fatalf("expected a never-expiring admin item, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestSaveTextCapsTheDefaultExpirationQuietly(t *testing.T) {
+	router := newTestRouter(newTestApp(t, func(settings *models.SystemSettings) {
+		settings.Retention.User.MaxMinutes = 5
+		settings.Clipboard.ExpirationValue = 0
+	}))
+
+	before := time.Now().UTC()
+	recorder := sendAs(router, "user-1", http.MethodPost, "/api/text", `{"content":"a"}`)
+	var saved models.SaveTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if saved.ExpiresAt.IsZero() || saved.ExpiresAt.After(before.Add(5*time.Minute+time.Second)) {
+		t.Fatalf("expected the default to be capped at 5 minutes, got %v", saved.ExpiresAt)
+	}
+}
+
+func TestUpdateItemChangesExpirationForOwnerAndAdmin(t *testing.T) {
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	app := newTestApp(t,
+		func(settings *models.SystemSettings) { settings.Retention.User.MaxMinutes = 24 * 60 },
+		&models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "a", CreatedAt: createdAt, ExpiresAt: createdAt.Add(2 * time.Hour)},
+	)
+	router := newTestRouter(app)
+
+	if recorder := sendAs(router, "user-2", http.MethodPatch, "/api/items/abcd", `{"expiration":{"expiresIn":"1h"}}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for someone else's item, got %d", recorder.Code)
+	}
+
+	// The cap counts from creation: 1h old plus 23h fits, plus 24h does not.
+	if recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/abcd", `{"expiration":{"expiresIn":"24h"}}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an extension past the cap to be rejected, got %d", recorder.Code)
+	}
+	recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/abcd", `{"expiration":{"expiresIn":"22h"}}`)
+	var updated models.RecentItemResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &updated); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if item, _ := app.ClipboardStore.Get("abcd"); !item.ExpiresAt.Equal(updated.ExpiresAt) || item.ExpiresAt.Sub(createdAt) < 22*time.Hour {
+		t.Fatalf("expected the stored expiration to be extended, got %v", item.ExpiresAt)
+	}
+
+	if recorder := sendAs(router, "admin", http.MethodPatch, "/api/items/abcd", `{"expiration":{"expiresIn":"never"}}`); recorder.Code != http.StatusOK {
+		t.Fatalf("expected an admin to lift the expiration, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if item, _ := app.ClipboardStore.Get("abcd"); !item.ExpiresAt.IsZero() {
+		t.Fatalf("expected the item to never expire, got %v", item.ExpiresAt)
+	}
+}
+
+// staleClipboardStore serves Get from a snapshot taken earlier, as a
+// request would see it if another one changed the item right after.
+type staleClipboardStore struct {
+	models.ClipboardStore
+	snapshot models.ClipboardItem
+}
+
+func (s staleClipboardStore) Get(id string) (*models.ClipboardItem, bool) {
+	item := s.snapshot
+	return &item, id == item.ID
+}
+
+func TestUpdateItemKeepsReadsRecordedMeanwhile(t *testing.T) {
+	createdAt := time.Now().UTC()
+	item := models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "a", MaxReads: 2, CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Hour)}
+	app := newTestApp(t, nil, &item)
+	if _, _, err := app.ClipboardStore.ConsumeRead("abcd"); err != nil {
+		t.Fatal(err)
+	}
+	store := app.ClipboardStore
+	app.ClipboardStore = staleClipboardStore{ClipboardStore: store, snapshot: item}
+
+	router := newTestRouter(app)
+	if recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/abcd", `{"tags":["work"]}`); recorder.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if updated, _ := store.Get("abcd"); updated.ReadCount != 1 || len(updated.Tags) != 1 {
+		t.Fatalf("expected the tag added and the read kept, got %#v", updated)
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// The password of a protected item, and of a share link, is sent in a
+// header when reading it. A header keeps the password out of URLs and
+// access logs.
+const (
+	clipboardItemPasswordHeader  = "Clipboard-Item-Password"
+	clipboardSharePasswordHeader = "Clipboard-Share-Password"
+)
+
+// maxItemPasswordLength is the most bcrypt will hash.
+const maxItemPasswordLength = 72
+
+// hashItemPassword returns the bcrypt hash stored for an item password, or
+// "" when no password was given.
+func hashItemPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) > maxItemPasswordLength {
+		return "", invalidItemOption("password must be at most %d bytes", maxItemPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash item password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// checkItemPassword makes sure the caller may read a password-protected item
+// and writes the error response when not. Owners never need the password.
+func (h *Handler) checkItemPassword(c *gin.Context, item *models.ClipboardItem) bool {
+	if item.PasswordHash == "" {
+		return true
+	}
+	if user, ok := c.Get("user"); ok && user.(*models.User).ID == item.UserID {
+		return true
+	}
+
+	status, message := h.verifyPassword(c, item.PasswordHash, c.GetHeader(clipboardItemPasswordHeader), item.ID)
+	if status != 0 {
+		c.JSON(status, gin.H{"error": message, "passwordRequired": status == http.StatusForbidden})
+		return false
+	}
+	return true
+}
+
+// verifyPassword checks a password against the bcrypt hash protecting
+// subject. Guesses are rate limited on their own, and wrong passwords count
+// as failed attempts so a client that keeps guessing gets blocked. On
+// failure it returns the status and message to answer with, otherwise 0.
+func (h *Handler) verifyPassword(c *gin.Context, hash, password, subject string) (int, string) {
+	if password == "" {
+		return http.StatusForbidden, "Password required"
+	}
+	if h.App.RateLimiter != nil && !h.App.RateLimiter.IsAllowed(h.App.Security.GetClientIP(c), models.ItemPasswordRateLimitEndpoint) {
+		return http.StatusTooManyRequests, "Too many password attempts. Please slow down."
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		h.App.Security.RecordFailedAttempt(c, fmt.Sprintf("Wrong password for %s", subject))
+		return http.StatusForbidden, "Incorrect password"
+	}
+	return 0, ""
+}
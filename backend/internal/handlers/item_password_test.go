@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// failureCountingSecurityService records failed attempts reported by handlers.
+type failureCountingSecurityService struct {
+	allowSecurityService
+	failures *[]string
+}
+
+func (s failureCountingSecurityService) RecordFailedAttempt(c interface{}, reason string) {
+	*s.failures = append(*s.failures, reason)
+}
+
+func TestPasswordProtectedTextRequiresPasswordFromOtherUsers(t *testing.T) {
+	var failures []string
+	app := newTestApp(t, nil)
+	app.Security = failureCountingSecurityService{failures: &failures}
+	router := newTestRouter(app)
+
+	recorder := sendAs(router, "alice", http.MethodPost, "/api/text", `{"content":"wifi: hunter2","password":"open sesame","visibility":"everyone"}`)
+	var saved models.SaveTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || saved.ID == "" {
+		t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if item, _ := app.ClipboardStore.Get(saved.ID); item.PasswordHash == "" || strings.Contains(item.PasswordHash, "open sesame") {
+		t.Fatalf("expected a bcrypt hash to be stored, got %q", item.PasswordHash)
+	}
+
+	read := func(password string, asOwner bool) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/api/text/"+saved.ID, nil)
+		if password != "" {
+			request.Header.Set("Clipboard-Item-Password", password)
+		}
+		if asOwner {
+			return serveAs(router, "alice", request)
+		}
+		return serveAs(router, "bob", request)
+	}
+
+	if recorder := read("", false); recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), "passwordRequired") {
+		t.Fatalf("expected a password prompt, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if len(failures) != 0 {
+		t.Fatalf("a missing password should not count as a failed attempt, got %v", failures)
+	}
+	if recorder := read("guess", false); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected a wrong password to be refused, got %d", recorder.Code)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("expected the wrong password to be recorded, got %v", failures)
+	}
+	if recorder := read("open sesame", false); recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "hunter2") {
+		t.Fatalf("expected the right password to unlock the item, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := read("", true); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the owner to read without the password, got %d", recorder.Code)
+	}
+
+	// Guessing is throttled before bcrypt even runs.
+	throttled := false
+	for attempt := 0; attempt < 20 && !throttled; attempt++ {
+		throttled = read("guess", false).Code == http.StatusTooManyRequests
+	}
+	if !throttled {
+		t.Fatal("expected repeated password guesses to be rate limited")
+	}
+}
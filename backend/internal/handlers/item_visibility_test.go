@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func saveVisibilityTestText(t *testing.T, router http.Handler, username, body string) string {
+	t.Helper()
+	recorder := sendAs(router, username, http.MethodPost, "/api/text", body)
+	var saved models.SaveTextResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil || saved.ID == "" {
+		t.Fatalf("save failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	return saved.ID
+}
+
+func TestPrivateItemsLookMissingToOtherUsers(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+	id := saveVisibilityTestText(t, router, "alice", `{"content":"draft"}`)
+
+	missing := sendAs(router, "bob", http.MethodGet, "/api/text/zzzz", "")
+	hidden := sendAs(router, "bob", http.MethodGet, "/api/text/"+id, "")
+	if hidden.Code != http.StatusNotFound || hidden.Body.String() != missing.Body.String() {
+		t.Fatalf("expected a hidden item to look missing, got %d %s", hidden.Code, hidden.Body.String())
+	}
+	if recorder := sendAs(router, "admin", http.MethodGet, "/api/text/"+id, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected admins to see every item, got %d", recorder.Code)
+	}
+
+	if recorder := sendAs(router, "bob", http.MethodDelete, "/api/"+id, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected delete by another user to be refused, got %d", recorder.Code)
+	}
+	if _, exists := app.ClipboardStore.Get(id); !exists {
+		t.Fatal("item deleted by a user who does not own it")
+	}
+	if recorder := sendAs(router, "alice", http.MethodDelete, "/api/"+id, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the owner to delete the item, got %d", recorder.Code)
+	}
+}
+
+func TestSharedItemsAreVisibleToNamedUsersUntilTheOwnerChangesThat(t *testing.T) {
+	router := newTestRouter(newTestApp(t, nil))
+	id := saveVisibilityTestText(t, router, "alice", `{"content":"minutes","sharedWith":["bob"]}`)
+
+	if recorder := sendAs(router, "bob", http.MethodGet, "/api/text/"+id, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected bob to read the item, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "carol", http.MethodGet, "/api/text/"+id, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected carol not to see the item, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "bob", http.MethodPatch, "/api/items/"+id, `{"visibility":"everyone"}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected only the owner to change visibility, got %d", recorder.Code)
+	}
+	if recorder := sendAs(router, "alice", http.MethodPatch, "/api/items/"+id, `{"sharedWith":["nobody"]}`); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an unknown username to be rejected, got %d", recorder.Code)
+	}
+
+	recorder := sendAs(router, "alice", http.MethodPatch, "/api/items/"+id, `{"visibility":"everyone"}`)
+	var updated models.RecentItemResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &updated); err != nil || updated.Visibility != models.VisibilityEveryone || len(updated.SharedWith) != 1 {
+		t.Fatalf("unexpected update response: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := sendAs(router, "carol", http.MethodGet, "/api/text/"+id, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected every user to read the item, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestPinnedItemsOutliveExpiryAndAreListedFirst(t *testing.T) {
+	past := time.Now().UTC().Add(-time.Hour)
+	app := newTestApp(t, func(settings *models.SystemSettings) { settings.Clipboard.MaxPinnedItems = 2 },
+		&models.ClipboardItem{ID: "vpn1", Type: "text", UserID: "user-1", Content: "vpn config", CreatedAt: past.Add(-time.Hour), ExpiresAt: past.Add(time.Minute)},
+		&models.ClipboardItem{ID: "wifi", Type: "text", UserID: "user-1", Content: "wifi", CreatedAt: past, ExpiresAt: time.Now().UTC().Add(time.Hour)},
+		&models.ClipboardItem{ID: "note", Type: "text", UserID: "user-1", Content: "note", CreatedAt: past, ExpiresAt: time.Now().UTC().Add(time.Hour)},
+	)
+	router := newTestRouter(app)
+
+	// Pin vpn1 while its expiry is still ahead, then let that pass.
+	item, _ := app.ClipboardStore.Get("vpn1")
+	item.ExpiresAt = time.Now().UTC().Add(time.Hour)
+	app.ClipboardStore.Put(item)
+	for _, id := range []string{"vpn1", "wifi"} {
+		if recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/"+id, `{"pinned":true}`); recorder.Code != http.StatusOK {
+			t.Fatalf("pin failed: %d %s", recorder.Code, recorder.Body.String())
+		}
+	}
+	if recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/note", `{"pinned":true}`); recorder.Code != http.StatusConflict {
+		t.Fatalf("expected the pin limit to be enforced, got %d", recorder.Code)
+	}
+	item, _ = app.ClipboardStore.Get("vpn1")
+	item.ExpiresAt = past
+	app.ClipboardStore.Put(item)
+
+	if removed, err := RemoveExpiredItems(app, time.Now().UTC()); err != nil || removed != 0 {
+		t.Fatalf("expected cleanup to keep pinned items, removed %d, %v", removed, err)
+	}
+	var list models.ListRecentItemsResponse
+	json.Unmarshal(sendAs(router, "user-1", http.MethodGet, "/api/items", "").Body.Bytes(), &list)
+	if len(list.Items) != 3 || !list.Items[0].Pinned || !list.Items[1].Pinned || list.Items[2].ID != "note" {
+		t.Fatalf("expected pinned items first, got %+v", list.Items)
+	}
+
+	recorder := sendAs(router, "user-1", http.MethodPatch, "/api/items/vpn1", `{"pinned":false}`)
+	var unpinned models.RecentItemResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &unpinned); err != nil || unpinned.Pinned || !unpinned.ExpiresAt.After(time.Now()) {
+		t.Fatalf("expected unpinning to give the item a fresh lifetime, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// ReconcileItems repairs what a crash between two writes can leave behind,
+// since an item is stored apart from its share links, its collection and
+// the blob reference counts: links to missing items are revoked, items
+// leave collections that are gone, and blob references are recounted from
+// the items. It runs at startup, before any request can change the stores.
+func ReconcileItems(app *models.App) error {
+	items := app.ClipboardStore.ListAll()
+	exists := make(map[string]bool, len(items))
+	counts := make(map[string]int)
+	for _, item := range items {
+		exists[item.ID] = true
+		for _, hash := range itemBlobHashes(item) {
+			counts[hash]++
+		}
+		if item.CollectionID == "" || app.Collections == nil {
+			continue
+		}
+		if _, ok := app.Collections.Get(item.CollectionID); ok {
+			continue
+		}
+		collectionID := item.CollectionID
+		_, err := app.ClipboardStore.Update(item.ID, func(item *models.ClipboardItem) error {
+			if item.CollectionID == collectionID {
+				item.CollectionID = ""
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to take item %s out of missing collection: %w", item.ID, err)
+		}
+		log.Printf("Took item %s out of missing collection %s", item.ID, collectionID)
+	}
+
+	if app.Shares != nil {
+		removed, err := app.Shares.DeleteForMissingItems(func(itemID string) bool { return exists[itemID] })
+		if err != nil {
+			return fmt.Errorf("failed to revoke share links of missing items: %w", err)
+		}
+		if removed > 0 {
+			log.Printf("Revoked %d share links of missing items", removed)
+		}
+	}
+	if reconciler, ok := app.Blobs.(models.BlobReconciler); ok {
+		changed, err := reconciler.Reconcile(counts)
+		if err != nil {
+			return fmt.Errorf("failed to recount blob references: %w", err)
+		}
+		if changed > 0 {
+			log.Printf("Corrected the reference counts of %d blobs", changed)
+		}
+	}
+	return nil
+}
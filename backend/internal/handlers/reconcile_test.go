@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestReconcileItemsRepairsWhatACrashLeftBehind(t *testing.T) {
+	app := newTestApp(t, nil)
+	now := time.Now().UTC()
+
+	// The item holds one reference, but a crash after a second upload of
+	// the same bytes left the count at two; another blob lost its item.
+	hash, size, err := app.Blobs.Put(strings.NewReader("kept contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := app.Blobs.Put(strings.NewReader("kept contents")); err != nil {
+		t.Fatal(err)
+	}
+	orphan, _, err := app.Blobs.Put(strings.NewReader("orphaned contents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := &models.ClipboardItem{
+		ID: "keep", Type: "file", UserID: "alice", FileName: "a.txt", FileHash: hash, FileSize: size,
+		CollectionID: "gone", CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	}
+	if err := app.ClipboardStore.Put(item); err != nil {
+		t.Fatal(err)
+	}
+	kept := &models.Share{ItemID: "keep", UserID: "alice"}
+	dangling := &models.Share{ItemID: "deleted", UserID: "alice"}
+	for _, share := range []*models.Share{kept, dangling} {
+		if err := app.Shares.Create(share); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ReconcileItems(app); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, _ := app.ClipboardStore.Get("keep"); stored.CollectionID != "" {
+		t.Fatalf("expected the item to leave the missing collection, got %q", stored.CollectionID)
+	}
+	if _, ok := app.Shares.Get(kept.Token); !ok {
+		t.Fatal("share of an existing item was revoked")
+	}
+	if _, ok := app.Shares.Get(dangling.Token); ok {
+		t.Fatal("share of a missing item was kept")
+	}
+	if _, err := app.Blobs.Open(orphan); err == nil {
+		t.Fatal("expected the unreferenced blob to be removed")
+	}
+
+	// One release now drops the last reference.
+	if err := app.Blobs.Release(hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.Blobs.Open(hash); err == nil {
+		t.Fatal("expected the blob to go with its only reference")
+	}
+}
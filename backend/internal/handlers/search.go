@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const (
+	maxSearchQueryLength = 200
+	maxSearchResults     = 50
+	// A snippet starts a little before the first match and runs for
+	// snippetLength characters.
+	snippetLead   = 40
+	snippetLength = 160
+)
+
+// Search finds the caller's items, and items others let them see, whose
+// text or file name contains every word of q, newest first.
+func (h *Handler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is too long"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	now := time.Now().UTC()
+	terms := searchTerms(query)
+	results := make([]models.SearchResult, 0)
+	// Candidates come newest first and only those the user may see, so
+	// items are loaded only until the page is full.
+	for _, id := range h.App.Search.Search(query, user, now) {
+		if len(results) == maxSearchResults {
+			break
+		}
+		item, exists := h.App.ClipboardStore.Get(id)
+		if !exists || models.ClipboardItemExpired(item, now) || !models.CanViewItem(item, user) {
+			continue
+		}
+		snippet := buildSnippet(searchableText(item, user), terms)
+		if snippet == nil {
+			continue
+		}
+		response := h.toRecentItemResponse(item)
+		if item.UserID != user.ID {
+			response = h.toReceivedItemResponse(item, user)
+		}
+		results = append(results, models.SearchResult{RecentItemResponse: response, Snippet: snippet})
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.SearchResponse{Results: results})
+}
+
+// searchableText is what of item may be searched by user. Other users'
+// text behind a password or a read limit stays out of search, since a
+// snippet would show it without the password or without counting a read.
+func searchableText(item *models.ClipboardItem, user *models.User) string {
+	switch item.Type {
+	case "text":
+		if item.UserID != user.ID && (item.PasswordHash != "" || item.MaxReads > 0) {
+			return ""
+		}
+		return item.Content
+	case "file", "bundle":
+		return item.FileName
+	default:
+		return ""
+	}
+}
+
+// searchTerms splits a query into the lower-case words that must appear,
+// with punctuation around them dropped. Punctuation inside a word, as in
+// "users.email", has to match as written.
+func searchTerms(query string) [][]rune {
+	terms := make([][]rune, 0)
+	for _, field := range strings.Fields(query) {
+		field = strings.TrimFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if field != "" {
+			terms = append(terms, lowerRunes(field))
+		}
+	}
+	return terms
+}
+
+// lowerRunes lower-cases text rune by rune, so offsets into the result are
+// offsets into the original.
+func lowerRunes(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// buildSnippet cuts text around the first match of any term and splits it
+// into matching and plain parts. It returns nil unless every term appears.
+func buildSnippet(text string, terms [][]rune) []models.SnippetPart {
+	if len(terms) == 0 {
+		return nil
+	}
+	// Line breaks and tabs become spaces so the snippet stays on one line.
+	runes := []rune(text)
+	for i, r := range runes {
+		if unicode.IsSpace(r) {
+			runes[i] = ' '
+		}
+	}
+	lower := lowerRunes(text)
+	matched := make([]bool, len(runes))
+	first := len(runes)
+	for _, term := range terms {
+		found := false
+		for start := indexRunes(lower, term, 0); start >= 0; start = indexRunes(lower, term, start+1) {
+			found = true
+			first = min(first, start)
+			for i := start; i < start+len(term); i++ {
+				matched[i] = true
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	start := max(0, first-snippetLead)
+	end := min(len(runes), start+snippetLength)
+	parts := make([]models.SnippetPart, 0)
+	for i := start; i < end; {
+		j := i
+		for j < end && matched[j] == matched[i] {
+			j++
+		}
+		parts = append(parts, models.SnippetPart{Text: string(runes[i:j]), Match: matched[i]})
+		i = j
+	}
+	if start > 0 {
+		parts = append([]models.SnippetPart{{Text: "…"}}, parts...)
+	}
+	if end < len(runes) {
+		parts = append(parts, models.SnippetPart{Text: "…"})
+	}
+	return parts
+}
+
+// indexRunes returns the index of the first needle in haystack at or after
+// from, or -1.
+func indexRunes(haystack, needle []rune, from int) int {
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j, r := range needle {
+			if haystack[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
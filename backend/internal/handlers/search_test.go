@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+	"web-clipboard-go/backend/internal/services"
+)
+
+func searchAs(t *testing.T, router http.Handler, username, query string) models.SearchResponse {
+	t.Helper()
+	recorder := sendAs(router, username, http.MethodGet, "/api/search?q="+url.QueryEscape(query), "")
+	var response models.SearchResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("search failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	return response
+}
+
+func snippetText(result models.SearchResult) (text string, matches []string) {
+	for _, part := range result.Snippet {
+		text += part.Text
+		if part.Match {
+			matches = append(matches, part.Text)
+		}
+	}
+	return text, matches
+}
+
+func TestSearchFindsVisibleTextWithHighlightedSnippets(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+	index := services.NewSearchIndex()
+	app.ClipboardStore = services.NewIndexedClipboardStore(app.ClipboardStore, index)
+	app.Search = index
+
+	query := saveVisibilityTestText(t, router, "alice", `{"content":"report:\nSELECT email FROM users WHERE active"}`)
+	backup := saveVisibilityTestText(t, router, "alice", `{"content":"数据库备份脚本"}`)
+	saveVisibilityTestText(t, router, "alice", `{"content":"select with password","visibility":"everyone","password":"hunter22"}`)
+	shared := saveVisibilityTestText(t, router, "alice", `{"content":"select for bob","sharedWith":["bob"]}`)
+
+	results := searchAs(t, router, "alice", "users SELECT").Results
+	if len(results) != 1 || results[0].ID != query {
+		t.Fatalf("expected only the query to match, got %+v", results)
+	}
+	text, matches := snippetText(results[0])
+	if text != "report: SELECT email FROM users WHERE active" || strings.Join(matches, ",") != "SELECT,users" {
+		t.Fatalf("unexpected snippet %q with matches %v", text, matches)
+	}
+	if results := searchAs(t, router, "alice", "备份").Results; len(results) != 1 || results[0].ID != backup {
+		t.Fatalf("expected Chinese text to be found, got %+v", results)
+	}
+	if results := searchAs(t, router, "alice", "backup script").Results; len(results) != 0 {
+		t.Fatalf("expected no matches, got %+v", results)
+	}
+
+	// Bob sees what was sent to him, but not alice's private or
+	// password-protected text.
+	results = searchAs(t, router, "bob", "select").Results
+	if len(results) != 1 || results[0].ID != shared || results[0].From != "alice" {
+		t.Fatalf("expected bob to find only the item sent to him, got %+v", results)
+	}
+
+	sendAs(router, "alice", http.MethodDelete, "/api/"+query, "")
+	if results := searchAs(t, router, "alice", "email").Results; len(results) != 0 {
+		t.Fatalf("expected deleted items to leave the index, got %+v", results)
+	}
+	if recorder := sendAs(router, "alice", http.MethodGet, "/api/search?q=", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected an empty query to be rejected, got %d", recorder.Code)
+	}
+}
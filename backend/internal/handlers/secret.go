@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// Ciphertext limits leave room for the nonce, tag and, for files, the
+// encrypted name header the browser adds around the plaintext.
+const (
+	maxSecretTextSize = 1024*1024 + 1024
+	maxSecretFileSize = models.MaxFileSize + 64*1024
+)
+
+// SaveSecret stores a clip that was encrypted in the browser. The body is
+// opaque ciphertext, so unlike SaveText it is not scanned for suspicious
+// patterns; blocked clients are still turned away.
+func (h *Handler) SaveSecret(c *gin.Context) {
+	var itemType string
+	var limit int64
+	switch c.Query("type") {
+	case "text":
+		itemType, limit = "secret-text", maxSecretTextSize
+	case "file":
+		itemType, limit = "secret-file", maxSecretFileSize
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be text or file"})
+		return
+	}
+
+	if !h.App.Security.ValidateFileRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	options, err := parseItemOptions(c.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	item, err := h.newItem(user, itemType, options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkQuota(c, user, max(c.Request.ContentLength, 0)) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	hash, size, err := h.App.Blobs.Put(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Encrypted content too large"})
+			return
+		}
+		log.Printf("Failed to save encrypted clip: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save encrypted content"})
+		return
+	}
+
+	item.FileHash = hash
+	item.FileSize = size
+	item.ContentType = "application/octet-stream"
+	if size == 0 {
+		releaseItem(h.App, item)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Encrypted content is empty"})
+		return
+	}
+	if err := h.App.ClipboardStore.Put(item); err != nil {
+		releaseItem(h.App, item)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save encrypted content"})
+		return
+	}
+
+	if !h.enforceQuota(c, user, 0, 0) {
+		if removed, _ := h.App.ClipboardStore.Delete(item.ID); removed != nil {
+			releaseItem(h.App, removed)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SaveSecretResponse{
+		ID:        item.ID,
+		Type:      itemType,
+		ExpiresAt: item.ExpiresAt,
+	})
+}
+
+// GetSecret returns an encrypted clip's ciphertext exactly as it was
+// uploaded. Clipboard-Item-Type tells the browser whether it decrypts to
+// text or to a file.
+func (h *Handler) GetSecret(c *gin.Context) {
+	id := strings.ToLower(c.Param("id"))
+
+	if !h.App.Security.ValidateAccessRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access denied"})
+		return
+	}
+
+	item, exists := h.App.ClipboardStore.Get(id)
+	if !exists || !models.IsSecretItemType(item.Type) || models.ClipboardItemExpired(item, time.Now().UTC()) || !canViewItem(c, item) {
+		h.App.Security.LogAccess(c, id, "secret", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	if !h.checkItemPassword(c, item) {
+		return
+	}
+
+	item, lastView, err := h.consumeRead(item)
+	if err != nil {
+		log.Printf("Failed to record read of %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load encrypted content"})
+		return
+	}
+	if item == nil {
+		h.App.Security.LogAccess(c, id, "secret", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	if lastView {
+		defer releaseItem(h.App, item)
+	}
+
+	content, err := openItemFile(h.App, item)
+	if err != nil {
+		h.App.Security.LogAccess(c, id, "secret", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	defer content.Close()
+
+	h.App.Security.LogAccess(c, id, "secret", true)
+	h.recordOpened(c, item)
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Clipboard-Item-Type", strings.TrimPrefix(item.Type, "secret-"))
+	serveItemContent(c, item, lastView, "", content)
+}
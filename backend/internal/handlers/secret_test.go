@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// scanningSecurityService rejects every text payload, as the real pattern
+// scanner would for ciphertext that happens to contain a blocked word.
+type scanningSecurityService struct {
+	allowSecurityService
+}
+
+func (scanningSecurityService) ValidateContentRequest(c interface{}, content string) bool {
+	return false
+}
+
+func TestSecretClipsStoreOpaqueCiphertextWithoutScanning(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.Security = scanningSecurityService{}
+	router := newTestRouter(app)
+
+	ciphertext := append([]byte("\x00\x01<script>"), bytes.Repeat([]byte{0xfe}, 100)...)
+	recorder := serveAs(router, "user-1", httptest.NewRequest(http.MethodPost, "/api/secret?type=text", bytes.NewReader(ciphertext)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var saved models.SaveSecretResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Type != "secret-text" {
+		t.Fatalf("unexpected item type %q", saved.Type)
+	}
+
+	recorder = serveAs(router, "user-1", httptest.NewRequest(http.MethodGet, "/api/secret/"+saved.ID, nil))
+	if recorder.Code != http.StatusOK || !bytes.Equal(recorder.Body.Bytes(), ciphertext) {
+		t.Fatalf("expected ciphertext back unchanged, got %d %q", recorder.Code, recorder.Body.Bytes())
+	}
+	if recorder.Header().Get("Clipboard-Item-Type") != "text" || recorder.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("unexpected headers %v", recorder.Header())
+	}
+
+	// Secret clips are not readable through the plaintext endpoints.
+	recorder = serveAs(router, "user-1", httptest.NewRequest(http.MethodGet, "/api/text/"+saved.ID, nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 from GetText, got %d", recorder.Code)
+	}
+
+	recorder = serveAs(router, "user-1", httptest.NewRequest(http.MethodPost, "/api/secret", bytes.NewReader(ciphertext)))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a type, got %d", recorder.Code)
+	}
+}
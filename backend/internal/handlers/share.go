@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// CreateShare mints a public link to one of the caller's items, so it can be
+// handed to someone without an account.
+func (h *Handler) CreateShare(c *gin.Context) {
+	var request models.CreateShareRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	item, ok := h.editableItem(c, strings.ToLower(c.Param("id")))
+	if !ok {
+		return
+	}
+	if models.IsSecretItemType(item.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End-to-end encrypted items are shared through their own link"})
+		return
+	}
+	if request.MaxDownloads < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "maxDownloads cannot be negative"})
+		return
+	}
+
+	expiresAt, err := shareExpiresAt(request.ItemExpiration)
+	if err == nil && request.Password != "" {
+		request.Password, err = hashItemPassword(request.Password)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	share := &models.Share{
+		ItemID:       item.ID,
+		UserID:       user.ID,
+		ExpiresAt:    expiresAt,
+		MaxDownloads: request.MaxDownloads,
+		PasswordHash: request.Password,
+	}
+	if err := h.App.Shares.Create(share); err != nil {
+		log.Printf("Failed to create share for %s: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, toShareResponse(share))
+}
+
+// shareExpiresAt resolves a share link's own expiration. Unlike items there
+// is no default or cap: a link without one simply lasts as long as its item.
+func shareExpiresAt(expiration models.ItemExpiration) (time.Time, error) {
+	choice, err := parseExpiration(expiration)
+	if err != nil || !choice.set || choice.never {
+		return time.Time{}, err
+	}
+	now := time.Now().UTC()
+	if choice.lifetime > 0 {
+		return now.Add(choice.lifetime), nil
+	}
+	if !choice.at.After(now) {
+		return time.Time{}, invalidItemOption("expiration must be in the future")
+	}
+	return choice.at, nil
+}
+
+// ListShares returns the item's active share links, newest first.
+func (h *Handler) ListShares(c *gin.Context) {
+	item, ok := h.editableItem(c, strings.ToLower(c.Param("id")))
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	shares := make([]models.ShareResponse, 0)
+	for _, share := range h.App.Shares.ListByItem(item.ID) {
+		if models.ShareExpired(share, now) {
+			continue
+		}
+		shares = append(shares, toShareResponse(share))
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CreatedAt.After(shares[j].CreatedAt)
+	})
+
+	c.JSON(http.StatusOK, models.ListSharesResponse{Shares: shares})
+}
+
+// DeleteShare revokes one of the item's share links.
+func (h *Handler) DeleteShare(c *gin.Context) {
+	item, ok := h.editableItem(c, strings.ToLower(c.Param("id")))
+	if !ok {
+		return
+	}
+	share, exists := h.App.Shares.Get(c.Param("token"))
+	if !exists || share.ItemID != item.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+	if err := h.App.Shares.Delete(share.Token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+func toShareResponse(share *models.Share) models.ShareResponse {
+	return models.ShareResponse{
+		Token:             share.Token,
+		Path:              "/s/" + share.Token,
+		ItemID:            share.ItemID,
+		CreatedAt:         share.CreatedAt,
+		ExpiresAt:         share.ExpiresAt,
+		MaxDownloads:      share.MaxDownloads,
+		DownloadCount:     share.DownloadCount,
+		PasswordProtected: share.PasswordHash != "",
+	}
+}
+
+// OpenShare serves a shared item to anyone holding the link; it sits outside
+// /api and needs no login. A link's own password does not waive the item's:
+// both are required when set. Every download counts against both the
+// link's limit and the item's own read limit.
+func (h *Handler) OpenShare(c *gin.Context) {
+	if !h.App.Security.ValidateAccessRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access denied"})
+		return
+	}
+
+	share, item, ok := h.activeShare(c.Param("token"))
+	if !ok {
+		h.App.Security.LogAccess(c, "link", "share", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found or expired"})
+		return
+	}
+	if !h.checkSharePasswords(c, share, item) {
+		return
+	}
+
+	share, err := h.App.Shares.ConsumeDownload(share.Token)
+	if err != nil {
+		log.Printf("Failed to record download of a share of %s: %v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load shared item"})
+		return
+	}
+	if share == nil {
+		h.App.Security.LogAccess(c, "link", "share", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found or expired"})
+		return
+	}
+	item, lastView, err := h.consumeRead(item)
+	if err != nil || item == nil {
+		// Nothing is served, so the link keeps its download.
+		if err := h.App.Shares.ReturnDownload(share); err != nil {
+			log.Printf("Failed to return unused download of a share of %s: %v", share.ItemID, err)
+		}
+	}
+	if err != nil {
+		log.Printf("Failed to record read of %s: %v", share.ItemID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load shared item"})
+		return
+	}
+	if item == nil {
+		h.App.Security.LogAccess(c, "link", "share", false)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found or expired"})
+		return
+	}
+	if lastView {
+		defer releaseItem(h.App, item)
+	}
+	if share.MaxDownloads > 0 {
+		c.Request.Header.Del("Range")
+		c.Header("Cache-Control", "no-store")
+	}
+
+	h.App.Security.LogAccess(c, item.ID, "share", true)
+	if item.Type == "text" {
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(item.Content))
+		return
+	}
+	if item.Type == "bundle" {
+		if lastView {
+			c.Header(clipboardLastViewHeader, "true")
+		}
+		writeBundleZip(c, h.App, item)
+		return
+	}
+
+	content, err := openItemFile(h.App, item)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found on disk"})
+		return
+	}
+	defer content.Close()
+	c.Header("Content-Disposition", contentDispositionHeader(item.FileName))
+	serveItemContent(c, item, lastView, item.FileName, content)
+}
+
+// checkSharePasswords checks a link's password and then its item's, and
+// writes the response when one is missing or wrong: a form for browsers,
+// which posts the passwords back as "password" and "itemPassword", and a
+// 403 naming which password is needed for other clients. Those send the
+// link's password in Clipboard-Share-Password, or in
+// Clipboard-Item-Password when the item has none of its own, and the
+// item's password in Clipboard-Item-Password.
+func (h *Handler) checkSharePasswords(c *gin.Context, share *models.Share, item *models.ClipboardItem) bool {
+	linkPassword := c.GetHeader(clipboardSharePasswordHeader)
+	itemPassword := c.GetHeader(clipboardItemPasswordHeader)
+	if linkPassword == "" && item.PasswordHash == "" {
+		linkPassword = itemPassword
+	}
+	if c.Request.Method == http.MethodPost {
+		linkPassword, itemPassword = c.PostForm("password"), c.PostForm("itemPassword")
+	}
+
+	checks := []struct{ passwordFor, hash, password, subject string }{
+		{"link", share.PasswordHash, linkPassword, "share of " + item.ID},
+		{"item", item.PasswordHash, itemPassword, item.ID},
+	}
+	for _, check := range checks {
+		if check.hash == "" {
+			continue
+		}
+		status, message := h.verifyPassword(c, check.hash, check.password, check.subject)
+		if status == 0 {
+			continue
+		}
+		if strings.Contains(c.GetHeader("Accept"), "text/html") && status != http.StatusTooManyRequests {
+			renderSharePasswordForm(c, status, sharePasswordForm{
+				Link:  share.PasswordHash != "",
+				Item:  item.PasswordHash != "",
+				Wrong: check.password != "",
+			})
+			return false
+		}
+		c.JSON(status, gin.H{"error": message, "passwordRequired": status == http.StatusForbidden, "passwordFor": check.passwordFor})
+		return false
+	}
+	return true
+}
+
+// activeShare looks up an unexpired link together with its unexpired item.
+func (h *Handler) activeShare(token string) (*models.Share, *models.ClipboardItem, bool) {
+	now := time.Now().UTC()
+	share, exists := h.App.Shares.Get(token)
+	if !exists || models.ShareExpired(share, now) {
+		return nil, nil, false
+	}
+	item, exists := h.App.ClipboardStore.Get(share.ItemID)
+	if !exists || models.ClipboardItemExpired(item, now) || models.IsSecretItemType(item.Type) {
+		return nil, nil, false
+	}
+	return share, item, true
+}
+
+var sharePasswordPage = template.Must(template.New("share-password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Web Clipboard</title>
+<style>
+body { font-family: sans-serif; background: #f3f4f6; display: flex; justify-content: center; padding-top: 15vh; }
+form { background: #fff; padding: 24px; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,.2); width: 280px; }
+input, button { width: 100%; box-sizing: border-box; padding: 8px; margin-top: 12px; font-size: 14px; }
+button { background: #3b82f6; color: #fff; border: 0; border-radius: 4px; cursor: pointer; }
+.error { color: #dc2626; font-size: 14px; }
+</style>
+</head>
+<body>
+<form method="post">
+<p>This link is password protected. / 此链接需要密码。</p>
+{{if .Wrong}}<p class="error">Incorrect password. / 密码错误。</p>{{end}}
+{{if .Link}}<label for="password">Link password / 链接密码</label>
+<input id="password" name="password" type="password" autocomplete="off" autofocus required>
+{{end}}{{if .Item}}<label for="itemPassword">Item password / 条目访问密码</label>
+<input id="itemPassword" name="itemPassword" type="password" autocomplete="off"{{if not .Link}} autofocus{{end}} required>
+{{end}}<button type="submit">Open / 打开</button>
+</form>
+</body>
+</html>
+`))
+
+// sharePasswordForm tells the password page which fields to show.
+type sharePasswordForm struct {
+	Link, Item, Wrong bool
+}
+
+func renderSharePasswordForm(c *gin.Context, status int, form sharePasswordForm) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := sharePasswordPage.Execute(c.Writer, form); err != nil {
+		log.Printf("Failed to render share password form: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func createTestShare(t *testing.T, router http.Handler, itemID, body string) models.ShareResponse {
+	t.Helper()
+	recorder := sendAs(router, "user-1", http.MethodPost, "/api/items/"+itemID+"/shares", body)
+	var share models.ShareResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &share); err != nil || recorder.Code != http.StatusCreated {
+		t.Fatalf("create share failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	return share
+}
+
+func TestShareLinkServesItemWithoutLoginUntilItsDownloadLimit(t *testing.T) {
+	router := newTestRouter(newTestApp(t, nil, &models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "contract draft"}))
+
+	if recorder := sendAs(router, "user-2", http.MethodPost, "/api/items/abcd/shares", `{}`); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected someone else's item to be unshareable, got %d", recorder.Code)
+	}
+
+	share := createTestShare(t, router, "abcd", `{"maxDownloads":2}`)
+	for download := 1; download <= 3; download++ {
+		recorder := sendAs(router, "", http.MethodGet, share.Path, "")
+		if download <= 2 && (recorder.Code != http.StatusOK || recorder.Body.String() != "contract draft") {
+			t.Fatalf("download %d: expected the text, got %d %s", download, recorder.Code, recorder.Body.String())
+		}
+		if download == 3 && recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected the used-up link to be gone, got %d", recorder.Code)
+		}
+	}
+}
+
+func TestShareLinkPasswordFormAndRevocation(t *testing.T) {
+	app := newTestApp(t, nil, &models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "contract draft"})
+	router := newTestRouter(app)
+	share := createTestShare(t, router, "abcd", `{"password":"s3cret","expiresIn":"1d"}`)
+	if !share.PasswordProtected || share.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected share %#v", share)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, share.Path, nil)
+	request.Header.Set("Accept", "text/html")
+	recorder := serveAs(router, "", request)
+	if recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), `name="password"`) {
+		t.Fatalf("expected a password form, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	post := func(password string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, share.Path, strings.NewReader(url.Values{"password": {password}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		request.Header.Set("Accept", "text/html")
+		return serveAs(router, "", request)
+	}
+	if recorder := post("guess"); recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), "Incorrect password") {
+		t.Fatalf("expected the form again for a wrong password, got %d", recorder.Code)
+	}
+	if recorder := post("s3cret"); recorder.Code != http.StatusOK || recorder.Body.String() != "contract draft" {
+		t.Fatalf("expected the text for the right password, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = sendAs(router, "user-1", http.MethodGet, "/api/items/abcd/shares", "")
+	var list models.ListSharesResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil || len(list.Shares) != 1 || list.Shares[0].Token != share.Token {
+		t.Fatalf("expected the share in the item's list, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if recorder := sendAs(router, "user-1", http.MethodDelete, "/api/items/abcd/shares/"+share.Token, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("revoke failed: %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := post("s3cret"); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected the revoked link to be gone, got %d", recorder.Code)
+	}
+
+	// Deleting the item takes its links with it.
+	share = createTestShare(t, router, "abcd", `{}`)
+	if removed, _ := app.ClipboardStore.Delete("abcd"); removed != nil {
+		releaseItem(app, removed)
+	}
+	if _, exists := app.Shares.Get(share.Token); exists {
+		t.Fatal("expected the item's links to be revoked with it")
+	}
+}
+
+func TestShareLinkStillNeedsTheItemPassword(t *testing.T) {
+	hash, err := hashItemPassword("item-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(newTestApp(t, nil, &models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "contract draft", PasswordHash: hash}))
+	open := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		return serveAs(router, "", request)
+	}
+
+	plain := createTestShare(t, router, "abcd", `{}`)
+	if recorder := open(plain.Path, nil); recorder.Code != http.StatusForbidden || !strings.Contains(recorder.Body.String(), `"passwordFor":"item"`) {
+		t.Fatalf("expected the item password to be required, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := open(plain.Path, map[string]string{clipboardItemPasswordHeader: "item-pass"}); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the text with the item password, got %d", recorder.Code)
+	}
+
+	protected := createTestShare(t, router, "abcd", `{"password":"link-pass"}`)
+	if recorder := open(protected.Path, map[string]string{clipboardSharePasswordHeader: "link-pass"}); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected the link password alone to be refused, got %d", recorder.Code)
+	}
+	recorder := open(protected.Path, map[string]string{clipboardSharePasswordHeader: "link-pass", clipboardItemPasswordHeader: "item-pass"})
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "contract draft" {
+		t.Fatalf("expected the text with both passwords, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	form := open(protected.Path, map[string]string{"Accept": "text/html"})
+	if !strings.Contains(form.Body.String(), `name="password"`) || !strings.Contains(form.Body.String(), `name="itemPassword"`) {
+		t.Fatalf("expected the form to ask for both passwords, got %s", form.Body.String())
+	}
+}
+
+func TestShareLinkKeepsItsDownloadWhenTheItemIsUsedUp(t *testing.T) {
+	item := models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "contract draft", MaxReads: 1}
+	app := newTestApp(t, nil, &item)
+	router := newTestRouter(app)
+	share := createTestShare(t, router, "abcd", `{"maxDownloads":1}`)
+
+	// Another reader takes the last read after the link looked the item up.
+	store := app.ClipboardStore
+	app.ClipboardStore = staleClipboardStore{ClipboardStore: store, snapshot: item}
+	if _, _, err := store.ConsumeRead("abcd"); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorder := sendAs(router, "", http.MethodGet, share.Path, ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a used-up item, got %d", recorder.Code)
+	}
+	if kept, exists := app.Shares.Get(share.Token); !exists || kept.DownloadCount != 0 {
+		t.Fatalf("expected the link to keep its download, got %#v", kept)
+	}
+}
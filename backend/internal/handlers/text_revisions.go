@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// maxTextRevisions is how many earlier contents an edited text item keeps;
+// older ones are dropped.
+const maxTextRevisions = 50
+
+// errRevisionConflict means the text was changed since the editor read it.
+var errRevisionConflict = errors.New("text was changed since it was read")
+
+// textETag names a revision of a text item for If-Match.
+func textETag(item *models.ClipboardItem) string {
+	return fmt.Sprintf(`"%s-%d"`, item.ID, models.CurrentTextRevision(item))
+}
+
+// matchesETag reports whether an If-Match header names etag or is "*".
+func matchesETag(ifMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateText replaces the content of a text item, keeping the old content
+// as a revision. The If-Match header must name the revision the editor
+// started from, so a device that missed another device's edit gets a 412
+// instead of overwriting it.
+func (h *Handler) UpdateText(c *gin.Context) {
+	id := strings.ToLower(c.Param("id"))
+
+	var request models.UpdateTextRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+		return
+	}
+	if !h.App.Security.ValidateContentRequest(c, request.Content) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	item, ok := h.editableItem(c, id)
+	if !ok {
+		return
+	}
+	if item.Type != "text" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	// The old content stays as a revision, so the new content is all extra.
+	if !h.enforceQuota(c, h.itemOwner(item, user), int64(len(request.Content)), 0) {
+		return
+	}
+
+	now := time.Now().UTC()
+	updated, err := h.App.ClipboardStore.Update(id, func(item *models.ClipboardItem) error {
+		if !matchesETag(ifMatch, textETag(item)) {
+			return errRevisionConflict
+		}
+		revisions := append(slices.Clone(item.Revisions), currentTextRevision(item))
+		if len(revisions) > maxTextRevisions {
+			revisions = revisions[len(revisions)-maxTextRevisions:]
+		}
+		item.Revisions = revisions
+		item.Revision = models.CurrentTextRevision(item) + 1
+		item.Content = request.Content
+		item.UpdatedAt = now
+		item.EditorID = user.ID
+		return nil
+	})
+	if errors.Is(err, errRevisionConflict) {
+		if current, exists := h.App.ClipboardStore.Get(id); exists {
+			c.Header("ETag", textETag(current))
+		}
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "The text was changed elsewhere, reload it before saving"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update text %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save text"})
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+
+	c.Header("ETag", textETag(updated))
+	c.JSON(http.StatusOK, models.UpdateTextResponse{
+		ID:        updated.ID,
+		Revision:  updated.Revision,
+		UpdatedAt: updated.UpdatedAt,
+	})
+}
+
+// ListTextRevisions lists every revision of a text item the caller may
+// edit, newest first.
+func (h *Handler) ListTextRevisions(c *gin.Context) {
+	item, ok := h.editableTextItem(c)
+	if !ok {
+		return
+	}
+
+	revisions := make([]models.TextRevisionResponse, 0, len(item.Revisions)+1)
+	current := h.textRevisionResponse(currentTextRevision(item))
+	current.Current = true
+	revisions = append(revisions, current)
+	for i := len(item.Revisions) - 1; i >= 0; i-- {
+		revisions = append(revisions, h.textRevisionResponse(item.Revisions[i]))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, models.ListTextRevisionsResponse{Revisions: revisions})
+}
+
+// GetTextRevision returns one revision of a text item, content included.
+func (h *Handler) GetTextRevision(c *gin.Context) {
+	item, ok := h.editableTextItem(c)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision"})
+		return
+	}
+
+	revisions := append(slices.Clone(item.Revisions), currentTextRevision(item))
+	index := slices.IndexFunc(revisions, func(revision models.TextRevision) bool { return revision.Revision == number })
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	response := h.textRevisionResponse(revisions[index])
+	response.Current = index == len(revisions)-1
+	response.Content = revisions[index].Content
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+// editableTextItem loads the text item named in the path if the caller may
+// edit it, writing a 404 otherwise.
+func (h *Handler) editableTextItem(c *gin.Context) (*models.ClipboardItem, bool) {
+	item, ok := h.editableItem(c, strings.ToLower(c.Param("id")))
+	if !ok {
+		return nil, false
+	}
+	if item.Type != "text" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return nil, false
+	}
+	return item, true
+}
+
+// currentTextRevision describes a text item's current content as a revision.
+func currentTextRevision(item *models.ClipboardItem) models.TextRevision {
+	revision := models.TextRevision{
+		Revision:  models.CurrentTextRevision(item),
+		Content:   item.Content,
+		EditorID:  item.EditorID,
+		CreatedAt: item.UpdatedAt,
+	}
+	if revision.EditorID == "" {
+		revision.EditorID, revision.CreatedAt = item.UserID, item.CreatedAt
+	}
+	return revision
+}
+
+func (h *Handler) textRevisionResponse(revision models.TextRevision) models.TextRevisionResponse {
+	response := models.TextRevisionResponse{
+		Revision:  revision.Revision,
+		CreatedAt: revision.CreatedAt,
+		Size:      len(revision.Content),
+	}
+	if editor := h.App.UserManager.GetUser(revision.EditorID); editor != nil {
+		response.Editor = editor.Username
+	}
+	return response
+}
+
+// itemOwner returns the user who owns item, whose quota its storage counts
+// against; user when that is the caller or the owner no longer exists.
+func (h *Handler) itemOwner(item *models.ClipboardItem, user *models.User) *models.User {
+	if item.UserID == user.ID {
+		return user
+	}
+	if owner := h.App.UserManager.GetUser(item.UserID); owner != nil {
+		return owner
+	}
+	return user
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func putTextAs(router http.Handler, username, id, ifMatch, content string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPut, "/api/text/"+id, strings.NewReader(`{"content":"`+content+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		request.Header.Set("If-Match", ifMatch)
+	}
+	return serveAs(router, username, request)
+}
+
+func TestUpdateTextKeepsRevisionsAndRefusesStaleEdits(t *testing.T) {
+	router := newTestRouter(newTestApp(t, nil))
+	id := saveVisibilityTestText(t, router, "alice", `{"content":"helo world","visibility":"everyone"}`)
+
+	read := sendAs(router, "alice", http.MethodGet, "/api/text/"+id, "")
+	etag := read.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected GET to return an ETag")
+	}
+
+	if recorder := putTextAs(router, "alice", id, "", "hello world"); recorder.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected an edit without If-Match to be refused, got %d", recorder.Code)
+	}
+	if recorder := putTextAs(router, "bob", id, etag, "hijacked"); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected only the owner to edit, got %d", recorder.Code)
+	}
+	laptop := putTextAs(router, "alice", id, etag, "hello world")
+	if laptop.Code != http.StatusOK || laptop.Header().Get("ETag") == etag {
+		t.Fatalf("edit failed: %d %s", laptop.Code, laptop.Body.String())
+	}
+	phone := putTextAs(router, "alice", id, etag, "helo, world")
+	if phone.Code != http.StatusPreconditionFailed || phone.Header().Get("ETag") != laptop.Header().Get("ETag") {
+		t.Fatalf("expected a stale edit to be refused with the current ETag, got %d %q", phone.Code, phone.Header().Get("ETag"))
+	}
+
+	var current models.GetTextResponse
+	json.Unmarshal(sendAs(router, "bob", http.MethodGet, "/api/text/"+id, "").Body.Bytes(), &current)
+	if current.Content != "hello world" || current.Revision != 2 {
+		t.Fatalf("expected revision 2 to be served, got %+v", current)
+	}
+
+	var list models.ListTextRevisionsResponse
+	json.Unmarshal(sendAs(router, "alice", http.MethodGet, "/api/text/"+id+"/revisions", "").Body.Bytes(), &list)
+	if len(list.Revisions) != 2 || !list.Revisions[0].Current || list.Revisions[0].Revision != 2 || list.Revisions[1].Editor != "alice" {
+		t.Fatalf("unexpected revisions %+v", list.Revisions)
+	}
+	var first models.TextRevisionResponse
+	json.Unmarshal(sendAs(router, "alice", http.MethodGet, "/api/text/"+id+"/revisions/1", "").Body.Bytes(), &first)
+	if first.Content != "helo world" || first.Current {
+		t.Fatalf("expected the original content as revision 1, got %+v", first)
+	}
+	if recorder := sendAs(router, "bob", http.MethodGet, "/api/text/"+id+"/revisions", ""); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected history to be limited to the owner, got %d", recorder.Code)
+	}
+}
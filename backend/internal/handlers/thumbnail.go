@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const (
+	// thumbnailSize bounds both sides of a thumbnail.
+	thumbnailSize = 320
+	// maxThumbnailSourcePixels keeps a small file that claims huge
+	// dimensions from being decoded; a decoded image takes about four
+	// bytes per pixel.
+	maxThumbnailSourcePixels = 16_000_000
+	// thumbnailWorkers is how many images are decoded at once, and
+	// thumbnailQueueSize how many more may wait for a worker.
+	thumbnailWorkers   = 2
+	thumbnailQueueSize = 256
+)
+
+// thumbnailContentTypes are the image types thumbnails are made of; the
+// decoders for all of them are pure Go.
+var thumbnailContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// thumbnailJob asks for the thumbnail of the image blob of an item.
+type thumbnailJob struct {
+	app    *models.App
+	itemID string
+	hash   string
+}
+
+var (
+	thumbnailQueue      = make(chan thumbnailJob, thumbnailQueueSize)
+	startThumbnailQueue sync.Once
+	// pendingThumbnails counts queued jobs until they finish, so tests can
+	// wait for them.
+	pendingThumbnails sync.WaitGroup
+)
+
+// queueThumbnail has a background worker record an image item's
+// dimensions and thumbnail once it is saved, so uploads neither wait for
+// the decode nor hold the decoded image in memory. When the queue is
+// full the item is left without a thumbnail.
+func (h *Handler) queueThumbnail(item *models.ClipboardItem) {
+	if !slices.Contains(thumbnailContentTypes, item.ContentType) || item.FileHash == "" {
+		return
+	}
+	startThumbnailQueue.Do(func() {
+		for i := 0; i < thumbnailWorkers; i++ {
+			go func() {
+				for job := range thumbnailQueue {
+					attachThumbnail(job)
+					pendingThumbnails.Done()
+				}
+			}()
+		}
+	})
+
+	pendingThumbnails.Add(1)
+	select {
+	case thumbnailQueue <- thumbnailJob{app: h.App, itemID: item.ID, hash: item.FileHash}:
+	default:
+		pendingThumbnails.Done()
+		log.Printf("Thumbnail queue is full, skipping thumbnail of %s", item.ID)
+	}
+}
+
+// attachThumbnail records the dimensions of an image item and stores a
+// thumbnail of it. The image is read once: the bytes DecodeConfig consumes
+// are replayed for the full decode. Images that cannot be decoded are kept
+// as plain files.
+func attachThumbnail(job thumbnailJob) {
+	content, err := job.app.Blobs.Open(job.hash)
+	if err != nil {
+		log.Printf("Failed to open image %s for a thumbnail: %v", job.itemID, err)
+		return
+	}
+	defer content.Close()
+
+	var head bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(content, &head))
+	if err != nil {
+		return
+	}
+	var thumbnail *models.ItemThumbnail
+	if config.Width > 0 && config.Height > 0 && int64(config.Width)*int64(config.Height) <= maxThumbnailSourcePixels {
+		data, contentType, bounds, err := makeThumbnail(io.MultiReader(&head, content))
+		if err != nil {
+			log.Printf("Failed to make thumbnail of %s: %v", job.itemID, err)
+		} else if hash, _, err := job.app.Blobs.Put(bytes.NewReader(data)); err != nil {
+			log.Printf("Failed to store thumbnail of %s: %v", job.itemID, err)
+		} else {
+			thumbnail = &models.ItemThumbnail{
+				Hash:        hash,
+				ContentType: contentType,
+				Width:       bounds.Dx(),
+				Height:      bounds.Dy(),
+			}
+		}
+	}
+
+	updated, err := job.app.ClipboardStore.Update(job.itemID, func(item *models.ClipboardItem) error {
+		item.Width, item.Height = config.Width, config.Height
+		item.Thumbnail = thumbnail
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to record thumbnail of %s: %v", job.itemID, err)
+	}
+	// The item may have been deleted or read to its limit meanwhile.
+	if (updated == nil || err != nil) && thumbnail != nil {
+		if err := job.app.Blobs.Release(thumbnail.Hash); err != nil {
+			log.Printf("Failed to release thumbnail of %s: %v", job.itemID, err)
+		}
+	}
+}
+
+// makeThumbnail decodes an image (the first frame of a GIF) and scales it
+// to fit in thumbnailSize×thumbnailSize, never enlarging it. Opaque images
+// become JPEG; those with transparency stay PNG so it is kept.
+func makeThumbnail(r io.Reader) ([]byte, string, image.Rectangle, error) {
+	source, _, err := image.Decode(r)
+	if err != nil {
+		return nil, "", image.Rectangle{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := source.Bounds()
+	width, height := fitThumbnail(bounds.Dx(), bounds.Dy())
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), source, bounds, draw.Src, nil)
+
+	var buffer bytes.Buffer
+	contentType := "image/jpeg"
+	if scaled.Opaque() {
+		err = jpeg.Encode(&buffer, scaled, &jpeg.Options{Quality: 80})
+	} else {
+		contentType = "image/png"
+		err = png.Encode(&buffer, scaled)
+	}
+	if err != nil {
+		return nil, "", image.Rectangle{}, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buffer.Bytes(), contentType, scaled.Bounds(), nil
+}
+
+// fitThumbnail scales width and height down to fit thumbnailSize, keeping
+// the aspect ratio and at least one pixel on each side.
+func fitThumbnail(width, height int) (int, int) {
+	if width <= thumbnailSize && height <= thumbnailSize {
+		return width, height
+	}
+	if width >= height {
+		return thumbnailSize, max(1, height*thumbnailSize/width)
+	}
+	return max(1, width*thumbnailSize/height), thumbnailSize
+}
+
+// GetThumbnail serves the thumbnail of an image item. Fetching it is not a
+// read, so other users only get thumbnails of items without a read limit;
+// the item password is still required.
+func (h *Handler) GetThumbnail(c *gin.Context) {
+	id := strings.ToLower(c.Param("id"))
+
+	if !h.App.Security.ValidateAccessRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Access denied"})
+		return
+	}
+
+	user := c.MustGet("user").(*models.User)
+	item, exists := h.App.ClipboardStore.Get(id)
+	if !exists || item.Type != "file" || models.ClipboardItemExpired(item, time.Now().UTC()) || !canViewItem(c, item) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found or expired"})
+		return
+	}
+	if item.Thumbnail == nil || (item.MaxReads > 0 && !models.CanEditItem(item, user)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item has no thumbnail"})
+		return
+	}
+	if !h.checkItemPassword(c, item) {
+		return
+	}
+
+	content, err := h.App.Blobs.Open(item.Thumbnail.Hash)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found on disk"})
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", item.Thumbnail.ContentType)
+	c.Header("Cache-Control", "private, max-age=3600")
+	http.ServeContent(c.Writer, c.Request, "", item.CreatedAt, content)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func uploadImageAs(t *testing.T, router http.Handler, username string, fields map[string]string) models.SaveFileResponse {
+	t.Helper()
+	picture := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for x := 0; x < 800; x++ {
+		for y := 0; y < 400; y++ {
+			picture.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, picture); err != nil {
+		t.Fatal(err)
+	}
+	return uploadFileAs(t, router, username, "screenshot.png", encoded.Bytes(), fields)
+}
+
+// uploadFileAs saves content as a file item through POST /api/file.
+func uploadFileAs(t *testing.T, router http.Handler, username, fileName string, content []byte, fields map[string]string) models.SaveFileResponse {
+	t.Helper()
+	recorder := postFileAs(router, username, fileName, content, fields)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("upload failed with %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var saved models.SaveFileResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &saved); err != nil {
+		t.Fatal(err)
+	}
+	return saved
+}
+
+func postFileAs(router http.Handler, username, fileName string, content []byte, fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write(content)
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/file", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return serveAs(router, username, request)
+}
+
+func TestImageUploadsGetDimensionsAndAThumbnail(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	saved := uploadImageAs(t, router, "alice", map[string]string{"visibility": "everyone"})
+	pendingThumbnails.Wait()
+	item, _ := app.ClipboardStore.Get(saved.ID)
+	if item.Width != 800 || item.Height != 400 || item.Thumbnail == nil || item.Thumbnail.Width != 320 || item.Thumbnail.Height != 160 {
+		t.Fatalf("unexpected image metadata: %dx%d, thumbnail %#v", item.Width, item.Height, item.Thumbnail)
+	}
+
+	var listed models.ListRecentItemsResponse
+	json.Unmarshal(sendAs(router, "alice", http.MethodGet, "/api/items", "").Body.Bytes(), &listed)
+	if len(listed.Items) != 1 || listed.Items[0].Width != 800 || listed.Items[0].Height != 400 || !listed.Items[0].Thumbnail {
+		t.Fatalf("expected dimensions and thumbnail in the listing, got %#v", listed.Items)
+	}
+
+	recorder := sendAs(router, "bob", http.MethodGet, "/api/file/"+saved.ID+"/thumbnail", "")
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("expected a JPEG thumbnail, got %d %v", recorder.Code, recorder.Header())
+	}
+	thumbnail, _, err := image.DecodeConfig(recorder.Body)
+	if err != nil || thumbnail.Width != 320 || thumbnail.Height != 160 {
+		t.Fatalf("unexpected thumbnail %#v: %v", thumbnail, err)
+	}
+
+	// Showing a thumbnail is not a read, so read-limited images keep theirs
+	// to the owner.
+	limited := uploadImageAs(t, router, "alice", map[string]string{"visibility": "everyone", "maxReads": "1"})
+	pendingThumbnails.Wait()
+	if code := sendAs(router, "bob", http.MethodGet, "/api/file/"+limited.ID+"/thumbnail", "").Code; code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's read-limited thumbnail, got %d", code)
+	}
+	if code := sendAs(router, "alice", http.MethodGet, "/api/file/"+limited.ID+"/thumbnail", "").Code; code != http.StatusOK {
+		t.Fatalf("expected the owner to get the thumbnail, got %d", code)
+	}
+
+	// Both uploads hold the same image, so they share one thumbnail blob
+	// until the second is gone.
+	for _, id := range []string{saved.ID, limited.ID} {
+		if code := sendAs(router, "alice", http.MethodDelete, "/api/"+id, "").Code; code != http.StatusOK {
+			t.Fatalf("delete failed with %d", code)
+		}
+	}
+	if _, err := app.Blobs.Open(item.Thumbnail.Hash); err == nil {
+		t.Fatal("expected the thumbnail to be removed with its item")
+	}
+}
+
+func TestHugeImagesOnlyGetTheirDimensions(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	// Just the header of a 5000×4000 PNG: enough for DecodeConfig, and
+	// over the pixel limit, so it must not be decoded.
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], 5000)
+	binary.BigEndian.PutUint32(header[4:], 4000)
+	header[8], header[9] = 8, 6
+	chunk := append([]byte("IHDR"), header...)
+	content := append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0, 13)
+	content = append(content, chunk...)
+	content = binary.BigEndian.AppendUint32(content, crc32.ChecksumIEEE(chunk))
+
+	saved := uploadFileAs(t, router, "alice", "huge.png", content, nil)
+	pendingThumbnails.Wait()
+	item, _ := app.ClipboardStore.Get(saved.ID)
+	if item.Width != 5000 || item.Height != 4000 || item.Thumbnail != nil {
+		t.Fatalf("expected only the dimensions, got %dx%d, thumbnail %#v", item.Width, item.Height, item.Thumbnail)
+	}
+}
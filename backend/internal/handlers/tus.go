@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// clipboardItemIDHeader tells tus clients which clipboard item a finished
+// upload became.
+const clipboardItemIDHeader = "Clipboard-Item-Id"
+
+// clipboardLastViewHeader marks the download that used up a read-limited
+// item.
+const clipboardLastViewHeader = "Clipboard-Last-View"
+
+// CreateUpload starts a resumable upload (tus creation extension). The file
+// name and type come from the Upload-Metadata "filename" and "filetype" keys;
+// item options such as "maxReads" and "expiresIn" are read from the same header.
+func (h *Handler) CreateUpload(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+	if !h.App.Security.ValidateFileRequest(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request rejected for security reasons"})
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header is required"})
+		return
+	}
+	if length > models.MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large (max 50MB)"})
+		return
+	}
+
+	metadata := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if !h.App.Security.ValidateFileType(metadata["filename"]) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File type not allowed"})
+		return
+	}
+	user := c.MustGet("user").(*models.User)
+	options, err := parseItemOptions(func(key string) string { return metadata[key] })
+	if err == nil {
+		err = h.checkItemOptions(user, options)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkQuota(c, user, length) {
+		return
+	}
+
+	upload := &models.Upload{
+		UserID:       user.ID,
+		Length:       length,
+		FileName:     metadata["filename"],
+		FileType:     metadata["filetype"],
+		Options:      uploadItemOptions(metadata),
+		PasswordHash: options.PasswordHash,
+	}
+	if err := h.App.Uploads.Create(upload); err != nil {
+		log.Printf("Failed to create upload: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	if length == 0 && !h.finishUpload(c, user, upload) {
+		return
+	}
+	c.Header("Location", "/api/uploads/"+upload.ID)
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusCreated)
+}
+
+// GetUploadOffset reports how much of an upload the server has (tus HEAD).
+func (h *Handler) GetUploadOffset(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+	upload, ok := h.ownedUpload(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload appends a chunk at Upload-Offset. The request that delivers
+// the last byte assembles the upload into a file clipboard item.
+func (h *Handler) PatchUpload(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required"})
+		return
+	}
+	upload, ok := h.ownedUpload(c)
+	if !ok {
+		return
+	}
+	if c.Request.ContentLength > upload.Length-offset {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk exceeds Upload-Length"})
+		return
+	}
+
+	upload, err = h.App.Uploads.Append(upload.ID, offset, c.Request.Body)
+	switch {
+	case errors.Is(err, models.ErrUploadOffsetMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+		return
+	case errors.Is(err, models.ErrUploadLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload is busy, retry later"})
+		return
+	case err != nil && upload == nil:
+		log.Printf("Failed to append to upload %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save chunk"})
+		return
+	case err != nil:
+		// The client went away mid-chunk; what arrived is kept for resuming.
+		setUploadHeaders(c, upload)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chunk upload interrupted"})
+		return
+	}
+
+	if upload.Offset == upload.Length {
+		user := c.MustGet("user").(*models.User)
+		if !h.finishUpload(c, user, upload) {
+			return
+		}
+	}
+	setUploadHeaders(c, upload)
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUpload terminates an upload and discards its data (tus termination).
+func (h *Handler) DeleteUpload(c *gin.Context) {
+	if !checkTusResumable(c) {
+		return
+	}
+	upload, ok := h.ownedUpload(c)
+	if !ok {
+		return
+	}
+
+	if err := h.App.Uploads.Delete(upload.ID); err != nil {
+		if errors.Is(err, models.ErrUploadLocked) {
+			c.JSON(http.StatusLocked, gin.H{"error": "Upload is busy, retry later"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete upload"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// finishUpload turns a fully received upload into a file clipboard item. On
+// failure it writes the error response; the upload is left in place so a
+// zero-length PATCH at the final offset can retry.
+func (h *Handler) finishUpload(c *gin.Context, user *models.User, upload *models.Upload) bool {
+	// The upload's bytes are already counted as reserved.
+	if !h.enforceQuota(c, user, 0, 1) {
+		return false
+	}
+
+	// Options were checked when the upload was created, but an absolute
+	// expiration may have passed since.
+	options, err := parseItemOptions(func(key string) string { return upload.Options[key] })
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	options.PasswordHash = upload.PasswordHash
+
+	content, err := h.App.Uploads.Open(upload.ID)
+	if err != nil {
+		log.Printf("Failed to open completed upload %s: %v", upload.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return false
+	}
+	defer content.Close()
+
+	sniffer := &contentSniffer{}
+	item, err := h.storeFileItem(user, upload.FileName, io.TeeReader(content, sniffer), func() string {
+		return sniffer.ContentType(upload.FileType)
+	}, options)
+	if errors.Is(err, errInvalidItemOption) || errors.Is(err, errUnreadableImage) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if err != nil {
+		log.Printf("Failed to save completed upload %s: %v", upload.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return false
+	}
+	if err := h.App.Uploads.Complete(upload.ID, item.ID); err != nil {
+		// Another request finished this upload first; drop our duplicate.
+		if removed, _ := h.App.ClipboardStore.Delete(item.ID); removed != nil {
+			releaseItem(h.App, removed)
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload already completed"})
+		return false
+	}
+
+	upload.ItemID = item.ID
+	return true
+}
+
+// uploadItemOptions keeps the item options found in tus metadata until the
+// upload finishes.
+func uploadItemOptions(metadata map[string]string) map[string]string {
+	var options map[string]string
+	for _, key := range itemOptionKeys {
+		if value, ok := metadata[key]; ok {
+			if options == nil {
+				options = make(map[string]string)
+			}
+			options[key] = value
+		}
+	}
+	return options
+}
+
+// ownedUpload loads the upload named in the URL. Uploads belonging to other
+// users are reported as missing.
+func (h *Handler) ownedUpload(c *gin.Context) (*models.Upload, bool) {
+	user := c.MustGet("user").(*models.User)
+	upload, exists := h.App.Uploads.Get(c.Param("id"))
+	if !exists || upload.UserID != user.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found or expired"})
+		return nil, false
+	}
+	return upload, true
+}
+
+func checkTusResumable(c *gin.Context) bool {
+	c.Header("Tus-Resumable", models.TusVersion)
+	if c.GetHeader("Tus-Resumable") != models.TusVersion {
+		c.Header("Tus-Version", models.TusVersion)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+		return false
+	}
+	return true
+}
+
+func setUploadHeaders(c *gin.Context, upload *models.Upload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.ItemID != "" {
+		c.Header(clipboardItemIDHeader, upload.ItemID)
+	}
+}
+
+// parseTusMetadata decodes "key base64value,key2 base64value2". Malformed
+// pairs are skipped.
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
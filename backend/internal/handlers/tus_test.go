@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func tusRequestAs(router http.Handler, username, method, target string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, body)
+	request.Header.Set("Tus-Resumable", models.TusVersion)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	return serveAs(router, username, request)
+}
+
+func TestTusUploadAssemblesFileItemAcrossChunks(t *testing.T) {
+	app := newTestApp(t, nil)
+	router := newTestRouter(app)
+
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("log.txt")) +
+		",filetype " + base64.StdEncoding.EncodeToString([]byte("text/plain"))
+	created := tusRequestAs(router, "user-1", http.MethodPost, "/api/uploads", nil, map[string]string{
+		"Upload-Length":   "11",
+		"Upload-Metadata": metadata,
+	})
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", created.Code, created.Body.String())
+	}
+	location := created.Header().Get("Location")
+
+	chunk := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}
+	if recorder := tusRequestAs(router, "user-1", http.MethodPatch, location, strings.NewReader("hello "), chunk); recorder.Code != http.StatusNoContent {
+		t.Fatalf("first chunk: expected 204, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	// A client that lost the response asks where to resume.
+	head := tusRequestAs(router, "user-1", http.MethodHead, location, nil, nil)
+	if head.Header().Get("Upload-Offset") != "6" || head.Header().Get("Upload-Length") != "11" {
+		t.Fatalf("unexpected HEAD headers %v", head.Header())
+	}
+	if recorder := tusRequestAs(router, "user-1", http.MethodPatch, location, strings.NewReader("world"), chunk); recorder.Code != http.StatusConflict {
+		t.Fatalf("stale offset: expected 409, got %d", recorder.Code)
+	}
+
+	chunk["Upload-Offset"] = "6"
+	final := tusRequestAs(router, "user-1", http.MethodPatch, location, strings.NewReader("world"), chunk)
+	if final.Code != http.StatusNoContent {
+		t.Fatalf("final chunk: expected 204, got %d: %s", final.Code, final.Body.String())
+	}
+	itemID := final.Header().Get("Clipboard-Item-Id")
+	item, exists := app.ClipboardStore.Get(itemID)
+	if !exists || item.FileName != "log.txt" || item.FileSize != 11 || item.UserID != "user-1" {
+		t.Fatalf("expected assembled file item, got %#v", item)
+	}
+	content, err := app.Blobs.Open(item.FileHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "hello world" {
+		t.Fatalf("unexpected file contents %q", data)
+	}
+}
+
+func TestTusUploadsAreScopedToTheirOwner(t *testing.T) {
+	app := newTestApp(t, nil)
+	upload := &models.Upload{UserID: "user-1", Length: 4, FileName: "a.txt"}
+	if err := app.Uploads.Create(upload); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(app)
+
+	if recorder := tusRequestAs(router, "user-2", http.MethodHead, "/api/uploads/"+upload.ID, nil, nil); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another user's upload, got %d", recorder.Code)
+	}
+	if recorder := tusRequestAs(router, "user-2", http.MethodDelete, "/api/uploads/"+upload.ID, nil, nil); recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when terminating another user's upload, got %d", recorder.Code)
+	}
+
+	recorder := serveAs(router, "user-2", httptest.NewRequest(http.MethodPost, "/api/uploads", nil))
+	if recorder.Code != http.StatusPreconditionFailed || recorder.Header().Get("Tus-Version") != models.TusVersion {
+		t.Fatalf("expected 412 without Tus-Resumable, got %d", recorder.Code)
+	}
+}
+
+func TestUnfinishedTusUploadsCountAgainstTheQuota(t *testing.T) {
+	router := newTestRouter(newTestApp(t, func(settings *models.SystemSettings) {
+		settings.Quotas.User = models.QuotaLimits{MaxBytes: 10}
+	}))
+	create := func(length string) *httptest.ResponseRecorder {
+		return tusRequestAs(router, "user-1", http.MethodPost, "/api/uploads", nil, map[string]string{
+			"Upload-Length":   length,
+			"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("log.txt")),
+		})
+	}
+
+	created := create("8")
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", created.Code, created.Body.String())
+	}
+	if recorder := create("5"); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected a second upload past the quota to be refused, got %d", recorder.Code)
+	}
+	if recorder := saveTextAs(router, "user-1", "abc"); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected text past the reserved quota to be refused, got %d", recorder.Code)
+	}
+
+	chunk := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}
+	final := tusRequestAs(router, "user-1", http.MethodPatch, created.Header().Get("Location"), strings.NewReader("12345678"), chunk)
+	if final.Code != http.StatusNoContent || final.Header().Get("Clipboard-Item-Id") == "" {
+		t.Fatalf("expected the reserved upload to finish, got %d: %s", final.Code, final.Body.String())
+	}
+	if recorder := saveTextAs(router, "user-1", "ab"); recorder.Code != http.StatusOK {
+		t.Fatalf("expected the remaining quota to be usable, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// GetUsage reports the caller's stored bytes and items against their quota.
+func (h *Handler) GetUsage(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	limits := h.systemSettings().Quotas.LimitsFor(user.Role)
+
+	c.JSON(http.StatusOK, models.UsageResponse{
+		StorageUsage: h.App.ClipboardStore.Usage(user.ID, time.Now().UTC()),
+		MaxBytes:     limits.MaxBytes,
+		MaxItems:     limits.MaxItems,
+	})
+}
+
+// checkQuota reports whether user may store one more item of size bytes,
+// and writes the error response when not.
+func (h *Handler) checkQuota(c *gin.Context, user *models.User, size int64) bool {
+	return h.enforceQuota(c, user, size, 1)
+}
+
+// enforceQuota checks usage plus the given additions against the user's
+// quota and the global cap. Unfinished uploads count as the items they will
+// become. Going over the user's own quota is a 413; hitting the server-wide
+// cap is a 507 because the user cannot fix it by deleting their own items
+// alone.
+func (h *Handler) enforceQuota(c *gin.Context, user *models.User, addBytes int64, addItems int) bool {
+	quotas := h.systemSettings().Quotas
+	limits := quotas.LimitsFor(user.Role)
+	now := time.Now().UTC()
+
+	if limits.MaxBytes > 0 || limits.MaxItems > 0 {
+		usage := h.App.ClipboardStore.Usage(user.ID, now)
+		usage.Bytes += h.reservedBytes(user.ID)
+		if limits.MaxItems > 0 && usage.Items+addItems > limits.MaxItems {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Item quota exceeded, delete some items first"})
+			return false
+		}
+		if limits.MaxBytes > 0 && usage.Bytes+addBytes > limits.MaxBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Storage quota exceeded, delete some items first"})
+			return false
+		}
+	}
+
+	if quotas.GlobalMaxBytes > 0 && h.App.ClipboardStore.Usage("", now).Bytes+h.reservedBytes("")+addBytes > quotas.GlobalMaxBytes {
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": "Server storage is full"})
+		return false
+	}
+	return true
+}
+
+// reservedBytes is the space userID's unfinished uploads, or everyone's when
+// userID is empty, will take up once they are complete.
+func (h *Handler) reservedBytes(userID string) int64 {
+	if h.App.Uploads == nil {
+		return 0
+	}
+	return h.App.Uploads.Reserved(userID)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func saveTextAs(router http.Handler, username, content string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.TextRequest{Content: content})
+	return sendAs(router, username, http.MethodPost, "/api/text", string(body))
+}
+
+func TestSaveTextEnforcesPerRoleQuotas(t *testing.T) {
+	expires := time.Now().UTC().Add(time.Hour)
+	router := newTestRouter(newTestApp(t,
+		func(settings *models.SystemSettings) {
+			settings.Quotas.User = models.QuotaLimits{MaxBytes: 10, MaxItems: 2}
+		},
+		&models.ClipboardItem{ID: "old1", Type: "text", UserID: "user-1", Content: "12345", ExpiresAt: expires},
+		&models.ClipboardItem{ID: "gone", Type: "text", UserID: "user-1", Content: "expired items do not count", ExpiresAt: time.Now().UTC().Add(-time.Minute)},
+	))
+
+	if recorder := saveTextAs(router, "user-1", "123456"); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected byte quota to reject with 413, got %d", recorder.Code)
+	}
+	if recorder := saveTextAs(router, "user-1", "1234"); recorder.Code != http.StatusOK {
+		t.Fatalf("expected save within quota, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := saveTextAs(router, "user-1", "1"); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected item quota to reject with 413, got %d", recorder.Code)
+	}
+
+	// Admins have their own (here unlimited) quota.
+	if recorder := saveTextAs(router, "admin", strings.Repeat("x", 100)); recorder.Code != http.StatusOK {
+		t.Fatalf("admin quota should be unlimited, got %d", recorder.Code)
+	}
+}
+
+func TestSaveTextRejectsWhenGlobalCapReached(t *testing.T) {
+	router := newTestRouter(newTestApp(t,
+		func(settings *models.SystemSettings) { settings.Quotas.GlobalMaxBytes = 8 },
+		&models.ClipboardItem{ID: "other", Type: "text", UserID: "user-2", Content: "1234567", ExpiresAt: time.Now().UTC().Add(time.Hour)},
+	))
+
+	if recorder := saveTextAs(router, "user-1", "12"); recorder.Code != http.StatusInsufficientStorage {
+		t.Fatalf("expected 507 when the server cap is reached, got %d", recorder.Code)
+	}
+}
+
+func TestGetUsageReportsCallerConsumption(t *testing.T) {
+	expires := time.Now().UTC().Add(time.Hour)
+	router := newTestRouter(newTestApp(t,
+		func(settings *models.SystemSettings) {
+			settings.Quotas.User = models.QuotaLimits{MaxBytes: 1 << 20, MaxItems: 50}
+		},
+		&models.ClipboardItem{ID: "text", Type: "text", UserID: "user-1", Content: "hello", ExpiresAt: expires},
+		&models.ClipboardItem{ID: "file", Type: "file", UserID: "user-1", FileSize: 1000, ExpiresAt: expires},
+		&models.ClipboardItem{ID: "theirs", Type: "text", UserID: "user-2", Content: "not counted", ExpiresAt: expires},
+	))
+
+	recorder := sendAs(router, "user-1", http.MethodGet, "/api/usage", "")
+	var response models.UsageResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Bytes != 1005 || response.Items != 2 || response.MaxBytes != 1<<20 || response.MaxItems != 50 {
+		t.Fatalf("unexpected usage %#v", response)
+	}
+}
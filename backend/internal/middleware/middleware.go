@@ -2,6 +2,9 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"web-clipboard-go/backend/internal/models"
@@ -27,11 +30,19 @@ func CorsMiddleware(app *models.App) gin.HandlerFunc {
 			}
 		}
 
-		c.Header("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Disposition")
+		c.Header("Access-Control-Allow-Methods", "GET, HEAD, POST, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata, Clipboard-Item-Password, Clipboard-Share-Password, If-Match")
+		c.Header("Access-Control-Expose-Headers", "Content-Disposition, Location, Tus-Resumable, Tus-Version, Upload-Offset, Upload-Length, Upload-Expires, Clipboard-Item-Id, Clipboard-Item-Type, Clipboard-Last-View, ETag")
 
 		if c.Request.Method == "OPTIONS" {
+			// tus clients discover server capabilities with OPTIONS, which
+			// never reaches the router.
+			if strings.HasPrefix(c.Request.URL.Path, "/api/uploads") {
+				c.Header("Tus-Resumable", models.TusVersion)
+				c.Header("Tus-Version", models.TusVersion)
+				c.Header("Tus-Extension", models.TusExtensions)
+				c.Header("Tus-Max-Size", strconv.FormatInt(models.MaxFileSize, 10))
+			}
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -68,6 +79,36 @@ func RateLimitMiddleware(app *models.App) gin.HandlerFunc {
 	}
 }
 
+// BucketRateLimitMiddleware applies an extra rate limit, counted separately
+// from the per-method limits, to a group of routes.
+func BucketRateLimitMiddleware(app *models.App, bucket string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !app.RateLimiter.IsAllowed(getClientIP(c), bucket) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded. Please slow down."})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// TransferDeadlineMiddleware gives routes that stream large uploads or
+// downloads their own read and write deadline, in place of the server's
+// short defaults, which would cut a slow transfer off mid-stream.
+func TransferDeadlineMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadline := time.Now().Add(timeout)
+		controller := http.NewResponseController(c.Writer)
+		// Writers that cannot take deadlines, such as test recorders, have
+		// no server timeouts to lift either.
+		_ = controller.SetReadDeadline(deadline)
+		_ = controller.SetWriteDeadline(deadline)
+
+		c.Next()
+	}
+}
+
 // AuthMiddleware validates user authentication
 func AuthMiddleware(app *models.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
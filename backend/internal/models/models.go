@@ -2,13 +2,22 @@ package models
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
-	"sync"
+	"slices"
 	"time"
 )
 
 const OAuthHandoffCookieName = "oauth_handoff"
 
+// TusVersion and TusExtensions describe the resumable upload endpoints
+// under /api/uploads.
+const (
+	TusVersion    = "1.0.0"
+	TusExtensions = "creation,termination,expiration"
+)
+
 const (
 	ClipboardExpirationUnitMinute = "minute"
 	ClipboardExpirationUnitHour   = "hour"
@@ -18,9 +27,11 @@ const (
 
 // App represents the application state
 type App struct {
-	ClipboardData   map[string]*ClipboardItem
-	DataMutex       *sync.RWMutex
-	TempDir         string
+	ClipboardStore  ClipboardStore
+	Blobs           BlobStore
+	Uploads         UploadStore
+	Shares          ShareStore
+	Collections     CollectionStore
 	RateLimiter     RateLimiter
 	Security        SecurityService
 	CleanupTicker   *time.Ticker
@@ -28,6 +39,7 @@ type App struct {
 	AuthService     AuthService
 	OAuthService    OAuthService
 	SettingsService SettingsService
+	Search          ItemSearcher
 }
 
 // ClipboardItem represents a clipboard entry (text or file)
@@ -37,15 +49,105 @@ type ClipboardItem struct {
 	UserID      string    `json:"userId"`
 	Content     string    `json:"content,omitempty"`
 	FileName    string    `json:"fileName,omitempty"`
-	FilePath    string    `json:"-"`
+	FileHash    string    `json:"fileHash,omitempty"` // SHA-256 of the contents in the blob store
+	FileSize    int64     `json:"fileSize,omitempty"`
+	FilePath    string    `json:"filePath,omitempty"` // legacy items stored before the blob store
 	ContentType string    `json:"contentType,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
 	ExpiresAt   time.Time `json:"expiresAt"`
+	// Files holds the members of a bundle item, each in the blob store
+	// under its own hash; FileName is the bundle's name and FileSize their
+	// total size.
+	Files []BundleFile `json:"files,omitempty"`
+	// Width and Height are an image item's dimensions in pixels, and
+	// Thumbnail its scaled-down copy; both are set at upload time for
+	// images that could be decoded.
+	Width     int            `json:"width,omitempty"`
+	Height    int            `json:"height,omitempty"`
+	Thumbnail *ItemThumbnail `json:"thumbnail,omitempty"`
+	// MetadataStripped is set when the image's metadata was removed on
+	// upload.
+	MetadataStripped bool `json:"metadataStripped,omitempty"`
+	// Pinned items never expire and are listed first.
+	Pinned bool `json:"pinned,omitempty"`
+	// Tags and CollectionID are the owner's own labels for the item; the
+	// collection is one of the owner's.
+	Tags         []string `json:"tags,omitempty"`
+	CollectionID string   `json:"collectionId,omitempty"`
+	// MaxReads deletes the item once it has been read that many times; 0
+	// means unlimited. ReadCount is only tracked for limited items.
+	MaxReads  int `json:"maxReads,omitempty"`
+	ReadCount int `json:"readCount,omitempty"`
+	// PasswordHash is the bcrypt hash of the password other users must give
+	// to read the item; empty when it has none.
+	PasswordHash string `json:"passwordHash,omitempty"`
+	// Visibility says who besides the owner and admins may see the item.
+	// SharedWith lists the user IDs it was sent to: the only other readers
+	// under the "users" visibility, and the users whose inbox it shows in.
+	Visibility string   `json:"visibility,omitempty"`
+	SharedWith []string `json:"sharedWith,omitempty"`
+	// OpenedAt records when each recipient first opened the item, by user ID.
+	OpenedAt map[string]time.Time `json:"openedAt,omitempty"`
+	// Revision numbers a text item's current content, from 1; Revisions
+	// keeps the earlier contents, oldest first. UpdatedAt and EditorID say
+	// when and by whom the current content was saved once it was edited.
+	Revision  int            `json:"revision,omitempty"`
+	Revisions []TextRevision `json:"revisions,omitempty"`
+	UpdatedAt time.Time      `json:"updatedAt,omitzero"`
+	EditorID  string         `json:"editorId,omitempty"`
+	// Encryption is set when Content holds ciphertext (including that of
+	// Revisions) at rest.
+	Encryption *ItemEncryption `json:"encryption,omitempty"`
+}
+
+// BundleFile is one file of a bundle item, kept under the path it was
+// uploaded with relative to the bundle.
+type BundleFile struct {
+	Path        string `json:"path"`
+	Hash        string `json:"hash"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// ItemThumbnail is a small copy of an image item, kept in the blob store
+// next to the original and removed with it.
+type ItemThumbnail struct {
+	Hash        string `json:"hash"`
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// TextRevision is an earlier content of an edited text item.
+type TextRevision struct {
+	Revision  int       `json:"revision"`
+	Content   string    `json:"content"`
+	EditorID  string    `json:"editorId"`
+	CreatedAt time.Time `json:"createdAt"` // when this content was saved
+}
+
+// CurrentTextRevision returns the number of a text item's current content.
+// Items saved before editing existed are at revision 1.
+func CurrentTextRevision(item *ClipboardItem) int {
+	if item.Revision == 0 {
+		return 1
+	}
+	return item.Revision
+}
+
+// ItemEncryption describes how a text item's content is encrypted at rest:
+// DataKey is the item's own key, wrapped by the master key KeyID.
+type ItemEncryption struct {
+	KeyID   string `json:"keyId"`
+	DataKey string `json:"dataKey"`
+	Size    int64  `json:"size"` // plaintext length including revisions, for quotas
 }
 
 type SystemSettings struct {
 	Auth      AuthSettings      `json:"auth"`
 	Clipboard ClipboardSettings `json:"clipboard"`
+	Quotas    QuotaSettings     `json:"quotas"`
+	Retention RetentionSettings `json:"retention"`
 }
 
 type AuthSettings struct {
@@ -67,6 +169,40 @@ type OAuthProviderConfig struct {
 type ClipboardSettings struct {
 	ExpirationValue int    `json:"expirationValue"`
 	ExpirationUnit  string `json:"expirationUnit"`
+	// MaxPinnedItems is how many items each user may pin; zero means no
+	// limit.
+	MaxPinnedItems int `json:"maxPinnedItems"`
+	// StripImageMetadata removes Exif, XMP and text metadata from JPEG,
+	// PNG and WebP uploads before they are stored.
+	StripImageMetadata bool `json:"stripImageMetadata"`
+}
+
+// QuotaSettings limits how much each user may keep stored, by role, and how
+// much all users together may store. Zero means unlimited.
+type QuotaSettings struct {
+	Admin          QuotaLimits `json:"admin"`
+	User           QuotaLimits `json:"user"`
+	GlobalMaxBytes int64       `json:"globalMaxBytes"`
+}
+
+// RetentionSettings caps, by role, how long an uploader may ask for an item
+// to be kept.
+type RetentionSettings struct {
+	Admin RetentionLimit `json:"admin"`
+	User  RetentionLimit `json:"user"`
+}
+
+// RetentionLimit is the longest lifetime, counted from creation, that an
+// item may be given. Zero means no cap, which also allows items that never
+// expire.
+type RetentionLimit struct {
+	MaxMinutes int `json:"maxMinutes"`
+}
+
+// QuotaLimits applies to each user individually.
+type QuotaLimits struct {
+	MaxBytes int64 `json:"maxBytes"`
+	MaxItems int   `json:"maxItems"`
 }
 
 type SystemSettingsResponse = SystemSettings
@@ -115,14 +251,59 @@ type Session struct {
 	RememberMe bool      `json:"rememberMe"`
 }
 
+// ClipboardData represents the structure of clipboard.json file
+type ClipboardData struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Items         []ClipboardItem `json:"items"`
+}
+
 // UsersData represents the structure of users.json file
 type UsersData struct {
-	Users []User `json:"users"`
+	SchemaVersion int    `json:"schemaVersion"`
+	Users         []User `json:"users"`
 }
 
 // Request/Response types for clipboard operations
 type TextRequest struct {
 	Content string `json:"content" binding:"required"`
+	ItemOptions
+}
+
+// ItemOptions are the optional settings an uploader can choose for a new
+// item. Text requests carry them as JSON; file uploads carry the same names
+// as form fields, query parameters or tus metadata.
+type ItemOptions struct {
+	MaxReads      int    `json:"maxReads,omitempty"`
+	BurnAfterRead bool   `json:"burnAfterRead,omitempty"` // same as maxReads 1
+	Password      string `json:"password,omitempty"`
+	Visibility    string `json:"visibility,omitempty"`
+	// SharedWith names the users who may see the item, by username. It
+	// implies the "users" visibility.
+	SharedWith []string `json:"sharedWith,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	// Collection files the item into one of the uploader's collections, by
+	// ID. Its defaults apply to options the request leaves out.
+	Collection string `json:"collection,omitempty"`
+	ItemExpiration
+}
+
+// ItemExpiration is an uploader's choice of when an item expires: either a
+// lifetime such as "90m", "12h", "7d" or "never", or an RFC 3339 time. When
+// both are empty the configured default applies.
+type ItemExpiration struct {
+	ExpiresIn string `json:"expiresIn,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// UpdateItemRequest changes an existing item. Only the fields that are
+// present are changed.
+type UpdateItemRequest struct {
+	Expiration *ItemExpiration `json:"expiration,omitempty"`
+	Visibility *string         `json:"visibility,omitempty"`
+	Pinned     *bool           `json:"pinned,omitempty"`
+	SharedWith *[]string       `json:"sharedWith,omitempty"` // usernames
+	Tags       *[]string       `json:"tags,omitempty"`
+	Collection *string         `json:"collection,omitempty"` // empty takes it out of its collection
 }
 
 type SaveTextResponse struct {
@@ -130,9 +311,46 @@ type SaveTextResponse struct {
 	ExpiresAt time.Time `json:"expiresAt"`
 }
 
+// UpdateTextRequest replaces a text item's content. The revision being
+// replaced is named by the If-Match header.
+type UpdateTextRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type UpdateTextResponse struct {
+	ID        string    `json:"id"`
+	Revision  int       `json:"revision"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TextRevisionResponse describes one revision of a text item; Content is
+// only filled in when a single revision is fetched.
+type TextRevisionResponse struct {
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"createdAt"`
+	Editor    string    `json:"editor"` // username
+	Size      int       `json:"size"`
+	Current   bool      `json:"current,omitempty"`
+	Content   string    `json:"content,omitempty"`
+}
+
+type ListTextRevisionsResponse struct {
+	Revisions []TextRevisionResponse `json:"revisions"`
+}
+
+type SaveSecretResponse struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
 type GetTextResponse struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"createdAt"`
+	Revision  int       `json:"revision"`
+	// LastView is set when this read used up the item's read limit and the
+	// item has been deleted.
+	LastView bool `json:"lastView,omitempty"`
 }
 
 type RecentItemResponse struct {
@@ -143,17 +361,106 @@ type RecentItemResponse struct {
 	ContentType string    `json:"contentType,omitempty"`
 	CreatedAt   time.Time `json:"createdAt"`
 	ExpiresAt   time.Time `json:"expiresAt"`
+	MaxReads    int       `json:"maxReads,omitempty"`
+	ReadCount   int       `json:"readCount,omitempty"`
+	Size        int64     `json:"size"` // bytes counted against the quota
+	// Width and Height are set for images; Thumbnail tells whether
+	// GET /api/file/{id}/thumbnail has one to serve.
+	Width     int  `json:"width,omitempty"`
+	Height    int  `json:"height,omitempty"`
+	Thumbnail bool `json:"thumbnail,omitempty"`
+	// Viewable is set for files of a type GET /api/file/{id}?inline=true
+	// shows in the browser.
+	Viewable bool `json:"viewable,omitempty"`
+	// MetadataStripped is set for images whose metadata was removed on
+	// upload.
+	MetadataStripped bool `json:"metadataStripped,omitempty"`
+	// PasswordProtected is set when other users need a password to read it.
+	PasswordProtected bool     `json:"passwordProtected,omitempty"`
+	Pinned            bool     `json:"pinned,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	Collection        string   `json:"collection,omitempty"` // collection ID
+	Visibility        string   `json:"visibility"`
+	SharedWith        []string `json:"sharedWith,omitempty"` // usernames
+	// OpenedBy tells the owner when each recipient first opened the item,
+	// by username.
+	OpenedBy map[string]time.Time `json:"openedBy,omitempty"`
+	// From and OpenedAt are set on received items: who sent the item and
+	// when the caller first opened it.
+	From     string     `json:"from,omitempty"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// SendItemRequest names the users to send an item to.
+type SendItemRequest struct {
+	Usernames []string `json:"usernames" binding:"required"`
 }
 
 type ListRecentItemsResponse struct {
 	Items []RecentItemResponse `json:"items"`
 }
 
+// SearchResult is an item that matched a search, with a snippet of its text
+// or file name around the first match.
+type SearchResult struct {
+	RecentItemResponse
+	Snippet []SnippetPart `json:"snippet"`
+}
+
+// SnippetPart is a run of snippet text; Match marks the runs that matched
+// the query, for the client to highlight.
+type SnippetPart struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// ListHistoryResponse is one page of a user's history. NextCursor fetches
+// the following page and is empty on the last one.
+type ListHistoryResponse struct {
+	Items      []RecentItemResponse `json:"items"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
 type SaveFileResponse struct {
-	ID          string    `json:"id"`
-	FileName    string    `json:"fileName"`
-	ContentType string    `json:"contentType,omitempty"`
-	ExpiresAt   time.Time `json:"expiresAt"`
+	ID               string    `json:"id"`
+	FileName         string    `json:"fileName"`
+	ContentType      string    `json:"contentType,omitempty"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	MetadataStripped bool      `json:"metadataStripped,omitempty"`
+}
+
+// SaveBundleResponse describes a newly uploaded bundle; it is also what
+// GET /api/bundle/{id} returns.
+type SaveBundleResponse struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Files     []BundleFileResponse `json:"files"`
+	Size      int64                `json:"size"`
+	ExpiresAt time.Time            `json:"expiresAt"`
+}
+
+type BundleFileResponse struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// StorageUsage is the amount of clipboard data currently held.
+type StorageUsage struct {
+	Bytes int64 `json:"bytes"`
+	Items int   `json:"items"`
+}
+
+// UsageResponse reports the caller's consumption against their quota.
+// Zero limits mean unlimited.
+type UsageResponse struct {
+	StorageUsage
+	MaxBytes int64 `json:"maxBytes"`
+	MaxItems int   `json:"maxItems"`
 }
 
 type CleanupResponse struct {
@@ -218,6 +525,230 @@ type UserManager interface {
 	ValidateCredentials(username, password string) (*User, error)
 }
 
+// ClipboardStore persists clipboard items. Implementations return copies so
+// callers may read items without holding the store's lock.
+type ClipboardStore interface {
+	Put(item *ClipboardItem) error
+	Get(id string) (*ClipboardItem, bool)
+	Delete(id string) (*ClipboardItem, error)
+	ListByUser(userID string) []*ClipboardItem
+	// ListSharedWith returns every item sent to the user by name, expired
+	// or not.
+	ListSharedWith(userID string) []*ClipboardItem
+	ListAll() []*ClipboardItem
+	// ConsumeRead atomically counts one read of a read-limited item and
+	// deletes it when the limit is reached, reporting whether this was the
+	// last read. Items without a limit are returned unchanged; a missing
+	// item returns nil.
+	ConsumeRead(id string) (item *ClipboardItem, last bool, err error)
+	// Update applies update to the stored item in one step, so concurrent
+	// changes cannot interleave, and returns the result. An error from
+	// update leaves the item unchanged and is returned as is. A missing
+	// item returns nil.
+	Update(id string, update func(item *ClipboardItem) error) (*ClipboardItem, error)
+	// MarkOpened records that a recipient opened an item at the given
+	// time, keeping the first time when called again. A missing item is
+	// ignored.
+	MarkOpened(id, userID string, at time.Time) error
+	// Usage totals unexpired items for one user, or for everyone when
+	// userID is empty.
+	Usage(userID string, now time.Time) StorageUsage
+	ExpireBefore(now time.Time) ([]*ClipboardItem, error)
+}
+
+// ItemSearcher finds items by the words in their text or file name.
+type ItemSearcher interface {
+	// Search returns the IDs of the unexpired items user may see that may
+	// contain every word of query, matching words by prefix, newest first.
+	// Callers check each item before showing it.
+	Search(query string, user *User, now time.Time) []string
+}
+
+// BlobStore holds uploaded file contents addressed by their SHA-256 hash.
+// Every Put takes a reference that must be given back with Release.
+type BlobStore interface {
+	Put(r io.Reader) (hash string, size int64, err error)
+	Open(hash string) (io.ReadSeekCloser, error)
+	Release(hash string) error
+}
+
+// MaxFileSize is the largest file a single clipboard item may hold.
+const MaxFileSize int64 = 50 * 1024 * 1024
+
+// Upload is a resumable (tus) upload. Once every byte has arrived it is
+// assembled into a file clipboard item and ItemID is set.
+type Upload struct {
+	ID       string `json:"id"`
+	UserID   string `json:"userId"`
+	Length   int64  `json:"length"`
+	Offset   int64  `json:"offset"`
+	FileName string `json:"fileName"`
+	FileType string `json:"fileType,omitempty"`
+	// Options holds the item options from Upload-Metadata, applied when
+	// the upload becomes an item.
+	Options map[string]string `json:"options,omitempty"`
+	// PasswordHash is the item password from the metadata, hashed so the
+	// plaintext is never written to disk.
+	PasswordHash string    `json:"passwordHash,omitempty"`
+	ItemID       string    `json:"itemId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+var (
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match")
+	ErrUploadLocked         = errors.New("upload is being written by another request")
+)
+
+// UploadStore keeps partially uploaded files until they are complete.
+type UploadStore interface {
+	// Create assigns the upload an ID and expiry and reserves its storage.
+	Create(upload *Upload) error
+	Get(id string) (*Upload, bool)
+	// Append writes r at offset, which must equal the stored offset. Bytes
+	// that arrive before r fails are kept so the client can resume.
+	Append(id string, offset int64, r io.Reader) (*Upload, error)
+	Open(id string) (io.ReadSeekCloser, error)
+	// Complete records the assembled item and drops the partial data.
+	Complete(id, itemID string) error
+	Delete(id string) error
+	ExpireBefore(now time.Time) (int, error)
+	// Reserved returns the total length of the unfinished uploads by
+	// userID, or by everyone when userID is empty.
+	Reserved(userID string) int64
+}
+
+// Share is a public link to a clipboard item that works without an account.
+// The token is the only thing needed to use it, so it is long and random.
+type Share struct {
+	Token  string `json:"token"`
+	ItemID string `json:"itemId"`
+	UserID string `json:"userId"` // who created the link
+	// ExpiresAt is zero when the link lasts as long as the item.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// MaxDownloads revokes the link after that many downloads; 0 means
+	// unlimited.
+	MaxDownloads  int       `json:"maxDownloads,omitempty"`
+	DownloadCount int       `json:"downloadCount,omitempty"`
+	PasswordHash  string    `json:"passwordHash,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ShareExpired reports whether the link itself has expired at now.
+func ShareExpired(share *Share, now time.Time) bool {
+	return !share.ExpiresAt.IsZero() && !share.ExpiresAt.After(now)
+}
+
+type ShareData struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	Shares        []Share `json:"shares"`
+}
+
+// ShareStore persists public share links.
+type ShareStore interface {
+	// Create assigns the share its token and creation time.
+	Create(share *Share) error
+	Get(token string) (*Share, bool)
+	// ListByItem returns the item's links, expired or not.
+	ListByItem(itemID string) []*Share
+	// Delete revokes a link; deleting a missing link is not an error.
+	Delete(token string) error
+	DeleteByItem(itemID string) error
+	// ConsumeDownload counts one download, revoking the link when its limit
+	// is reached. It returns nil when the link is gone or used up.
+	ConsumeDownload(token string) (*Share, error)
+	// ReturnDownload undoes a download counted by ConsumeDownload when
+	// nothing was served after all; consumed is the share it returned.
+	ReturnDownload(consumed *Share) error
+	ExpireBefore(now time.Time) (int, error)
+	// DeleteForMissingItems removes the links of every item for which
+	// exists returns false and returns how many were removed.
+	DeleteForMissingItems(exists func(itemID string) bool) (int, error)
+}
+
+// Collection is a named folder a user files items into. Items saved into
+// it without an expiration of their own get ExpiresIn, a lifetime as in
+// ItemExpiration; empty leaves the system default.
+type Collection struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Name      string    `json:"name"`
+	ExpiresIn string    `json:"expiresIn,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CollectionData struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Collections   []Collection `json:"collections"`
+}
+
+type CollectionStore interface {
+	// Create assigns the collection its ID and creation time.
+	Create(collection *Collection) error
+	Get(id string) (*Collection, bool)
+	// Put replaces an existing collection.
+	Put(collection *Collection) error
+	// Delete removes a collection; deleting a missing one is not an error.
+	Delete(id string) error
+	ListByUser(userID string) []*Collection
+}
+
+// CollectionRequest creates a collection, or changes the fields it sets.
+type CollectionRequest struct {
+	Name      *string `json:"name,omitempty"`
+	ExpiresIn *string `json:"expiresIn,omitempty"` // empty clears the default
+}
+
+type CollectionResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ExpiresIn string    `json:"expiresIn,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	Items     int       `json:"items"` // unexpired items filed in it
+}
+
+type ListCollectionsResponse struct {
+	Collections []CollectionResponse `json:"collections"`
+}
+
+// CreateShareRequest mints a share link. Expiration uses the same lifetimes
+// as items; "never" or nothing makes the link last as long as the item.
+type CreateShareRequest struct {
+	ItemExpiration
+	MaxDownloads int    `json:"maxDownloads,omitempty"`
+	Password     string `json:"password,omitempty"`
+}
+
+type ShareResponse struct {
+	Token             string    `json:"token"`
+	Path              string    `json:"path"` // public URL path, e.g. /s/<token>
+	ItemID            string    `json:"itemId"`
+	CreatedAt         time.Time `json:"createdAt"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	MaxDownloads      int       `json:"maxDownloads,omitempty"`
+	DownloadCount     int       `json:"downloadCount,omitempty"`
+	PasswordProtected bool      `json:"passwordProtected,omitempty"`
+}
+
+type ListSharesResponse struct {
+	Shares []ShareResponse `json:"shares"`
+}
+
+// BlobURLSigner is optionally implemented by blob stores that can send
+// clients straight to the backing object store. An empty URL means the
+// download has to be proxied.
+type BlobURLSigner interface {
+	DownloadURL(hash, contentDisposition, contentType string) (string, error)
+}
+
+// BlobReconciler is optionally implemented by blob stores that can have
+// their reference counts corrected from the items that hold references.
+type BlobReconciler interface {
+	// Reconcile sets the count of every stored blob to counts[hash] and
+	// removes blobs no longer referenced, returning how many counts changed.
+	Reconcile(counts map[string]int) (int, error)
+}
+
 type SettingsService interface {
 	GetSettings() SystemSettings
 	GetSettingsResponse() SystemSettingsResponse
@@ -246,6 +777,7 @@ type SecurityService interface {
 	ValidateFileType(fileName string) bool
 	ValidateAccessRequest(c interface{}) bool
 	LogAccess(c interface{}, id, itemType string, success bool)
+	RecordFailedAttempt(c interface{}, reason string)
 	CleanupExpired()
 	GetClientIP(c interface{}) string
 }
@@ -255,6 +787,14 @@ type RateLimiter interface {
 	CleanupExpired()
 }
 
+// ShareRateLimitEndpoint is the rate limit bucket for the public share
+// routes, which anyone can reach without logging in.
+const ShareRateLimitEndpoint = "share"
+
+// ItemPasswordRateLimitEndpoint is the rate limit bucket for item password
+// checks. It is much tighter than the per-method buckets to slow guessing.
+const ItemPasswordRateLimitEndpoint = "item-password"
+
 // FailedAttemptInfo tracks failed access attempts
 type FailedAttemptInfo struct {
 	Count       int
@@ -290,6 +830,10 @@ func DefaultSystemSettings() SystemSettings {
 		Clipboard: ClipboardSettings{
 			ExpirationValue: 10,
 			ExpirationUnit:  ClipboardExpirationUnitMinute,
+			MaxPinnedItems:  10,
+		},
+		Retention: RetentionSettings{
+			User: RetentionLimit{MaxMinutes: 7 * 24 * 60},
 		},
 	}
 }
@@ -311,8 +855,97 @@ func (settings ClipboardSettings) ExpiresAt(now time.Time) time.Time {
 	}
 }
 
+// LimitsFor returns the per-user limits that apply to the given role.
+func (quotas QuotaSettings) LimitsFor(role string) QuotaLimits {
+	if role == "admin" {
+		return quotas.Admin
+	}
+	return quotas.User
+}
+
+// LimitFor returns the retention cap that applies to the given role.
+func (retention RetentionSettings) LimitFor(role string) RetentionLimit {
+	if role == "admin" {
+		return retention.Admin
+	}
+	return retention.User
+}
+
+// MaxLifetime returns the cap as a duration, or 0 when there is none.
+func (limit RetentionLimit) MaxLifetime() time.Duration {
+	return time.Duration(limit.MaxMinutes) * time.Minute
+}
+
+// ClipboardItemSize is the number of bytes an item counts against quotas:
+// the text length or the uploaded file size, summed over a bundle's files.
+func ClipboardItemSize(item *ClipboardItem) int64 {
+	if item.Type == "file" || item.Type == "bundle" || IsSecretItemType(item.Type) {
+		return item.FileSize
+	}
+	if item.Encryption != nil {
+		return item.Encryption.Size
+	}
+	size := int64(len(item.Content))
+	for _, revision := range item.Revisions {
+		size += int64(len(revision.Content))
+	}
+	return size
+}
+
+// IsSecretItemType reports whether items of this type hold ciphertext that
+// was encrypted in the browser. The server never sees their key, so their
+// blob is opaque and only ever returned as-is.
+func IsSecretItemType(itemType string) bool {
+	return itemType == "secret-text" || itemType == "secret-file"
+}
+
+// Item visibilities. The owner and admins can always see an item.
+const (
+	VisibilityPrivate  = "private"  // nobody else
+	VisibilityUsers    = "users"    // the users in SharedWith
+	VisibilityEveryone = "everyone" // any logged-in user
+)
+
+// ValidVisibility reports whether v names a visibility.
+func ValidVisibility(v string) bool {
+	return v == VisibilityPrivate || v == VisibilityUsers || v == VisibilityEveryone
+}
+
+// ItemVisibility returns the item's visibility. Items saved before
+// visibility existed are private, except end-to-end encrypted ones, which
+// were always handed out by link.
+func ItemVisibility(item *ClipboardItem) string {
+	if item.Visibility != "" {
+		return item.Visibility
+	}
+	if IsSecretItemType(item.Type) {
+		return VisibilityEveryone
+	}
+	return VisibilityPrivate
+}
+
+// CanViewItem reports whether user may see item.
+func CanViewItem(item *ClipboardItem, user *User) bool {
+	if CanEditItem(item, user) {
+		return true
+	}
+	switch ItemVisibility(item) {
+	case VisibilityEveryone:
+		return true
+	case VisibilityUsers:
+		return slices.Contains(item.SharedWith, user.ID)
+	}
+	return false
+}
+
+// CanEditItem reports whether user may change or delete item: only its
+// owner and admins can.
+func CanEditItem(item *ClipboardItem, user *User) bool {
+	return item.UserID == user.ID || user.Role == "admin"
+}
+
 func ClipboardItemExpired(item *ClipboardItem, now time.Time) bool {
-	if item == nil || item.ExpiresAt.IsZero() {
+	if item == nil || item.Pinned || item.ExpiresAt.IsZero() {
 		return false
 	}
 	return item.ExpiresAt.Before(now)
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"log"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 type AuthService struct {
 	sessions    map[string]*models.Session // key: token
 	userManager *UserManager
+	storage     *BoltStorage // when set, sessions survive restarts
 	mutex       sync.RWMutex
 }
 
@@ -23,6 +25,32 @@ func NewAuthService(userManager *UserManager) *AuthService {
 	}
 }
 
+// NewBoltAuthService creates an auth service whose sessions are stored in the
+// embedded database. Unexpired sessions are restored on startup.
+func NewBoltAuthService(userManager *UserManager, storage *BoltStorage) (*AuthService, error) {
+	as := NewAuthService(userManager)
+	as.storage = storage
+
+	sessions, err := storage.loadSessions()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	expired := make([]string, 0)
+	for i := range sessions {
+		session := &sessions[i]
+		if session.ExpiresAt.Before(now) {
+			expired = append(expired, session.Token)
+			continue
+		}
+		as.sessions[session.Token] = session
+	}
+	if err := storage.deleteSessions(expired...); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
 // CreateSession creates a new session for a user
 func (as *AuthService) CreateSession(userID string, rememberMe bool) (*models.Session, error) {
 	token, err := generateToken()
@@ -45,6 +73,12 @@ func (as *AuthService) CreateSession(userID string, rememberMe bool) (*models.Se
 		RememberMe: rememberMe,
 	}
 
+	if as.storage != nil {
+		if err := as.storage.putSession(*session); err != nil {
+			return nil, err
+		}
+	}
+
 	as.mutex.Lock()
 	as.sessions[token] = session
 	as.mutex.Unlock()
@@ -92,31 +126,50 @@ func (as *AuthService) DeleteSession(token string) {
 	as.mutex.Lock()
 	delete(as.sessions, token)
 	as.mutex.Unlock()
+
+	as.deleteStoredSessions(token)
 }
 
 // DeleteUserSessions deletes all sessions for a user
 func (as *AuthService) DeleteUserSessions(userID string) {
 	as.mutex.Lock()
-	defer as.mutex.Unlock()
-
+	removed := make([]string, 0)
 	for token, session := range as.sessions {
 		if session.UserID == userID {
 			delete(as.sessions, token)
+			removed = append(removed, token)
 		}
 	}
+	as.mutex.Unlock()
+
+	as.deleteStoredSessions(removed...)
 }
 
 // CleanupExpiredSessions removes expired sessions
 func (as *AuthService) CleanupExpiredSessions() {
 	now := time.Now().UTC()
 	as.mutex.Lock()
-	defer as.mutex.Unlock()
-
+	removed := make([]string, 0)
 	for token, session := range as.sessions {
 		if session.ExpiresAt.Before(now) {
 			delete(as.sessions, token)
+			removed = append(removed, token)
 		}
 	}
+	as.mutex.Unlock()
+
+	as.deleteStoredSessions(removed...)
+}
+
+// deleteStoredSessions removes sessions from the database. Failures are only
+// logged because the in-memory session is already gone.
+func (as *AuthService) deleteStoredSessions(tokens ...string) {
+	if as.storage == nil {
+		return
+	}
+	if err := as.storage.deleteSessions(tokens...); err != nil {
+		log.Printf("Failed to delete stored sessions: %v", err)
+	}
 }
 
 // GetSessionCount returns the number of active sessions
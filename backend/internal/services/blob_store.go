@@ -0,0 +1,468 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// blobBackend holds raw blob bytes by key. ContentBlobStore decides keys and
+// reference counts; backends only move bytes.
+type blobBackend interface {
+	// store moves the finished upload at tempPath into place under key.
+	// size and payloadHash (hex SHA-256) describe the bytes in tempPath,
+	// which are ciphertext when the blob is encrypted.
+	store(key, tempPath string, size int64, payloadHash string) error
+	open(key string) (io.ReadSeekCloser, error)
+	remove(key string) error
+}
+
+// blobPresigner is implemented by backends that can hand clients a
+// short-lived direct download URL instead of proxying the bytes.
+type blobPresigner interface {
+	presignGet(key, contentDisposition, contentType string, ttl time.Duration) (string, error)
+}
+
+// ContentBlobStore stores uploaded file contents by SHA-256. Identical
+// uploads share one blob, and a reference count in refs.json decides when
+// the bytes can be removed from the backend. With a key ring configured,
+// every new blob is encrypted under its own data key.
+type ContentBlobStore struct {
+	backend  blobBackend
+	tempDir  string
+	refs     map[string]int           // key: blob hash
+	storing  map[string]chan struct{} // closed once the hash is stored
+	refsPath string
+	keys     *KeyRing
+	blobKeys map[string]blobKeyInfo // key: blob hash; only encrypted blobs
+	keysPath string
+	mutex    sync.Mutex
+}
+
+// blobKeyInfo is a blob's data key, wrapped by the master key KeyID.
+type blobKeyInfo struct {
+	KeyID   string `json:"keyId"`
+	DataKey string `json:"dataKey"`
+}
+
+// NewLocalBlobStore keeps blobs on local disk in a sharded directory tree
+// (ab/cd/abcd...) under dir.
+func NewLocalBlobStore(dir string) (*ContentBlobStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return newContentBlobStore(localBlobBackend{dir: dir}, dir, filepath.Join(dir, "refs.json"), filepath.Join(dir, "keys.json"))
+}
+
+func newContentBlobStore(backend blobBackend, tempDir, refsPath, keysPath string) (*ContentBlobStore, error) {
+	store := &ContentBlobStore{
+		backend:  backend,
+		tempDir:  tempDir,
+		refs:     make(map[string]int),
+		storing:  make(map[string]chan struct{}),
+		refsPath: refsPath,
+		blobKeys: make(map[string]blobKeyInfo),
+		keysPath: keysPath,
+	}
+	if err := readJSONIfExists(refsPath, &store.refs); err != nil {
+		return nil, fmt.Errorf("failed to read blob references: %w", err)
+	}
+	if err := readJSONIfExists(keysPath, &store.blobKeys); err != nil {
+		return nil, fmt.Errorf("failed to read blob keys: %w", err)
+	}
+	return store, nil
+}
+
+// UseKeyRing turns on encryption for blobs stored from now on. Blobs stored
+// earlier keep being served as they are.
+func (b *ContentBlobStore) UseKeyRing(keys *KeyRing) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.keys = keys
+}
+
+func readJSONIfExists(path string, target interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// Put streams r into the store and takes one reference on the resulting
+// blob. Uploading bytes that are already stored only bumps the count.
+func (b *ContentBlobStore) Put(r io.Reader) (string, int64, error) {
+	b.mutex.Lock()
+	keys := b.keys
+	b.mutex.Unlock()
+
+	temp, err := os.CreateTemp(b.tempDir, ".upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	tempPath := temp.Name()
+	defer os.Remove(tempPath)
+
+	// Plaintext never touches the disk when encryption is on: bytes are
+	// hashed and then encrypted on their way into the temp file.
+	// The stored bytes are hashed and counted too, since with encryption
+	// they differ from the upload in both.
+	written := &hashingWriter{w: temp, hash: sha256.New()}
+	var sink io.WriteCloser = nopWriteCloser{written}
+	var keyInfo *blobKeyInfo
+	if keys != nil {
+		dataKey, keyID, wrapped, err := keys.newDataKey()
+		if err == nil {
+			sink, err = newSegmentWriter(written, dataKey)
+		}
+		if err != nil {
+			temp.Close()
+			return "", 0, err
+		}
+		keyInfo = &blobKeyInfo{KeyID: keyID, DataKey: wrapped}
+	}
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(sink, hasher), r)
+	if err == nil {
+		err = sink.Close()
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write upload: %w", err)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	b.mutex.Lock()
+	for {
+		if b.refs[hash] > 0 {
+			defer b.mutex.Unlock()
+			return hash, size, b.addRefLocked(hash)
+		}
+		// Wait for a concurrent upload of the same bytes, then re-check,
+		// so two uploads never write the same key with different data keys.
+		done, busy := b.storing[hash]
+		if !busy {
+			break
+		}
+		b.mutex.Unlock()
+		<-done
+		b.mutex.Lock()
+	}
+	done := make(chan struct{})
+	b.storing[hash] = done
+	b.mutex.Unlock()
+
+	// Storing may be a slow network upload, so it runs unlocked.
+	storeErr := b.backend.store(blobKey(hash), tempPath, written.size, hex.EncodeToString(written.hash.Sum(nil)))
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.storing, hash)
+	close(done)
+	if storeErr != nil {
+		return "", 0, fmt.Errorf("failed to store blob: %w", storeErr)
+	}
+	if keyInfo != nil {
+		b.blobKeys[hash] = *keyInfo
+	} else {
+		delete(b.blobKeys, hash)
+	}
+	err = b.saveKeysLocked()
+	if err == nil {
+		err = b.addRefLocked(hash)
+	}
+	if err != nil {
+		if b.refs[hash] == 0 {
+			b.backend.remove(blobKey(hash))
+		}
+		return "", 0, err
+	}
+	return hash, size, nil
+}
+
+func (b *ContentBlobStore) addRefLocked(hash string) error {
+	b.refs[hash]++
+	if err := b.saveRefsLocked(); err != nil {
+		// Rollback
+		b.refs[hash]--
+		if b.refs[hash] <= 0 {
+			delete(b.refs, hash)
+		}
+		return err
+	}
+	return nil
+}
+
+// Open returns the blob contents for reading, decrypted if necessary.
+func (b *ContentBlobStore) Open(hash string) (io.ReadSeekCloser, error) {
+	if !validBlobHash(hash) {
+		return nil, os.ErrNotExist
+	}
+	b.mutex.Lock()
+	keyInfo, encrypted := b.blobKeys[hash]
+	keys := b.keys
+	b.mutex.Unlock()
+
+	var dataKey []byte
+	if encrypted {
+		if keys == nil {
+			return nil, errors.New("blob is encrypted but no master key is configured")
+		}
+		var err error
+		if dataKey, err = keys.unwrap(keyInfo.KeyID, keyInfo.DataKey); err != nil {
+			return nil, err
+		}
+	}
+
+	content, err := b.backend.open(blobKey(hash))
+	if err != nil || !encrypted {
+		return content, err
+	}
+	reader, err := newSegmentReader(content, dataKey)
+	if err != nil {
+		content.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// DownloadURL returns a presigned direct download URL when the backend
+// supports it, or an empty string when downloads must be proxied. Encrypted
+// blobs are always proxied so they can be decrypted.
+func (b *ContentBlobStore) DownloadURL(hash, contentDisposition, contentType string) (string, error) {
+	presigner, ok := b.backend.(blobPresigner)
+	if !ok || !validBlobHash(hash) {
+		return "", nil
+	}
+	b.mutex.Lock()
+	_, encrypted := b.blobKeys[hash]
+	b.mutex.Unlock()
+	if encrypted {
+		return "", nil
+	}
+	return presigner.presignGet(blobKey(hash), contentDisposition, contentType, 5*time.Minute)
+}
+
+// Release drops one reference and deletes the blob once none remain.
+func (b *ContentBlobStore) Release(hash string) error {
+	if !validBlobHash(hash) {
+		return errors.New("invalid blob hash")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	count, exists := b.refs[hash]
+	if !exists {
+		return nil
+	}
+	if count > 1 {
+		b.refs[hash] = count - 1
+	} else {
+		delete(b.refs, hash)
+	}
+	if err := b.saveRefsLocked(); err != nil {
+		b.refs[hash] = count
+		return err
+	}
+	if count <= 1 {
+		if err := b.backend.remove(blobKey(hash)); err != nil {
+			return fmt.Errorf("failed to remove blob: %w", err)
+		}
+		if _, encrypted := b.blobKeys[hash]; encrypted {
+			delete(b.blobKeys, hash)
+			if err := b.saveKeysLocked(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reconcile sets the reference count of every stored blob to counts[hash],
+// removing blobs that nothing references any more, and returns how many
+// counts changed. Hashes the store does not hold are ignored. Counts taken
+// while items are being saved would be stale, so it is meant for startup.
+func (b *ContentBlobStore) Reconcile(counts map[string]int) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	previous := maps.Clone(b.refs)
+	changed := 0
+	orphans := make([]string, 0)
+	for hash, count := range previous {
+		want := counts[hash]
+		if want == count {
+			continue
+		}
+		changed++
+		if want > 0 {
+			b.refs[hash] = want
+			continue
+		}
+		delete(b.refs, hash)
+		orphans = append(orphans, hash)
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	if err := b.saveRefsLocked(); err != nil {
+		b.refs = previous
+		return 0, err
+	}
+
+	for _, hash := range orphans {
+		if err := b.backend.remove(blobKey(hash)); err != nil {
+			return changed, fmt.Errorf("failed to remove blob: %w", err)
+		}
+		delete(b.blobKeys, hash)
+	}
+	if len(orphans) > 0 {
+		if err := b.saveKeysLocked(); err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// RewrapKeys re-encrypts every blob data key that is not wrapped by the
+// primary master key, so retired master keys can be removed. Blob contents
+// are not rewritten.
+func (b *ContentBlobStore) RewrapKeys() (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.keys == nil {
+		return 0, nil
+	}
+
+	previous := make(map[string]blobKeyInfo, len(b.blobKeys))
+	rewrapped := 0
+	for hash, info := range b.blobKeys {
+		previous[hash] = info
+		if info.KeyID == b.keys.PrimaryID() {
+			continue
+		}
+		keyID, wrapped, err := b.keys.rewrap(info.KeyID, info.DataKey)
+		if err != nil {
+			b.blobKeys = previous
+			return 0, fmt.Errorf("blob %s: %w", hash, err)
+		}
+		b.blobKeys[hash] = blobKeyInfo{KeyID: keyID, DataKey: wrapped}
+		rewrapped++
+	}
+	if rewrapped == 0 {
+		return 0, nil
+	}
+	if err := b.saveKeysLocked(); err != nil {
+		b.blobKeys = previous
+		return 0, err
+	}
+	return rewrapped, nil
+}
+
+func (b *ContentBlobStore) saveRefsLocked() error {
+	data, err := json.Marshal(b.refs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blob references: %w", err)
+	}
+	if err := writeFileAtomic(b.refsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write blob references: %w", err)
+	}
+	return nil
+}
+
+func (b *ContentBlobStore) saveKeysLocked() error {
+	if len(b.blobKeys) == 0 {
+		if _, err := os.Stat(b.keysPath); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	data, err := json.Marshal(b.blobKeys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blob keys: %w", err)
+	}
+	if err := writeFileAtomic(b.keysPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write blob keys: %w", err)
+	}
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// hashingWriter hashes and counts the bytes written through it.
+type hashingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	size int64
+}
+
+func (h *hashingWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	h.hash.Write(p[:n])
+	h.size += int64(n)
+	return n, err
+}
+
+// blobKey shards by the first two byte pairs so no directory grows huge.
+func blobKey(hash string) string {
+	return hash[0:2] + "/" + hash[2:4] + "/" + hash
+}
+
+func validBlobHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// localBlobBackend keeps blobs as files below dir.
+type localBlobBackend struct {
+	dir string
+}
+
+func (l localBlobBackend) store(key, tempPath string, size int64, payloadHash string) error {
+	path := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Identical content may already be in place; renaming over it is harmless.
+	return os.Rename(tempPath, path)
+}
+
+func (l localBlobBackend) open(key string) (io.ReadSeekCloser, error) {
+	return os.Open(l.path(key))
+}
+
+func (l localBlobBackend) remove(key string) error {
+	if err := os.Remove(l.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l localBlobBackend) path(key string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(key))
+}
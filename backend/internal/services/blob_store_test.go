@@ -0,0 +1,71 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBlobStoreDeduplicatesAndReferenceCounts(t *testing.T) {
+	dir := t.TempDir()
+	blobs, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, size, err := blobs.Put(strings.NewReader("same build artifact"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := blobs.Put(strings.NewReader("same build artifact"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("identical uploads should share a hash: %s vs %s", first, second)
+	}
+	if size != int64(len("same build artifact")) {
+		t.Fatalf("unexpected size %d", size)
+	}
+	blobPath := filepath.Join(dir, first[0:2], first[2:4], first)
+	if _, err := os.Stat(blobPath); err != nil {
+		t.Fatalf("blob not stored in sharded path: %v", err)
+	}
+
+	// Reference counts must survive a restart.
+	reopened, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Release(first); err != nil {
+		t.Fatal(err)
+	}
+	content, err := reopened.Open(first)
+	if err != nil {
+		t.Fatalf("blob removed while still referenced: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "same build artifact" {
+		t.Fatalf("unexpected blob contents %q", data)
+	}
+
+	if err := reopened.Release(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(blobPath); !os.IsNotExist(err) {
+		t.Fatalf("blob should be removed after last release, stat err=%v", err)
+	}
+}
+
+func TestLocalBlobStoreRejectsPathLikeHashes(t *testing.T) {
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blobs.Open("../../etc/passwd"); err == nil {
+		t.Fatal("expected non-hash blob name to be rejected")
+	}
+}
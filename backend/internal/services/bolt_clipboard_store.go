@@ -0,0 +1,314 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// BoltClipboardStore keeps clipboard metadata in the embedded database with
+// owner, recipient and expiry indexes, so listing and cleanup avoid full
+// scans.
+type BoltClipboardStore struct {
+	storage *BoltStorage
+}
+
+func NewBoltClipboardStore(storage *BoltStorage) *BoltClipboardStore {
+	return &BoltClipboardStore{storage: storage}
+}
+
+// Put creates or replaces an item.
+func (s *BoltClipboardStore) Put(item *models.ClipboardItem) error {
+	if item == nil || item.ID == "" {
+		return fmt.Errorf("clipboard item id cannot be empty")
+	}
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		return putClipboardItemTx(tx, *item)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write clipboard item: %w", err)
+	}
+	return nil
+}
+
+// Get returns the item with the given ID.
+func (s *BoltClipboardStore) Get(id string) (*models.ClipboardItem, bool) {
+	var item *models.ClipboardItem
+	err := s.storage.db.View(func(tx *bolt.Tx) error {
+		var err error
+		item, err = getClipboardItemTx(tx, id)
+		return err
+	})
+	if err != nil || item == nil {
+		return nil, false
+	}
+	return item, true
+}
+
+// Delete removes an item and returns it, or nil if it did not exist.
+func (s *BoltClipboardStore) Delete(id string) (*models.ClipboardItem, error) {
+	var item *models.ClipboardItem
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		item, err = deleteClipboardItemTx(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete clipboard item: %w", err)
+	}
+	return item, nil
+}
+
+// ConsumeRead counts one read of a read-limited item in a single
+// transaction, deleting it when the limit is reached.
+func (s *BoltClipboardStore) ConsumeRead(id string) (*models.ClipboardItem, bool, error) {
+	var item *models.ClipboardItem
+	last := false
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		item, err = getClipboardItemTx(tx, id)
+		if err != nil || item == nil || item.MaxReads <= 0 {
+			return err
+		}
+		item.ReadCount++
+		if item.ReadCount >= item.MaxReads {
+			last = true
+			_, err = deleteClipboardItemTx(tx, id)
+			return err
+		}
+		return putClipboardItemTx(tx, *item)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record clipboard item read: %w", err)
+	}
+	return item, last, nil
+}
+
+// Update applies update to the item and stores the result in a single
+// transaction.
+func (s *BoltClipboardStore) Update(id string, update func(item *models.ClipboardItem) error) (*models.ClipboardItem, error) {
+	var item *models.ClipboardItem
+	var updateErr error
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		item, err = getClipboardItemTx(tx, id)
+		if err != nil || item == nil {
+			return err
+		}
+		if updateErr = update(item); updateErr != nil {
+			return updateErr
+		}
+		item.ID = id
+		return putClipboardItemTx(tx, *item)
+	})
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update clipboard item: %w", err)
+	}
+	return item, nil
+}
+
+// MarkOpened records when a recipient first opened an item.
+func (s *BoltClipboardStore) MarkOpened(id, userID string, at time.Time) error {
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		item, err := getClipboardItemTx(tx, id)
+		if err != nil || item == nil {
+			return err
+		}
+		if _, opened := item.OpenedAt[userID]; opened {
+			return nil
+		}
+		if item.OpenedAt == nil {
+			item.OpenedAt = make(map[string]time.Time)
+		}
+		item.OpenedAt[userID] = at
+		return putClipboardItemTx(tx, *item)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record clipboard item open: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns every item owned by the user using the user index.
+func (s *BoltClipboardStore) ListByUser(userID string) []*models.ClipboardItem {
+	return s.listIndexed(bucketClipboardByUser, userID)
+}
+
+// ListSharedWith returns every item sent to the user using the recipient
+// index.
+func (s *BoltClipboardStore) ListSharedWith(userID string) []*models.ClipboardItem {
+	return s.listIndexed(bucketClipboardByRecipient, userID)
+}
+
+// listIndexed returns the items an index lists under userID.
+func (s *BoltClipboardStore) listIndexed(index []byte, userID string) []*models.ClipboardItem {
+	items := make([]*models.ClipboardItem, 0)
+	prefix := []byte(userID + "\x00")
+	s.storage.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(index).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+			item, err := getClipboardItemTx(tx, string(key[len(prefix):]))
+			if err != nil || item == nil {
+				continue
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items
+}
+
+// ListAll returns every stored item, expired or not.
+func (s *BoltClipboardStore) ListAll() []*models.ClipboardItem {
+	items := make([]*models.ClipboardItem, 0)
+	s.storage.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketClipboard).ForEach(func(_, data []byte) error {
+			var item models.ClipboardItem
+			if err := json.Unmarshal(data, &item); err == nil {
+				items = append(items, &item)
+			}
+			return nil
+		})
+	})
+	return items
+}
+
+// Usage totals unexpired items for userID, or for every user when empty.
+func (s *BoltClipboardStore) Usage(userID string, now time.Time) models.StorageUsage {
+	if userID != "" {
+		return sumStorageUsage(s.ListByUser(userID), now)
+	}
+	return sumStorageUsage(s.ListAll(), now)
+}
+
+// ExpireBefore removes every item that has expired at now, walking the expiry
+// index in order and stopping at the first unexpired entry.
+func (s *BoltClipboardStore) ExpireBefore(now time.Time) ([]*models.ClipboardItem, error) {
+	expired := make([]*models.ClipboardItem, 0)
+	limit := expiryIndexPrefix(now)
+	err := s.storage.db.Update(func(tx *bolt.Tx) error {
+		ids := make([]string, 0)
+		cursor := tx.Bucket(bucketClipboardExpires).Cursor()
+		for key, _ := cursor.First(); key != nil && bytes.Compare(key[:8], limit) < 0; key, _ = cursor.Next() {
+			ids = append(ids, string(key[8:]))
+		}
+		for _, id := range ids {
+			item, err := deleteClipboardItemTx(tx, id)
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				expired = append(expired, item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire clipboard items: %w", err)
+	}
+	return expired, nil
+}
+
+func getClipboardItemTx(tx *bolt.Tx, id string) (*models.ClipboardItem, error) {
+	data := tx.Bucket(bucketClipboard).Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	var item models.ClipboardItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func putClipboardItemTx(tx *bolt.Tx, item models.ClipboardItem) error {
+	if _, err := deleteClipboardItemTx(tx, item.ID); err != nil {
+		return err
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketClipboard).Put([]byte(item.ID), data); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketClipboardByUser).Put(userIndexKey(item.UserID, item.ID), nil); err != nil {
+		return err
+	}
+	for _, recipient := range item.SharedWith {
+		if err := tx.Bucket(bucketClipboardByRecipient).Put(userIndexKey(recipient, item.ID), nil); err != nil {
+			return err
+		}
+	}
+	// Pinned items stay out of the expiry index so cleanup never sees them.
+	if !item.ExpiresAt.IsZero() && !item.Pinned {
+		if err := tx.Bucket(bucketClipboardExpires).Put(expiryIndexKey(item.ExpiresAt, item.ID), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteClipboardItemTx(tx *bolt.Tx, id string) (*models.ClipboardItem, error) {
+	item, err := getClipboardItemTx(tx, id)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	if err := tx.Bucket(bucketClipboardByUser).Delete(userIndexKey(item.UserID, item.ID)); err != nil {
+		return nil, err
+	}
+	for _, recipient := range item.SharedWith {
+		if err := tx.Bucket(bucketClipboardByRecipient).Delete(userIndexKey(recipient, item.ID)); err != nil {
+			return nil, err
+		}
+	}
+	if !item.ExpiresAt.IsZero() {
+		if err := tx.Bucket(bucketClipboardExpires).Delete(expiryIndexKey(item.ExpiresAt, item.ID)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Bucket(bucketClipboard).Delete([]byte(id)); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// indexClipboardRecipientsTx adds every stored item to the recipient index.
+func indexClipboardRecipientsTx(tx *bolt.Tx) error {
+	byRecipient := tx.Bucket(bucketClipboardByRecipient)
+	return tx.Bucket(bucketClipboard).ForEach(func(_, data []byte) error {
+		var item models.ClipboardItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil
+		}
+		for _, recipient := range item.SharedWith {
+			if err := byRecipient.Put(userIndexKey(recipient, item.ID), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func userIndexKey(userID, itemID string) []byte {
+	return []byte(userID + "\x00" + itemID)
+}
+
+// expiryIndexKey sorts by expiration time so cleanup can stop early.
+func expiryIndexKey(expiresAt time.Time, itemID string) []byte {
+	return append(expiryIndexPrefix(expiresAt), itemID...)
+}
+
+func expiryIndexPrefix(t time.Time) []byte {
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, uint64(t.UnixNano()))
+	return prefix
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"web-clipboard-go/backend/internal/models"
+)
+
+// ImportJSONData copies users.json, settings.json and clipboard.json from
+// dataDir into the database in a single transaction. It runs only once per
+// database; later calls return false without touching the JSON files, which
+// are left in place as a backup.
+func (s *BoltStorage) ImportJSONData(dataDir string) (bool, error) {
+	var usersData models.UsersData
+	usersFound, err := readJSONImportFile(filepath.Join(dataDir, "users.json"), usersFileSchema, &usersData)
+	if err != nil {
+		return false, err
+	}
+	settings := settingsFile{SystemSettings: models.DefaultSystemSettings()}
+	settingsFound, err := readJSONImportFile(filepath.Join(dataDir, "settings.json"), settingsFileSchema, &settings)
+	if err != nil {
+		return false, err
+	}
+	var clipboardData models.ClipboardData
+	clipboardFound, err := readJSONImportFile(filepath.Join(dataDir, "clipboard.json"), clipboardFileSchema, &clipboardData)
+	if err != nil {
+		return false, err
+	}
+
+	imported := false
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta.Get(jsonImportedKey) != nil {
+			return nil
+		}
+
+		if usersFound {
+			for _, user := range usersData.Users {
+				if err := putUserTx(tx, user); err != nil {
+					return fmt.Errorf("user %q: %w", user.Username, err)
+				}
+			}
+		}
+		if settingsFound {
+			data, err := json.Marshal(settings.SystemSettings)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketSettings).Put(settingsKey, data); err != nil {
+				return err
+			}
+		}
+		if clipboardFound {
+			for _, item := range clipboardData.Items {
+				if err := putClipboardItemTx(tx, item); err != nil {
+					return fmt.Errorf("clipboard item %q: %w", item.ID, err)
+				}
+			}
+		}
+
+		imported = usersFound || settingsFound || clipboardFound
+		return meta.Put(jsonImportedKey, []byte(time.Now().UTC().Format(time.RFC3339)))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to import JSON data: %w", err)
+	}
+	return imported, nil
+}
+
+func readJSONImportFile(path string, schema dataFileSchema, target interface{}) (bool, error) {
+	data, _, err := readDataFile(path, schema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return true, nil
+}
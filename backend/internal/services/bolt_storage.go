@@ -0,0 +1,266 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"web-clipboard-go/backend/internal/models"
+)
+
+const boltDatabaseFileName = "web-clipboard.db"
+
+var (
+	bucketUsers                = []byte("users")
+	bucketUsersByUsername      = []byte("users_by_username")
+	bucketUserIdentities       = []byte("user_identities")
+	bucketSettings             = []byte("settings")
+	bucketSessions             = []byte("sessions")
+	bucketClipboard            = []byte("clipboard")
+	bucketClipboardByUser      = []byte("clipboard_by_user")
+	bucketClipboardExpires     = []byte("clipboard_by_expiry")
+	bucketClipboardByRecipient = []byte("clipboard_by_recipient")
+	bucketMeta                 = []byte("meta")
+
+	settingsKey     = []byte("system")
+	jsonImportedKey = []byte("json_imported_at")
+)
+
+// BoltStorage is the embedded database backend. Users, settings, sessions and
+// clipboard metadata share one bbolt file so related writes are transactional.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// OpenBoltStorage opens (or creates) web-clipboard.db in the data directory.
+func OpenBoltStorage(dataDir string) (*BoltStorage, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dataDir, boltDatabaseFileName), 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Databases from before the recipient index need it built once.
+		indexRecipients := tx.Bucket(bucketClipboardByRecipient) == nil
+		for _, name := range [][]byte{
+			bucketUsers, bucketUsersByUsername, bucketUserIdentities,
+			bucketSettings, bucketSessions,
+			bucketClipboard, bucketClipboardByUser, bucketClipboardExpires,
+			bucketClipboardByRecipient, bucketMeta,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if indexRecipients {
+			return indexClipboardRecipientsTx(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &BoltStorage{db: db}, nil
+}
+
+// Close releases the database file lock.
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+// loadUsers returns every stored user.
+func (s *BoltStorage) loadUsers() ([]models.User, error) {
+	users := make([]models.User, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketUsers).ForEach(func(_, value []byte) error {
+			var user models.User
+			if err := json.Unmarshal(value, &user); err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	return users, nil
+}
+
+// putUser writes a user and refreshes its username and identity indexes in a
+// single transaction, rejecting values already owned by another user.
+func (s *BoltStorage) putUser(user models.User) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return putUserTx(tx, user)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write user: %w", err)
+	}
+	return nil
+}
+
+func putUserTx(tx *bolt.Tx, user models.User) error {
+	users := tx.Bucket(bucketUsers)
+	byUsername := tx.Bucket(bucketUsersByUsername)
+	identities := tx.Bucket(bucketUserIdentities)
+
+	if err := deleteUserIndexesTx(tx, user.ID); err != nil {
+		return err
+	}
+
+	usernameKey := []byte(strings.ToLower(user.Username))
+	if owner := byUsername.Get(usernameKey); owner != nil && string(owner) != user.ID {
+		return errors.New("username already exists")
+	}
+	if err := byUsername.Put(usernameKey, []byte(user.ID)); err != nil {
+		return err
+	}
+	for _, identity := range user.Identities {
+		key := identityIndexKey(identity.Provider, identity.Subject)
+		if owner := identities.Get(key); owner != nil && string(owner) != user.ID {
+			return errors.New("external identity already linked")
+		}
+		if err := identities.Put(key, []byte(user.ID)); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	return users.Put([]byte(user.ID), data)
+}
+
+// deleteUser removes a user together with its index entries.
+func (s *BoltStorage) deleteUser(id string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteUserIndexesTx(tx, id); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketUsers).Delete([]byte(id))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+func deleteUserIndexesTx(tx *bolt.Tx, id string) error {
+	existing := tx.Bucket(bucketUsers).Get([]byte(id))
+	if existing == nil {
+		return nil
+	}
+	var previous models.User
+	if err := json.Unmarshal(existing, &previous); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketUsersByUsername).Delete([]byte(strings.ToLower(previous.Username))); err != nil {
+		return err
+	}
+	for _, identity := range previous.Identities {
+		if err := tx.Bucket(bucketUserIdentities).Delete(identityIndexKey(identity.Provider, identity.Subject)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func identityIndexKey(provider, subject string) []byte {
+	return []byte(strings.ToLower(provider) + "\x00" + subject)
+}
+
+// loadSettings returns the stored settings and whether any were found.
+func (s *BoltStorage) loadSettings() (models.SystemSettings, bool, error) {
+	settings := models.DefaultSystemSettings()
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketSettings).Get(settingsKey)
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &settings)
+	})
+	if err != nil {
+		return settings, false, fmt.Errorf("failed to read settings: %w", err)
+	}
+	return settings, found, nil
+}
+
+func (s *BoltStorage) saveSettings(settings models.SystemSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Put(settingsKey, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// loadSessions returns every stored session, including expired ones.
+func (s *BoltStorage) loadSessions() ([]models.Session, error) {
+	sessions := make([]models.Session, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSessions).ForEach(func(_, value []byte) error {
+			var session models.Session
+			if err := json.Unmarshal(value, &session); err != nil {
+				return err
+			}
+			sessions = append(sessions, session)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *BoltStorage) putSession(session models.Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSessions).Put([]byte(session.Token), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStorage) deleteSessions(tokens ...string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketSessions)
+		for _, token := range tokens {
+			if err := bucket.Delete([]byte(token)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"web-clipboard-go/backend/internal/models"
+)
+
+func openTestBoltStorage(t *testing.T, dataDir string) *BoltStorage {
+	t.Helper()
+	storage, err := OpenBoltStorage(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestBoltUserManagerPersistsUsersAcrossReopen(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := OpenBoltStorage(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewBoltUserManager(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manager.GetUserByUsername("admin") == nil {
+		t.Fatal("default admin missing")
+	}
+	user, err := manager.CreateUser("alice", "secret123", "alice@example.com", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestBoltStorage(t, dataDir)
+	reloaded, err := NewBoltUserManager(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.GetAllUsers()) != 2 {
+		t.Fatalf("expected admin and alice after reopen, got %d users", len(reloaded.GetAllUsers()))
+	}
+	if _, err := reloaded.ValidateCredentials("alice", "secret123"); err != nil {
+		t.Fatalf("reloaded user cannot log in: %v", err)
+	}
+
+	if err := reopened.putUser(models.User{ID: "other", Username: "ALICE"}); err == nil {
+		t.Fatal("expected username index to reject a second user named alice")
+	}
+	if err := reloaded.DeleteUser(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.putUser(models.User{ID: "other", Username: "alice"}); err != nil {
+		t.Fatalf("deleted user's username should be free again: %v", err)
+	}
+}
+
+func TestBoltAuthServiceRestoresSessionsAndForgetsLogout(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := OpenBoltStorage(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewBoltUserManager(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := manager.GetUserByUsername("admin")
+	authService, err := NewBoltAuthService(manager, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := authService.CreateSession(admin.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loggedOut, err := authService.CreateSession(admin.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService.DeleteSession(loggedOut.Token)
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestBoltStorage(t, dataDir)
+	reloadedManager, err := NewBoltUserManager(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewBoltAuthService(reloadedManager, reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, valid := reloaded.ValidateToken(kept.Token); !valid {
+		t.Fatal("session should survive restart")
+	}
+	if _, valid := reloaded.ValidateToken(loggedOut.Token); valid {
+		t.Fatal("logged out session must not be restored")
+	}
+}
+
+func TestBoltClipboardStoreIndexesByUserAndExpiry(t *testing.T) {
+	store := NewBoltClipboardStore(openTestBoltStorage(t, t.TempDir()))
+	now := time.Now().UTC()
+	for _, item := range []*models.ClipboardItem{
+		{ID: "a1", Type: "text", UserID: "user-1", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "a2", Type: "text", UserID: "user-1", ExpiresAt: now.Add(time.Minute)},
+		{ID: "b1", Type: "text", UserID: "user-10"},
+		{ID: "p1", Type: "text", UserID: "user-2", ExpiresAt: now.Add(-time.Minute), Pinned: true},
+	} {
+		if err := store.Put(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if items := store.ListByUser("user-1"); len(items) != 2 {
+		t.Fatalf("expected 2 items for user-1, got %#v", items)
+	}
+
+	// Moving an item to a later expiry must drop its old index entry.
+	moved, _ := store.Get("a1")
+	moved.ExpiresAt = now.Add(time.Hour)
+	if err := store.Put(moved); err != nil {
+		t.Fatal(err)
+	}
+	expired, err := store.ExpireBefore(now.Add(2 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != "a2" {
+		t.Fatalf("expected only a2 to expire, got %#v", expired)
+	}
+	if _, exists := store.Get("a1"); !exists {
+		t.Fatal("re-dated item should not expire")
+	}
+	if _, exists := store.Get("b1"); !exists {
+		t.Fatal("never-expiring item should remain")
+	}
+	if _, exists := store.Get("p1"); !exists {
+		t.Fatal("pinned item should not expire")
+	}
+}
+
+func TestImportJSONDataRunsOnce(t *testing.T) {
+	dataDir := t.TempDir()
+	jsonUsers, err := NewUserManager(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jsonUsers.CreateUser("bob", "secret123", "bob@example.com", "user"); err != nil {
+		t.Fatal(err)
+	}
+	jsonSettings, err := NewSettingsService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := jsonSettings.GetSettings()
+	settings.Clipboard.ExpirationValue = 3
+	settings.Clipboard.ExpirationUnit = models.ClipboardExpirationUnitDay
+	if err := jsonSettings.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	jsonClipboard, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonClipboard.Put(&models.ClipboardItem{ID: "keep", Type: "text", UserID: "user-1", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := openTestBoltStorage(t, dataDir)
+	imported, err := storage.ImportJSONData(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !imported {
+		t.Fatal("expected first import to copy JSON data")
+	}
+	if imported, err := storage.ImportJSONData(dataDir); err != nil || imported {
+		t.Fatalf("second import should be a no-op, got imported=%v err=%v", imported, err)
+	}
+
+	manager, err := NewBoltUserManager(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.ValidateCredentials("bob", "secret123"); err != nil {
+		t.Fatalf("imported user cannot log in: %v", err)
+	}
+	settingsService, err := NewBoltSettingsService(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := settingsService.GetSettings().Clipboard; got.ExpirationValue != 3 || got.ExpirationUnit != models.ClipboardExpirationUnitDay {
+		t.Fatalf("imported clipboard settings mismatch: %#v", got)
+	}
+	if item, exists := NewBoltClipboardStore(storage).Get("keep"); !exists || item.Content != "hi" {
+		t.Fatalf("imported clipboard item missing: %#v", item)
+	}
+}
+
+func TestBoltStorageIndexesRecipientsOfEarlierItems(t *testing.T) {
+	dataDir := t.TempDir()
+	storage, err := OpenBoltStorage(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBoltClipboardStore(storage).Put(&models.ClipboardItem{ID: "a1", Type: "text", UserID: "alice", SharedWith: []string{"bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Drop the index, as in a database written before it existed.
+	if err := storage.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(bucketClipboardByRecipient)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	store := NewBoltClipboardStore(openTestBoltStorage(t, dataDir))
+	if items := store.ListSharedWith("bob"); len(items) != 1 || items[0].ID != "a1" {
+		t.Fatalf("expected the earlier item in bob's inbox, got %#v", items)
+	}
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// FileClipboardStore keeps clipboard items in memory and mirrors them to
+// clipboard.json in the data directory so they survive restarts. Items are
+// also indexed by owner and by recipient, so listing one user's items or
+// inbox does not scan everyone's.
+type FileClipboardStore struct {
+	items       map[string]*models.ClipboardItem            // key: item ID
+	byUser      map[string]map[string]*models.ClipboardItem // key: user ID, then item ID
+	byRecipient map[string]map[string]*models.ClipboardItem // key: user ID, then item ID
+	filePath    string
+	mutex       sync.RWMutex
+}
+
+func NewFileClipboardStore(dataDir string) (*FileClipboardStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	store := &FileClipboardStore{
+		items:       make(map[string]*models.ClipboardItem),
+		byUser:      make(map[string]map[string]*models.ClipboardItem),
+		byRecipient: make(map[string]map[string]*models.ClipboardItem),
+		filePath:    filepath.Join(dataDir, "clipboard.json"),
+	}
+	if err := store.loadItems(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Put creates or replaces an item.
+func (s *FileClipboardStore) Put(item *models.ClipboardItem) error {
+	if item == nil || item.ID == "" {
+		return fmt.Errorf("clipboard item id cannot be empty")
+	}
+	stored := *item
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, existed := s.items[stored.ID]
+	s.storeLocked(&stored)
+	if err := s.saveItemsLocked(); err != nil {
+		// Rollback
+		if existed {
+			s.storeLocked(previous)
+		} else {
+			s.removeLocked(stored.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the item with the given ID.
+func (s *FileClipboardStore) Get(id string) (*models.ClipboardItem, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	item, exists := s.items[id]
+	if !exists {
+		return nil, false
+	}
+	clone := *item
+	return &clone, true
+}
+
+// Delete removes an item and returns it, or nil if it did not exist.
+func (s *FileClipboardStore) Delete(id string) (*models.ClipboardItem, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[id]
+	if !exists {
+		return nil, nil
+	}
+	s.removeLocked(id)
+	if err := s.saveItemsLocked(); err != nil {
+		s.storeLocked(item)
+		return nil, err
+	}
+	return item, nil
+}
+
+// ConsumeRead counts one read of a read-limited item, deleting it when the
+// limit is reached.
+func (s *FileClipboardStore) ConsumeRead(id string) (*models.ClipboardItem, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[id]
+	if !exists {
+		return nil, false, nil
+	}
+	if item.MaxReads <= 0 {
+		clone := *item
+		return &clone, false, nil
+	}
+
+	previous := *item
+	item.ReadCount++
+	last := item.ReadCount >= item.MaxReads
+	if last {
+		s.removeLocked(id)
+	}
+	if err := s.saveItemsLocked(); err != nil {
+		// Rollback
+		s.storeLocked(&previous)
+		return nil, false, err
+	}
+	clone := *item
+	return &clone, last, nil
+}
+
+// Update applies update to a copy of the item and stores the result.
+func (s *FileClipboardStore) Update(id string, update func(item *models.ClipboardItem) error) (*models.ClipboardItem, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[id]
+	if !exists {
+		return nil, nil
+	}
+	updated := *item
+	if err := update(&updated); err != nil {
+		return nil, err
+	}
+	updated.ID = id
+	s.storeLocked(&updated)
+	if err := s.saveItemsLocked(); err != nil {
+		// Rollback
+		s.storeLocked(item)
+		return nil, err
+	}
+	clone := updated
+	return &clone, nil
+}
+
+// MarkOpened records when a recipient first opened an item.
+func (s *FileClipboardStore) MarkOpened(id, userID string, at time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[id]
+	if !exists {
+		return nil
+	}
+	if _, opened := item.OpenedAt[userID]; opened {
+		return nil
+	}
+
+	// Copy the map: items handed out by Get share it with the stored one.
+	previous := *item
+	item.OpenedAt = make(map[string]time.Time, len(previous.OpenedAt)+1)
+	for recipient, openedAt := range previous.OpenedAt {
+		item.OpenedAt[recipient] = openedAt
+	}
+	item.OpenedAt[userID] = at
+	if err := s.saveItemsLocked(); err != nil {
+		// Rollback
+		s.storeLocked(&previous)
+		return err
+	}
+	return nil
+}
+
+// ListByUser returns copies of every item owned by the user, expired or not.
+func (s *FileClipboardStore) ListByUser(userID string) []*models.ClipboardItem {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return cloneItems(s.byUser[userID])
+}
+
+// ListSharedWith returns copies of every item sent to the user, expired or
+// not.
+func (s *FileClipboardStore) ListSharedWith(userID string) []*models.ClipboardItem {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return cloneItems(s.byRecipient[userID])
+}
+
+// ListAll returns copies of every stored item, expired or not.
+func (s *FileClipboardStore) ListAll() []*models.ClipboardItem {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return cloneItems(s.items)
+}
+
+// Usage totals unexpired items for userID, or for every user when empty.
+func (s *FileClipboardStore) Usage(userID string, now time.Time) models.StorageUsage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	source := s.items
+	if userID != "" {
+		source = s.byUser[userID]
+	}
+	items := make([]*models.ClipboardItem, 0, len(source))
+	for _, item := range source {
+		items = append(items, item)
+	}
+	return sumStorageUsage(items, now)
+}
+
+// ExpireBefore removes every item that has expired at now and returns them
+// so the caller can release any attached files.
+func (s *FileClipboardStore) ExpireBefore(now time.Time) ([]*models.ClipboardItem, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expired := make([]*models.ClipboardItem, 0)
+	for id, item := range s.items {
+		if models.ClipboardItemExpired(item, now) {
+			expired = append(expired, item)
+			s.removeLocked(id)
+		}
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+	if err := s.saveItemsLocked(); err != nil {
+		for _, item := range expired {
+			s.storeLocked(item)
+		}
+		return nil, err
+	}
+	return expired, nil
+}
+
+// loadItems loads items from JSON file
+func (s *FileClipboardStore) loadItems() error {
+	data, migrated, err := readDataFile(s.filePath, clipboardFileSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read clipboard file: %w", err)
+	}
+
+	var clipboardData models.ClipboardData
+	if err := json.Unmarshal(data, &clipboardData); err != nil {
+		return fmt.Errorf("failed to parse clipboard file: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range clipboardData.Items {
+		s.storeLocked(&clipboardData.Items[i])
+	}
+	if migrated {
+		return s.saveItemsLocked()
+	}
+	return nil
+}
+
+// storeLocked puts item in the store and the owner and recipient indexes,
+// replacing any item with the same ID.
+func (s *FileClipboardStore) storeLocked(item *models.ClipboardItem) {
+	s.removeLocked(item.ID)
+	s.items[item.ID] = item
+	addToIndex(s.byUser, item.UserID, item)
+	for _, recipient := range item.SharedWith {
+		addToIndex(s.byRecipient, recipient, item)
+	}
+}
+
+// removeLocked drops the item with the given ID from the store and the owner
+// and recipient indexes.
+func (s *FileClipboardStore) removeLocked(id string) {
+	item, exists := s.items[id]
+	if !exists {
+		return
+	}
+	delete(s.items, id)
+	removeFromIndex(s.byUser, item.UserID, id)
+	for _, recipient := range item.SharedWith {
+		removeFromIndex(s.byRecipient, recipient, id)
+	}
+}
+
+func addToIndex(index map[string]map[string]*models.ClipboardItem, key string, item *models.ClipboardItem) {
+	entries := index[key]
+	if entries == nil {
+		entries = make(map[string]*models.ClipboardItem)
+		index[key] = entries
+	}
+	entries[item.ID] = item
+}
+
+func removeFromIndex(index map[string]map[string]*models.ClipboardItem, key, id string) {
+	delete(index[key], id)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+func cloneItems(source map[string]*models.ClipboardItem) []*models.ClipboardItem {
+	items := make([]*models.ClipboardItem, 0, len(source))
+	for _, item := range source {
+		clone := *item
+		items = append(items, &clone)
+	}
+	return items
+}
+
+// saveItemsLocked writes all items to the JSON file. Callers must hold the
+// write lock so concurrent saves cannot reorder on disk.
+func (s *FileClipboardStore) saveItemsLocked() error {
+	itemsList := make([]models.ClipboardItem, 0, len(s.items))
+	for _, item := range s.items {
+		itemsList = append(itemsList, *item)
+	}
+
+	data, err := json.MarshalIndent(models.ClipboardData{
+		SchemaVersion: clipboardFileSchema.version,
+		Items:         itemsList,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal clipboard items: %w", err)
+	}
+	if err := writeFileAtomic(s.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write clipboard file: %w", err)
+	}
+	return nil
+}
+
+func sumStorageUsage(items []*models.ClipboardItem, now time.Time) models.StorageUsage {
+	var usage models.StorageUsage
+	for _, item := range items {
+		if models.ClipboardItemExpired(item, now) {
+			continue
+		}
+		usage.Bytes += models.ClipboardItemSize(item)
+		usage.Items++
+	}
+	return usage
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestFileClipboardStorePersistsItemsAcrossReload(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	if err := store.Put(&models.ClipboardItem{
+		ID:        "keep",
+		Type:      "text",
+		UserID:    "user-1",
+		Content:   "survives restart",
+		CreatedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&models.ClipboardItem{
+		ID:        "file",
+		Type:      "file",
+		UserID:    "user-1",
+		FileName:  "report.txt",
+		FilePath:  filepath.Join(dataDir, "files", "file_report.txt"),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, exists := reloaded.Get("keep")
+	if !exists || item.Content != "survives restart" || !item.ExpiresAt.IsZero() {
+		t.Fatalf("never-expiring text item was not reloaded: %#v", item)
+	}
+	file, exists := reloaded.Get("file")
+	if !exists || file.FilePath == "" {
+		t.Fatalf("file item lost its stored path after reload: %#v", file)
+	}
+	if items := reloaded.ListByUser("user-1"); len(items) != 2 {
+		t.Fatalf("expected 2 reloaded items for user, got %d", len(items))
+	}
+}
+
+func TestFileClipboardStoreExpireBeforeRemovesOnlyExpiredItems(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	for _, item := range []*models.ClipboardItem{
+		{ID: "old", Type: "text", UserID: "user-1", ExpiresAt: now.Add(-time.Minute)},
+		{ID: "new", Type: "text", UserID: "user-1", ExpiresAt: now.Add(time.Minute)},
+		{ID: "never", Type: "text", UserID: "user-1"},
+	} {
+		if err := store.Put(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expired, err := store.ExpireBefore(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != "old" {
+		t.Fatalf("expected only old item to expire, got %#v", expired)
+	}
+
+	reloaded, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := reloaded.Get("old"); exists {
+		t.Fatal("expired item should be removed from disk")
+	}
+	for _, id := range []string{"new", "never"} {
+		if _, exists := reloaded.Get(id); !exists {
+			t.Fatalf("unexpired item %q should remain", id)
+		}
+	}
+}
+
+func TestFileClipboardStoreGetReturnsCopy(t *testing.T) {
+	store, err := NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&models.ClipboardItem{ID: "abcd", Type: "text", Content: "original"}); err != nil {
+		t.Fatal(err)
+	}
+
+	item, _ := store.Get("abcd")
+	item.Content = "mutated"
+
+	stored, _ := store.Get("abcd")
+	if stored.Content != "original" {
+		t.Fatalf("mutating a returned item changed the store: %q", stored.Content)
+	}
+}
+
+func TestFileClipboardStoreIndexesItemsByOwner(t *testing.T) {
+	dataDir := t.TempDir()
+	store, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	store.Put(&models.ClipboardItem{ID: "a1", Type: "text", UserID: "user-1", Content: "one", CreatedAt: now})
+	store.Put(&models.ClipboardItem{ID: "a2", Type: "text", UserID: "user-1", Content: "two", CreatedAt: now})
+	store.Put(&models.ClipboardItem{ID: "b1", Type: "text", UserID: "user-2", Content: "three", CreatedAt: now})
+	// Replacing an item under another owner moves it between users.
+	store.Put(&models.ClipboardItem{ID: "a2", Type: "text", UserID: "user-2", Content: "two", CreatedAt: now})
+	if _, err := store.Delete("b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*FileClipboardStore{store, reloaded} {
+		if items := s.ListByUser("user-1"); len(items) != 1 || items[0].ID != "a1" {
+			t.Fatalf("unexpected items for user-1: %#v", items)
+		}
+		if items := s.ListByUser("user-2"); len(items) != 1 || items[0].ID != "a2" {
+			t.Fatalf("unexpected items for user-2: %#v", items)
+		}
+		if usage := s.Usage("user-2", now); usage.Items != 1 || usage.Bytes != 3 {
+			t.Fatalf("unexpected usage for user-2: %#v", usage)
+		}
+	}
+}
+
+func TestClipboardStoresIndexItemsByRecipient(t *testing.T) {
+	fileStore, err := NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]models.ClipboardStore{
+		"file": fileStore,
+		"bolt": NewBoltClipboardStore(openTestBoltStorage(t, t.TempDir())),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now().UTC()
+			store.Put(&models.ClipboardItem{ID: "a1", Type: "text", UserID: "alice", Content: "one", SharedWith: []string{"bob", "carol"}, CreatedAt: now})
+			store.Put(&models.ClipboardItem{ID: "a2", Type: "text", UserID: "alice", Content: "two", SharedWith: []string{"bob"}, CreatedAt: now})
+			store.Put(&models.ClipboardItem{ID: "a3", Type: "text", UserID: "alice", Content: "three", CreatedAt: now})
+			if _, err := store.Update("a1", func(item *models.ClipboardItem) error {
+				item.SharedWith = []string{"bob"}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Delete("a2"); err != nil {
+				t.Fatal(err)
+			}
+
+			if items := store.ListSharedWith("bob"); len(items) != 1 || items[0].ID != "a1" {
+				t.Fatalf("unexpected items for bob: %#v", items)
+			}
+			if items := store.ListSharedWith("carol"); len(items) != 0 {
+				t.Fatalf("expected carol's inbox to be empty once unshared, got %#v", items)
+			}
+		})
+	}
+}
+
+func TestClipboardStoresConsumeReadsUntilTheLimit(t *testing.T) {
+	fileStore, err := NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]models.ClipboardStore{
+		"file": fileStore,
+		"bolt": NewBoltClipboardStore(openTestBoltStorage(t, t.TempDir())),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now().UTC()
+			store.Put(&models.ClipboardItem{ID: "twice", Type: "text", UserID: "user-1", Content: "otp", MaxReads: 2, CreatedAt: now})
+			store.Put(&models.ClipboardItem{ID: "always", Type: "text", UserID: "user-1", Content: "notes", CreatedAt: now})
+
+			item, last, err := store.ConsumeRead("twice")
+			if err != nil || item == nil || last || item.ReadCount != 1 {
+				t.Fatalf("first read: got %#v, last=%v, err=%v", item, last, err)
+			}
+			item, last, err = store.ConsumeRead("twice")
+			if err != nil || item == nil || !last || item.Content != "otp" {
+				t.Fatalf("second read should be the last: got %#v, last=%v, err=%v", item, last, err)
+			}
+			if _, exists := store.Get("twice"); exists {
+				t.Fatal("item should be deleted after its last read")
+			}
+			if item, _, _ := store.ConsumeRead("twice"); item != nil {
+				t.Fatal("reading a used-up item should find nothing")
+			}
+
+			item, last, err = store.ConsumeRead("always")
+			if err != nil || item == nil || last || item.ReadCount != 0 {
+				t.Fatalf("unlimited items should not be counted: got %#v, last=%v, err=%v", item, last, err)
+			}
+		})
+	}
+}
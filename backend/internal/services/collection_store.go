@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// FileCollectionStore keeps collections in memory and mirrors them to
+// collections.json in the data directory so they survive restarts.
+type FileCollectionStore struct {
+	collections map[string]*models.Collection // key: collection ID
+	filePath    string
+	mutex       sync.RWMutex
+}
+
+func NewFileCollectionStore(dataDir string) (*FileCollectionStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	store := &FileCollectionStore{
+		collections: make(map[string]*models.Collection),
+		filePath:    filepath.Join(dataDir, "collections.json"),
+	}
+	if err := store.loadCollections(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Create assigns a random ID and creation time and stores the collection.
+func (s *FileCollectionStore) Create(collection *models.Collection) error {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return fmt.Errorf("failed to generate collection id: %w", err)
+	}
+	collection.ID = hex.EncodeToString(idBytes)
+	collection.CreatedAt = time.Now().UTC()
+	return s.Put(collection)
+}
+
+// Get returns a copy of the collection with the given ID.
+func (s *FileCollectionStore) Get(id string) (*models.Collection, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	collection, exists := s.collections[id]
+	if !exists {
+		return nil, false
+	}
+	clone := *collection
+	return &clone, true
+}
+
+// Put creates or replaces a collection.
+func (s *FileCollectionStore) Put(collection *models.Collection) error {
+	if collection == nil || collection.ID == "" {
+		return fmt.Errorf("collection id cannot be empty")
+	}
+	stored := *collection
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, existed := s.collections[stored.ID]
+	s.collections[stored.ID] = &stored
+	if err := s.saveCollectionsLocked(); err != nil {
+		// Rollback
+		if existed {
+			s.collections[stored.ID] = previous
+		} else {
+			delete(s.collections, stored.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a collection.
+func (s *FileCollectionStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	collection, exists := s.collections[id]
+	if !exists {
+		return nil
+	}
+	delete(s.collections, id)
+	if err := s.saveCollectionsLocked(); err != nil {
+		s.collections[id] = collection
+		return err
+	}
+	return nil
+}
+
+// ListByUser returns copies of every collection the user owns.
+func (s *FileCollectionStore) ListByUser(userID string) []*models.Collection {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	collections := make([]*models.Collection, 0)
+	for _, collection := range s.collections {
+		if collection.UserID == userID {
+			clone := *collection
+			collections = append(collections, &clone)
+		}
+	}
+	return collections
+}
+
+// loadCollections loads collections from the JSON file.
+func (s *FileCollectionStore) loadCollections() error {
+	data, migrated, err := readDataFile(s.filePath, collectionsFileSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read collections file: %w", err)
+	}
+
+	var collectionData models.CollectionData
+	if err := json.Unmarshal(data, &collectionData); err != nil {
+		return fmt.Errorf("failed to parse collections file: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range collectionData.Collections {
+		collection := &collectionData.Collections[i]
+		s.collections[collection.ID] = collection
+	}
+	if migrated {
+		return s.saveCollectionsLocked()
+	}
+	return nil
+}
+
+// saveCollectionsLocked writes all collections to the JSON file. Callers
+// must hold the write lock.
+func (s *FileCollectionStore) saveCollectionsLocked() error {
+	collectionsList := make([]models.Collection, 0, len(s.collections))
+	for _, collection := range s.collections {
+		collectionsList = append(collectionsList, *collection)
+	}
+
+	data, err := json.MarshalIndent(models.CollectionData{
+		SchemaVersion: collectionsFileSchema.version,
+		Collections:   collectionsList,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collections: %w", err)
+	}
+	if err := writeFileAtomic(s.filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write collections file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestFileCollectionStorePersistsCollectionsPerUser(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileCollectionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := &models.Collection{UserID: "user-1", Name: "Work", ExpiresIn: "7d"}
+	if err := store.Create(work); err != nil || work.ID == "" || work.CreatedAt.IsZero() {
+		t.Fatalf("expected an ID and creation time, got %#v, %v", work, err)
+	}
+	store.Create(&models.Collection{UserID: "user-1", Name: "Home"})
+	store.Create(&models.Collection{UserID: "user-2", Name: "Work"})
+
+	work.Name = "Office"
+	if err := store.Put(work); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewFileCollectionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reloaded.Get(work.ID); got == nil || got.Name != "Office" || got.ExpiresIn != "7d" {
+		t.Fatalf("expected the renamed collection after a reload, got %#v", got)
+	}
+	if collections := reloaded.ListByUser("user-1"); len(collections) != 2 {
+		t.Fatalf("expected two collections for user-1, got %d", len(collections))
+	}
+
+	if err := reloaded.Delete(work.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := reloaded.Get(work.ID); exists {
+		t.Fatal("deleted collection still present")
+	}
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// dataFileMigration upgrades a decoded data file by exactly one schema version.
+type dataFileMigration func(document map[string]json.RawMessage) error
+
+// dataFileSchema describes a versioned JSON data file. migrations[n] upgrades
+// a file from version n to n+1, so len(migrations) must equal version.
+type dataFileSchema struct {
+	name       string
+	version    int
+	migrations []dataFileMigration
+}
+
+// stampSchemaVersion is the migration for files written before schemaVersion
+// existed; their layout is otherwise identical to version 1.
+func stampSchemaVersion(map[string]json.RawMessage) error {
+	return nil
+}
+
+var (
+	usersFileSchema = dataFileSchema{
+		name:       "users.json",
+		version:    1,
+		migrations: []dataFileMigration{stampSchemaVersion},
+	}
+	settingsFileSchema = dataFileSchema{
+		name:       "settings.json",
+		version:    1,
+		migrations: []dataFileMigration{stampSchemaVersion},
+	}
+	clipboardFileSchema = dataFileSchema{
+		name:       "clipboard.json",
+		version:    1,
+		migrations: []dataFileMigration{stampSchemaVersion},
+	}
+	sharesFileSchema = dataFileSchema{
+		name:       "shares.json",
+		version:    1,
+		migrations: []dataFileMigration{stampSchemaVersion},
+	}
+	collectionsFileSchema = dataFileSchema{
+		name:       "collections.json",
+		version:    1,
+		migrations: []dataFileMigration{stampSchemaVersion},
+	}
+)
+
+// readDataFile reads a versioned data file and upgrades it to the current
+// schema. Before a file is migrated its old contents are kept as path.bak,
+// which is also used instead of a corrupt primary file. migrated reports
+// whether the returned data differs from disk and should be written back.
+func readDataFile(path string, schema dataFileSchema) (data []byte, migrated bool, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	recovered := false
+	if !json.Valid(data) {
+		backup, backupErr := os.ReadFile(backupPath(path))
+		if backupErr != nil || !json.Valid(backup) {
+			return nil, false, fmt.Errorf("failed to parse %s: file is corrupt and no usable backup exists", schema.name)
+		}
+		log.Printf("%s is corrupt, recovering from %s", schema.name, filepath.Base(backupPath(path)))
+		data = backup
+		recovered = true
+	}
+
+	upgraded, changed, err := migrateDataFile(data, schema)
+	if err != nil {
+		return nil, false, err
+	}
+	if changed && !recovered {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := writeFileAtomic(backupPath(path), data, info.Mode().Perm()); err != nil {
+			return nil, false, fmt.Errorf("failed to back up %s before migrating it: %w", schema.name, err)
+		}
+	}
+	return upgraded, recovered || changed, nil
+}
+
+func migrateDataFile(data []byte, schema dataFileSchema) ([]byte, bool, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, false, fmt.Errorf("failed to parse %s: %w", schema.name, err)
+	}
+	if document == nil {
+		document = make(map[string]json.RawMessage)
+	}
+
+	version := 0
+	if raw, exists := document["schemaVersion"]; exists {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s schemaVersion: %w", schema.name, err)
+		}
+	}
+	if version < 0 {
+		return nil, false, fmt.Errorf("%s has invalid schema version %d", schema.name, version)
+	}
+	if version > schema.version {
+		return nil, false, fmt.Errorf("%s has schema version %d, but this build only supports up to version %d; upgrade web-clipboard-go before starting it on this data directory", schema.name, version, schema.version)
+	}
+	if version == schema.version {
+		return data, false, nil
+	}
+
+	for ; version < schema.version; version++ {
+		if err := schema.migrations[version](document); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate %s from schema version %d to %d: %w", schema.name, version, version+1, err)
+		}
+	}
+	rawVersion, _ := json.Marshal(schema.version)
+	document["schemaVersion"] = rawVersion
+
+	upgraded, err := json.Marshal(document)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode migrated %s: %w", schema.name, err)
+	}
+	return upgraded, true, nil
+}
+
+// writeFileAtomic replaces path without ever leaving a partially written
+// file behind: data goes to a synced temp file that is renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	cleanup := func() {
+		temp.Close()
+		os.Remove(tempPath)
+	}
+
+	if _, err := temp.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := temp.Chmod(perm); err != nil {
+		cleanup()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes a rename to disk. Some platforms cannot fsync
+// directories, so failures are ignored.
+func syncDir(dir string) {
+	handle, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	handle.Sync()
+	handle.Close()
+}
+
+func backupPath(path string) string {
+	return path + ".bak"
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestWriteFileAtomicReplacesFileWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := writeFileAtomic(path, []byte(`{"v":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(`{"v":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != `{"v":2}` {
+		t.Fatalf("unexpected current=%s", current)
+	}
+	// Backups are only taken before migrations, not on every write.
+	if _, err := os.Stat(backupPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup, got err=%v", err)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".data.json.tmp-*"))
+	if len(leftovers) != 0 {
+		t.Fatalf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestUserManagerUpgradesLegacyUsersFile(t *testing.T) {
+	dataDir := t.TempDir()
+	legacy := `{"users":[{"id":"u1","username":"legacy","email":"legacy@example.com","role":"admin","isActive":true}]}`
+	if err := os.WriteFile(filepath.Join(dataDir, "users.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := NewUserManager(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manager.GetUserByUsername("legacy") == nil {
+		t.Fatal("legacy user was not loaded")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usersData models.UsersData
+	if err := json.Unmarshal(data, &usersData); err != nil {
+		t.Fatal(err)
+	}
+	if usersData.SchemaVersion != usersFileSchema.version {
+		t.Fatalf("users.json was not upgraded, schemaVersion=%d", usersData.SchemaVersion)
+	}
+}
+
+func TestUserManagerRejectsNewerSchemaVersion(t *testing.T) {
+	dataDir := t.TempDir()
+	future := `{"schemaVersion":99,"users":[]}`
+	if err := os.WriteFile(filepath.Join(dataDir, "users.json"), []byte(future), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewUserManager(dataDir)
+	if err == nil {
+		t.Fatal("expected a users.json from a newer build to be rejected")
+	}
+	if !strings.Contains(err.Error(), "schema version 99") {
+		t.Fatalf("error should name the unsupported version, got %v", err)
+	}
+}
+
+func TestUserManagerRejectsNegativeSchemaVersion(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, "users.json"), []byte(`{"schemaVersion":-1,"users":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewUserManager(dataDir)
+	if err == nil || !strings.Contains(err.Error(), "schema version -1") {
+		t.Fatalf("expected a negative schema version to be rejected, got %v", err)
+	}
+}
+
+func TestSettingsServiceRecoversFromCorruptFileUsingMigrationBackup(t *testing.T) {
+	dataDir := t.TempDir()
+	service, err := NewSettingsService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := service.GetSettings()
+	settings.Clipboard.ExpirationValue = 4
+	settings.Clipboard.ExpirationUnit = models.ClipboardExpirationUnitHour
+	if err := service.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Turn the file into one written before schemaVersion existed, so the
+	// next start migrates it and keeps the old contents as a backup.
+	path := filepath.Join(dataDir, "settings.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	delete(document, "schemaVersion")
+	legacy, _ := json.Marshal(document)
+	if err := os.WriteFile(path, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := NewSettingsService(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup, err := os.ReadFile(backupPath(path)); err != nil || string(backup) != string(legacy) {
+		t.Fatalf("expected the pre-migration file as backup, got %s err=%v", backup, err)
+	}
+	settings.Clipboard.ExpirationValue = 5
+	if err := migrated.SaveSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash that truncated the primary file mid-write.
+	if err := os.WriteFile(path, []byte(`{"auth":{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := NewSettingsService(dataDir)
+	if err != nil {
+		t.Fatalf("corrupt settings.json should recover from backup: %v", err)
+	}
+	if got := recovered.GetSettings().Clipboard; got.ExpirationValue != 4 || got.ExpirationUnit != models.ClipboardExpirationUnitHour {
+		t.Fatalf("expected settings from backup, got %#v", got)
+	}
+}
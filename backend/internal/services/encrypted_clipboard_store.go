@@ -0,0 +1,240 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// EncryptedClipboardStore wraps another clipboard store and keeps item
+// content encrypted in it. Each item gets its own data key, wrapped by the
+// primary master key; callers only ever see plaintext.
+type EncryptedClipboardStore struct {
+	inner models.ClipboardStore
+	keys  *KeyRing
+}
+
+func NewEncryptedClipboardStore(inner models.ClipboardStore, keys *KeyRing) *EncryptedClipboardStore {
+	return &EncryptedClipboardStore{inner: inner, keys: keys}
+}
+
+func (s *EncryptedClipboardStore) Put(item *models.ClipboardItem) error {
+	if item == nil {
+		return s.inner.Put(item)
+	}
+	encrypted, err := s.encrypt(item)
+	if err != nil {
+		return err
+	}
+	return s.inner.Put(encrypted)
+}
+
+// Get returns the decrypted item. Items that cannot be decrypted, for
+// example because their master key was removed, are reported as missing.
+func (s *EncryptedClipboardStore) Get(id string) (*models.ClipboardItem, bool) {
+	item, exists := s.inner.Get(id)
+	if !exists {
+		return nil, false
+	}
+	if err := s.decrypt(item); err != nil {
+		log.Printf("Failed to decrypt clipboard item %s: %v", id, err)
+		return nil, false
+	}
+	return item, true
+}
+
+func (s *EncryptedClipboardStore) Delete(id string) (*models.ClipboardItem, error) {
+	item, err := s.inner.Delete(id)
+	if err != nil || item == nil {
+		return item, err
+	}
+	if err := s.decrypt(item); err != nil {
+		// The item is gone either way; the caller only needs its metadata.
+		item.Content = ""
+		item.Encryption = nil
+	}
+	return item, nil
+}
+
+func (s *EncryptedClipboardStore) ConsumeRead(id string) (*models.ClipboardItem, bool, error) {
+	item, last, err := s.inner.ConsumeRead(id)
+	if err != nil || item == nil {
+		return item, last, err
+	}
+	if err := s.decrypt(item); err != nil {
+		log.Printf("Failed to decrypt clipboard item %s: %v", id, err)
+		return nil, false, nil
+	}
+	return item, last, nil
+}
+
+// Update hands update the decrypted item and stores its result encrypted
+// under a new data key.
+func (s *EncryptedClipboardStore) Update(id string, update func(item *models.ClipboardItem) error) (*models.ClipboardItem, error) {
+	updated, err := s.inner.Update(id, func(item *models.ClipboardItem) error {
+		if err := s.decrypt(item); err != nil {
+			return err
+		}
+		if err := update(item); err != nil {
+			return err
+		}
+		encrypted, err := s.encrypt(item)
+		if err != nil {
+			return err
+		}
+		*item = *encrypted
+		return nil
+	})
+	if err != nil || updated == nil {
+		return updated, err
+	}
+	if err := s.decrypt(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *EncryptedClipboardStore) MarkOpened(id, userID string, at time.Time) error {
+	return s.inner.MarkOpened(id, userID, at)
+}
+
+func (s *EncryptedClipboardStore) ListByUser(userID string) []*models.ClipboardItem {
+	return s.decryptAll(s.inner.ListByUser(userID))
+}
+
+func (s *EncryptedClipboardStore) ListSharedWith(userID string) []*models.ClipboardItem {
+	return s.decryptAll(s.inner.ListSharedWith(userID))
+}
+
+func (s *EncryptedClipboardStore) ListAll() []*models.ClipboardItem {
+	return s.decryptAll(s.inner.ListAll())
+}
+
+func (s *EncryptedClipboardStore) Usage(userID string, now time.Time) models.StorageUsage {
+	return s.inner.Usage(userID, now)
+}
+
+func (s *EncryptedClipboardStore) ExpireBefore(now time.Time) ([]*models.ClipboardItem, error) {
+	expired, err := s.inner.ExpireBefore(now)
+	for _, item := range expired {
+		if s.decrypt(item) != nil {
+			item.Content = ""
+			item.Encryption = nil
+		}
+	}
+	return expired, err
+}
+
+// RewrapKeys brings every stored item onto the primary master key: data keys
+// wrapped by an older key are rewrapped, and items stored before encryption
+// was enabled are encrypted. It returns the number of items updated.
+func (s *EncryptedClipboardStore) RewrapKeys() (int, error) {
+	updated := 0
+	for _, item := range s.inner.ListAll() {
+		switch {
+		case item.Encryption == nil && item.Content == "":
+			continue
+		case item.Encryption == nil:
+			encrypted, err := s.encrypt(item)
+			if err != nil {
+				return updated, err
+			}
+			item = encrypted
+		case item.Encryption.KeyID == s.keys.PrimaryID():
+			continue
+		default:
+			keyID, wrapped, err := s.keys.rewrap(item.Encryption.KeyID, item.Encryption.DataKey)
+			if err != nil {
+				return updated, fmt.Errorf("clipboard item %s: %w", item.ID, err)
+			}
+			item.Encryption = &models.ItemEncryption{KeyID: keyID, DataKey: wrapped, Size: item.Encryption.Size}
+		}
+		if err := s.inner.Put(item); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// encrypt returns a copy of item with its content and earlier revisions
+// sealed under a new data key.
+func (s *EncryptedClipboardStore) encrypt(item *models.ClipboardItem) (*models.ClipboardItem, error) {
+	encrypted := *item
+	encrypted.Encryption = nil
+	if item.Content == "" {
+		return &encrypted, nil
+	}
+	size := models.ClipboardItemSize(&encrypted)
+	dataKey, keyID, wrapped, err := s.keys.newDataKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	content, err := sealBase64(aead, []byte(item.Content))
+	if err != nil {
+		return nil, err
+	}
+	encrypted.Content = content
+	encrypted.Revisions = make([]models.TextRevision, len(item.Revisions))
+	for i, revision := range item.Revisions {
+		if revision.Content, err = sealBase64(aead, []byte(revision.Content)); err != nil {
+			return nil, err
+		}
+		encrypted.Revisions[i] = revision
+	}
+	encrypted.Encryption = &models.ItemEncryption{KeyID: keyID, DataKey: wrapped, Size: size}
+	return &encrypted, nil
+}
+
+// decrypt replaces item's content with its plaintext in place. Items stored
+// before encryption was enabled are left as they are.
+func (s *EncryptedClipboardStore) decrypt(item *models.ClipboardItem) error {
+	if item.Encryption == nil {
+		return nil
+	}
+	dataKey, err := s.keys.unwrap(item.Encryption.KeyID, item.Encryption.DataKey)
+	if err != nil {
+		return err
+	}
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return err
+	}
+	content, err := openBase64(aead, item.Content)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt content: %w", err)
+	}
+	revisions := make([]models.TextRevision, len(item.Revisions))
+	for i, revision := range item.Revisions {
+		plaintext, err := openBase64(aead, revision.Content)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt revision %d: %w", revision.Revision, err)
+		}
+		revision.Content = string(plaintext)
+		revisions[i] = revision
+	}
+	item.Content = string(content)
+	if len(revisions) > 0 {
+		item.Revisions = revisions
+	}
+	item.Encryption = nil
+	return nil
+}
+
+func (s *EncryptedClipboardStore) decryptAll(items []*models.ClipboardItem) []*models.ClipboardItem {
+	decrypted := make([]*models.ClipboardItem, 0, len(items))
+	for _, item := range items {
+		if err := s.decrypt(item); err != nil {
+			log.Printf("Failed to decrypt clipboard item %s: %v", item.ID, err)
+			continue
+		}
+		decrypted = append(decrypted, item)
+	}
+	return decrypted
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const dataKeySize = 32
+
+// KeyRing holds the master keys used to wrap per-item data keys. The first
+// key wraps new data keys; the others are only kept to unwrap data keys
+// written before a rotation.
+type KeyRing struct {
+	primary string
+	keys    map[string]cipher.AEAD // key: key ID
+}
+
+// NewKeyRing builds a key ring from raw 32-byte AES-256 keys, primary first.
+func NewKeyRing(keys ...[]byte) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one master key is required")
+	}
+	ring := &KeyRing{keys: make(map[string]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key %d must be %d bytes, got %d", i+1, dataKeySize, len(key))
+		}
+		aead, err := newAESGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		id := masterKeyID(key)
+		if i == 0 {
+			ring.primary = id
+		}
+		ring.keys[id] = aead
+	}
+	return ring, nil
+}
+
+// LoadKeyRing reads base64-encoded master keys from keyFile (one per line,
+// '#' starts a comment) or from envValue (comma-separated). The first key is
+// the primary. It returns nil when neither source is configured.
+func LoadKeyRing(keyFile, envValue string) (*KeyRing, error) {
+	if keyFile != "" && envValue != "" {
+		return nil, errors.New("configure the master key through a key file or an environment variable, not both")
+	}
+
+	var encoded []string
+	switch {
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master key file: %w", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			if line = strings.TrimSpace(line); line != "" {
+				encoded = append(encoded, line)
+			}
+		}
+	case envValue != "":
+		for _, value := range strings.Split(envValue, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				encoded = append(encoded, value)
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	keys := make([][]byte, 0, len(encoded))
+	for i, value := range encoded {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("master key %d is not valid base64: %w", i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyRing(keys...)
+}
+
+// PrimaryID identifies the master key that wraps new data keys.
+func (k *KeyRing) PrimaryID() string {
+	return k.primary
+}
+
+// newDataKey returns a fresh data key and its wrapped form.
+func (k *KeyRing) newDataKey() (key []byte, keyID, wrapped string, err error) {
+	key = make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err = sealBase64(k.keys[k.primary], key)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return key, k.primary, wrapped, nil
+}
+
+func (k *KeyRing) unwrap(keyID, wrapped string) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not configured", keyID)
+	}
+	key, err := openBase64(aead, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return key, nil
+}
+
+// rewrap re-encrypts a data key under the primary master key.
+func (k *KeyRing) rewrap(keyID, wrapped string) (string, string, error) {
+	key, err := k.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", "", err
+	}
+	rewrapped, err := sealBase64(k.keys[k.primary], key)
+	if err != nil {
+		return "", "", err
+	}
+	return k.primary, rewrapped, nil
+}
+
+func masterKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealBase64 encrypts plaintext with a random nonce and returns
+// base64(nonce || ciphertext).
+func sealBase64(aead cipher.AEAD, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func openBase64(aead cipher.AEAD, encoded string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+// Blobs are encrypted in fixed-size segments so a download can seek to any
+// segment without decrypting what comes before it. Each segment is sealed
+// with its index as the nonce and a final-segment flag as additional data,
+// which catches reordered or truncated ciphertext. The final segment always
+// holds fewer than blobSegmentSize bytes, possibly none.
+const blobSegmentSize = 64 * 1024
+
+func segmentNonce(aead cipher.AEAD, index int64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], uint64(index))
+	return nonce
+}
+
+func segmentAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// segmentWriter encrypts everything written to it onto w. Close must be
+// called to write the final segment.
+type segmentWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	buffer []byte
+	index  int64
+}
+
+func newSegmentWriter(w io.Writer, dataKey []byte) (*segmentWriter, error) {
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentWriter{w: w, aead: aead, buffer: make([]byte, 0, blobSegmentSize)}, nil
+}
+
+func (s *segmentWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(blobSegmentSize-len(s.buffer), len(p))
+		s.buffer = append(s.buffer, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(s.buffer) == blobSegmentSize {
+			if err := s.flush(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (s *segmentWriter) Close() error {
+	return s.flush(true)
+}
+
+func (s *segmentWriter) flush(final bool) error {
+	sealed := s.aead.Seal(nil, segmentNonce(s.aead, s.index), s.buffer, segmentAAD(final))
+	s.index++
+	s.buffer = s.buffer[:0]
+	_, err := s.w.Write(sealed)
+	return err
+}
+
+// segmentReader decrypts a segmented blob and supports seeking, so
+// http.ServeContent can answer Range requests.
+type segmentReader struct {
+	src        io.ReadSeekCloser
+	aead       cipher.AEAD
+	cipherSize int64
+	plainSize  int64
+	segments   int64
+	pos        int64
+	loaded     int64 // index of the segment in plain, or -1
+	plain      []byte
+}
+
+func newSegmentReader(src io.ReadSeekCloser, dataKey []byte) (*segmentReader, error) {
+	aead, err := newAESGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	cipherSize, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	sealedSegment := int64(blobSegmentSize + aead.Overhead())
+	// The final segment is always present, so anything shorter than its
+	// tag after the full segments means segments went missing.
+	if cipherSize%sealedSegment < int64(aead.Overhead()) {
+		return nil, errors.New("encrypted blob is truncated")
+	}
+	segments := cipherSize/sealedSegment + 1
+	plainSize := cipherSize - segments*int64(aead.Overhead())
+	return &segmentReader{
+		src:        src,
+		aead:       aead,
+		cipherSize: cipherSize,
+		plainSize:  plainSize,
+		segments:   segments,
+		loaded:     -1,
+	}, nil
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	if s.pos >= s.plainSize {
+		return 0, io.EOF
+	}
+	index := s.pos / blobSegmentSize
+	if index != s.loaded {
+		if err := s.load(index); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.plain[s.pos-index*blobSegmentSize:])
+	s.pos += int64(n)
+	return n, nil
+}
+
+func (s *segmentReader) load(index int64) error {
+	sealedSegment := int64(blobSegmentSize + s.aead.Overhead())
+	start := index * sealedSegment
+	sealed := make([]byte, min(sealedSegment, s.cipherSize-start))
+	if _, err := s.src.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(s.src, sealed); err != nil {
+		return err
+	}
+	plain, err := s.aead.Open(s.plain[:0], segmentNonce(s.aead, index), sealed, segmentAAD(index == s.segments-1))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt blob segment %d: %w", index, err)
+	}
+	s.plain = plain
+	s.loaded = index
+	return nil
+}
+
+func (s *segmentReader) Seek(offset int64, whence int) (int64, error) {
+	next := offset
+	switch whence {
+	case io.SeekCurrent:
+		next = s.pos + offset
+	case io.SeekEnd:
+		next = s.plainSize + offset
+	}
+	if next < 0 {
+		return 0, errors.New("negative seek position")
+	}
+	s.pos = next
+	return next, nil
+}
+
+func (s *segmentReader) Close() error {
+	return s.src.Close()
+}
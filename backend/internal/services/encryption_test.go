@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func newTestMasterKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// memoryFile adapts a byte slice to io.ReadSeekCloser.
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+func TestSegmentEncryptionRoundTripsAndSeeks(t *testing.T) {
+	dataKey := newTestMasterKey(t)
+	plain := make([]byte, 2*blobSegmentSize+123)
+	rand.Read(plain)
+
+	var sealed bytes.Buffer
+	writer, err := newSegmentWriter(&sealed, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(plain[:1000])
+	writer.Write(plain[1000:])
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed.Bytes(), plain[:64]) {
+		t.Fatal("ciphertext contains plaintext")
+	}
+
+	reader, err := newSegmentReader(memoryFile{bytes.NewReader(sealed.Bytes())}, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := reader.Seek(0, io.SeekEnd); size != int64(len(plain)) {
+		t.Fatalf("expected plaintext size %d, got %d", len(plain), size)
+	}
+	offset := int64(blobSegmentSize - 10)
+	reader.Seek(offset, io.SeekStart)
+	tail, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tail, plain[offset:]) {
+		t.Fatal("data read after seeking does not match")
+	}
+
+	// Dropping the final segment must not pass for a shorter file.
+	truncated := sealed.Bytes()[:2*(blobSegmentSize+16)]
+	if _, err := newSegmentReader(memoryFile{bytes.NewReader(truncated)}, dataKey); err == nil {
+		t.Fatal("expected ciphertext without a final segment to be rejected")
+	}
+
+	// Cutting inside the final segment fails authentication.
+	reader, err = newSegmentReader(memoryFile{bytes.NewReader(sealed.Bytes()[:sealed.Len()-50])}, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Fatal("expected truncated ciphertext to fail authentication")
+	}
+}
+
+func TestLoadKeyRingReadsKeyFileWithPrimaryFirst(t *testing.T) {
+	primary, retired := newTestMasterKey(t), newTestMasterKey(t)
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	content := "# current\n" + base64.StdEncoding.EncodeToString(primary) + "\n\n" +
+		base64.StdEncoding.EncodeToString(retired) + " # retired 2026-01\n"
+	if err := os.WriteFile(keyFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := LoadKeyRing(keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.PrimaryID() != masterKeyID(primary) || len(keys.keys) != 2 {
+		t.Fatalf("unexpected key ring %v", keys.keys)
+	}
+	if keys, err := LoadKeyRing("", ""); keys != nil || err != nil {
+		t.Fatalf("expected no key ring without configuration, got %v, %v", keys, err)
+	}
+	if _, err := LoadKeyRing("", base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Fatal("expected a short key to be rejected")
+	}
+}
+
+func TestEncryptedClipboardStoreKeepsContentEncryptedOnDisk(t *testing.T) {
+	dataDir := t.TempDir()
+	inner, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKey, newKey := newTestMasterKey(t), newTestMasterKey(t)
+	oldRing, _ := NewKeyRing(oldKey)
+	store := NewEncryptedClipboardStore(inner, oldRing)
+
+	now := time.Now().UTC()
+	item := &models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "launch code 0000", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := store.Put(item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Content != "launch code 0000" {
+		t.Fatal("Put must not modify the caller's item")
+	}
+	data, _ := os.ReadFile(filepath.Join(dataDir, "clipboard.json"))
+	if strings.Contains(string(data), "launch code") {
+		t.Fatal("plaintext content written to disk")
+	}
+	if usage := store.Usage("user-1", now); usage.Bytes != int64(len("launch code 0000")) {
+		t.Fatalf("usage should count plaintext bytes, got %d", usage.Bytes)
+	}
+
+	// Rotate: the new key becomes primary and old items are rewrapped.
+	rotated, _ := NewKeyRing(newKey, oldKey)
+	store = NewEncryptedClipboardStore(inner, rotated)
+	if count, err := store.RewrapKeys(); err != nil || count != 1 {
+		t.Fatalf("expected one rewrapped item, got %d, %v", count, err)
+	}
+	newOnly, _ := NewKeyRing(newKey)
+	store = NewEncryptedClipboardStore(inner, newOnly)
+	got, exists := store.Get("abcd")
+	if !exists || got.Content != "launch code 0000" || got.Encryption != nil {
+		t.Fatalf("expected decrypted item after rotation, got %#v", got)
+	}
+
+	// Without the right key the item is unreadable rather than garbage.
+	store = NewEncryptedClipboardStore(inner, oldRing)
+	if _, exists := store.Get("abcd"); exists {
+		t.Fatal("expected item to be unreadable with a retired key")
+	}
+}
+
+func TestEncryptedBlobStoreServesPlaintextAndRotates(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, newKey := newTestMasterKey(t), newTestMasterKey(t)
+	blobs, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, _, err := blobs.Put(strings.NewReader("uploaded before encryption"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldRing, _ := NewKeyRing(oldKey)
+	blobs.UseKeyRing(oldRing)
+	hash, _, err := blobs.Put(strings.NewReader("quarterly report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := os.ReadFile(filepath.Join(dir, blobKey(hash)))
+	if bytes.Contains(stored, []byte("quarterly")) {
+		t.Fatal("blob stored in plaintext")
+	}
+
+	reopened, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, _ := NewKeyRing(newKey, oldKey)
+	reopened.UseKeyRing(rotated)
+	if count, err := reopened.RewrapKeys(); err != nil || count != 1 {
+		t.Fatalf("expected one rewrapped blob key, got %d, %v", count, err)
+	}
+	newOnly, _ := NewKeyRing(newKey)
+	reopened.UseKeyRing(newOnly)
+	for blob, want := range map[string]string{hash: "quarterly report", legacy: "uploaded before encryption"} {
+		content, err := reopened.Open(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(content)
+		content.Close()
+		if string(data) != want {
+			t.Fatalf("expected %q, got %q", want, data)
+		}
+	}
+}
+
+func TestEncryptedClipboardStoreSealsTextRevisions(t *testing.T) {
+	dataDir := t.TempDir()
+	inner, err := NewFileClipboardStore(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := NewKeyRing(newTestMasterKey(t))
+	store := NewEncryptedClipboardStore(inner, keys)
+
+	now := time.Now().UTC()
+	if err := store.Put(&models.ClipboardItem{ID: "abcd", Type: "text", UserID: "user-1", Content: "first draft", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := store.Update("abcd", func(item *models.ClipboardItem) error {
+		item.Revisions = append(item.Revisions, models.TextRevision{Revision: 1, Content: item.Content, CreatedAt: item.CreatedAt})
+		item.Revision = 2
+		item.Content = "second draft"
+		return nil
+	})
+	if err != nil || updated.Content != "second draft" || updated.Revisions[0].Content != "first draft" {
+		t.Fatalf("unexpected update result %#v, %v", updated, err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dataDir, "clipboard.json"))
+	if strings.Contains(string(data), "draft") {
+		t.Fatal("revision content written to disk in plaintext")
+	}
+	if usage := store.Usage("user-1", now); usage.Bytes != int64(len("first draft")+len("second draft")) {
+		t.Fatalf("usage should count every revision, got %d", usage.Bytes)
+	}
+	got, _ := store.Get("abcd")
+	if got.Revisions[0].Content != "first draft" {
+		t.Fatalf("expected revisions to decrypt, got %#v", got.Revisions)
+	}
+}
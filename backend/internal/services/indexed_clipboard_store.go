@@ -0,0 +1,87 @@
+package services
+
+import (
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// IndexedClipboardStore wraps another clipboard store and keeps a search
+// index in step with every write to it. It should wrap the outermost store
+// that hands out plaintext, so encrypted content is indexed as it reads.
+type IndexedClipboardStore struct {
+	inner models.ClipboardStore
+	index *SearchIndex
+}
+
+// NewIndexedClipboardStore indexes every item already in inner.
+func NewIndexedClipboardStore(inner models.ClipboardStore, index *SearchIndex) *IndexedClipboardStore {
+	for _, item := range inner.ListAll() {
+		index.Add(item)
+	}
+	return &IndexedClipboardStore{inner: inner, index: index}
+}
+
+func (s *IndexedClipboardStore) Put(item *models.ClipboardItem) error {
+	if err := s.inner.Put(item); err != nil {
+		return err
+	}
+	s.index.Add(item)
+	return nil
+}
+
+func (s *IndexedClipboardStore) Get(id string) (*models.ClipboardItem, bool) {
+	return s.inner.Get(id)
+}
+
+func (s *IndexedClipboardStore) Delete(id string) (*models.ClipboardItem, error) {
+	item, err := s.inner.Delete(id)
+	if err == nil && item != nil {
+		s.index.Remove(id)
+	}
+	return item, err
+}
+
+func (s *IndexedClipboardStore) ConsumeRead(id string) (*models.ClipboardItem, bool, error) {
+	item, last, err := s.inner.ConsumeRead(id)
+	if err == nil && last {
+		s.index.Remove(id)
+	}
+	return item, last, err
+}
+
+func (s *IndexedClipboardStore) Update(id string, update func(item *models.ClipboardItem) error) (*models.ClipboardItem, error) {
+	item, err := s.inner.Update(id, update)
+	if err == nil && item != nil {
+		s.index.Add(item)
+	}
+	return item, err
+}
+
+func (s *IndexedClipboardStore) MarkOpened(id, userID string, at time.Time) error {
+	return s.inner.MarkOpened(id, userID, at)
+}
+
+func (s *IndexedClipboardStore) ListByUser(userID string) []*models.ClipboardItem {
+	return s.inner.ListByUser(userID)
+}
+
+func (s *IndexedClipboardStore) ListSharedWith(userID string) []*models.ClipboardItem {
+	return s.inner.ListSharedWith(userID)
+}
+
+func (s *IndexedClipboardStore) ListAll() []*models.ClipboardItem {
+	return s.inner.ListAll()
+}
+
+func (s *IndexedClipboardStore) Usage(userID string, now time.Time) models.StorageUsage {
+	return s.inner.Usage(userID, now)
+}
+
+func (s *IndexedClipboardStore) ExpireBefore(now time.Time) ([]*models.ClipboardItem, error) {
+	expired, err := s.inner.ExpireBefore(now)
+	for _, item := range expired {
+		s.index.Remove(item.ID)
+	}
+	return expired, err
+}
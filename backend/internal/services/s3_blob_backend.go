@@ -0,0 +1,466 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Config configures an S3-compatible object store such as AWS S3 or MinIO.
+// Objects are addressed path-style: Endpoint/Bucket/Prefix+key.
+type S3Config struct {
+	Endpoint         string
+	Region           string
+	Bucket           string
+	AccessKey        string
+	SecretKey        string
+	Prefix           string
+	PresignDownloads bool
+}
+
+// NewS3BlobStore keeps blob bytes in an S3-compatible bucket. Uploads are
+// staged and hashed in dataDir, and reference counts live in
+// dataDir/blob-refs.json. Since those counts are only known to this
+// instance, its objects go under a namespace of its own, so instances that
+// share a bucket never delete each other's blobs.
+func NewS3BlobStore(config S3Config, dataDir string) (*ContentBlobStore, error) {
+	config.Endpoint = strings.TrimRight(strings.TrimSpace(config.Endpoint), "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("S3 endpoint and bucket are required")
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("S3 access key and secret key are required")
+	}
+	if _, err := url.Parse(config.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	tempDir := filepath.Join(dataDir, "uploads")
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	// No overall client timeout: proxied downloads read the body for as
+	// long as the download route allows. Only connecting and waiting for
+	// response headers are bounded here.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Minute
+	backend := &s3BlobBackend{
+		config:        config,
+		client:        &http.Client{Transport: transport},
+		now:           time.Now,
+		namespacePath: filepath.Join(dataDir, "s3-namespace.json"),
+	}
+	refsPath := filepath.Join(dataDir, "blob-refs.json")
+	if err := backend.loadNamespace(refsPath); err != nil {
+		return nil, err
+	}
+	return newContentBlobStore(backend, tempDir, refsPath, filepath.Join(dataDir, "blob-keys.json"))
+}
+
+type s3BlobBackend struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+
+	// Objects are stored under namespace/key. Keys in shared were stored
+	// before instances had namespaces and sit directly under the prefix;
+	// other instances may reference them too, so they are never deleted.
+	namespace     string
+	shared        map[string]bool
+	namespacePath string
+	mutex         sync.Mutex
+}
+
+// s3Namespace is the content of s3-namespace.json.
+type s3Namespace struct {
+	Namespace string   `json:"namespace"`
+	Shared    []string `json:"shared,omitempty"`
+}
+
+// loadNamespace reads this instance's namespace, creating one on first
+// start. Blobs already counted in refsPath at that point were stored
+// without a namespace and are recorded as shared.
+func (s *s3BlobBackend) loadNamespace(refsPath string) error {
+	var state s3Namespace
+	if err := readJSONIfExists(s.namespacePath, &state); err != nil {
+		return fmt.Errorf("failed to read S3 namespace: %w", err)
+	}
+	s.shared = make(map[string]bool)
+	if state.Namespace != "" {
+		s.namespace = state.Namespace
+		for _, key := range state.Shared {
+			s.shared[key] = true
+		}
+		return nil
+	}
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return fmt.Errorf("failed to generate S3 namespace: %w", err)
+	}
+	s.namespace = hex.EncodeToString(idBytes)
+	refs := make(map[string]int)
+	if err := readJSONIfExists(refsPath, &refs); err != nil {
+		return fmt.Errorf("failed to read blob references: %w", err)
+	}
+	for hash := range refs {
+		if validBlobHash(hash) {
+			s.shared[blobKey(hash)] = true
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saveNamespaceLocked()
+}
+
+func (s *s3BlobBackend) saveNamespaceLocked() error {
+	state := s3Namespace{Namespace: s.namespace}
+	for key := range s.shared {
+		state.Shared = append(state.Shared, key)
+	}
+	sort.Strings(state.Shared)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal S3 namespace: %w", err)
+	}
+	if err := writeFileAtomic(s.namespacePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write S3 namespace: %w", err)
+	}
+	return nil
+}
+
+// objectKey maps a blob key to its key in the bucket, below the prefix.
+func (s *s3BlobBackend) objectKey(key string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.shared[key] {
+		return key
+	}
+	return s.namespace + "/" + key
+}
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// store streams the staged upload to the bucket. The hash of the staged
+// bytes is signed as the payload hash, so S3 verifies what it receives.
+func (s *s3BlobBackend) store(key, tempPath string, size int64, payloadHash string) error {
+	file, err := os.Open(tempPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Only called once this instance holds no reference to key, so a
+	// shared object is left to the instances still using it.
+	if _, err := s.forgetShared(key); err != nil {
+		return err
+	}
+	request, err := s.newRequest(http.MethodPut, s.objectKey(key), nil, file, payloadHash)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+	request.Header.Set("Content-Type", "application/octet-stream")
+	return s.do(request, http.StatusOK)
+}
+
+// forgetShared stops treating key as a shared object and reports whether
+// it was one.
+func (s *s3BlobBackend) forgetShared(key string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.shared[key] {
+		return false, nil
+	}
+	delete(s.shared, key)
+	if err := s.saveNamespaceLocked(); err != nil {
+		s.shared[key] = true
+		return true, err
+	}
+	return true, nil
+}
+
+func (s *s3BlobBackend) open(key string) (io.ReadSeekCloser, error) {
+	key = s.objectKey(key)
+	request, err := s.newRequest(http.MethodHead, key, nil, nil, s3EmptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("S3 HEAD %s: unexpected status %d", key, response.StatusCode)
+	}
+	return &s3Object{backend: s, key: key, size: response.ContentLength}, nil
+}
+
+func (s *s3BlobBackend) remove(key string) error {
+	if shared, err := s.forgetShared(key); shared || err != nil {
+		return err
+	}
+	request, err := s.newRequest(http.MethodDelete, s.objectKey(key), nil, nil, s3EmptyPayload)
+	if err != nil {
+		return err
+	}
+	return s.do(request, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// presignGet returns a query-signed GET URL. The response-* parameters make
+// S3 send our Content-Disposition and Content-Type instead of its defaults.
+func (s *s3BlobBackend) presignGet(key, contentDisposition, contentType string, ttl time.Duration) (string, error) {
+	if !s.config.PresignDownloads {
+		return "", nil
+	}
+	objectURL, err := s.objectURL(s.objectKey(key))
+	if err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKey+"/"+s.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if contentDisposition != "" {
+		query.Set("response-content-disposition", contentDisposition)
+	}
+	if contentType != "" {
+		query.Set("response-content-type", contentType)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalS3Query(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	objectURL.RawQuery = canonicalS3Query(query)
+	return objectURL.String(), nil
+}
+
+func (s *s3BlobBackend) objectURL(key string) (*url.URL, error) {
+	segments := strings.Split(s.config.Bucket+"/"+s.config.Prefix+key, "/")
+	for i, segment := range segments {
+		segments[i] = s3URIEncode(segment)
+	}
+	return url.Parse(s.config.Endpoint + "/" + strings.Join(segments, "/"))
+}
+
+func (s *s3BlobBackend) newRequest(method, key string, headers http.Header, body io.Reader, payloadHash string) (*http.Request, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(method, objectURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		request.Header[name] = values
+	}
+	s.sign(request, payloadHash)
+	return request, nil
+}
+
+// sign adds SigV4 header authentication covering host and the x-amz headers.
+func (s *s3BlobBackend) sign(request *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		canonicalS3Query(request.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKey, s.credentialScope(now), signedHeaders, s.signature(now, canonicalRequest),
+	))
+}
+
+func (s *s3BlobBackend) credentialScope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+func (s *s3BlobBackend) signature(now time.Time, canonicalRequest string) string {
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format("20060102T150405Z"),
+		s.credentialScope(now),
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s *s3BlobBackend) do(request *http.Request, expected ...int) error {
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	for _, status := range expected {
+		if response.StatusCode == status {
+			return nil
+		}
+	}
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("S3 %s %s: unexpected status %d: %s", request.Method, request.URL.Path, response.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalS3Query sorts and encodes query parameters the way SigV4 expects
+// (RFC 3986, spaces as %20), which url.Values.Encode does not do.
+func canonicalS3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3URIEncode(key)+"="+s3URIEncode(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3URIEncode(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if ('A' <= b && b <= 'Z') || ('a' <= b && b <= 'z') || ('0' <= b && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&builder, "%%%02X", b)
+	}
+	return builder.String()
+}
+
+// s3Object streams an object with ranged GETs so http.ServeContent can seek
+// for Range requests without downloading the whole object first.
+type s3Object struct {
+	backend *s3BlobBackend
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		headers := http.Header{}
+		headers.Set("Range", fmt.Sprintf("bytes=%d-", o.offset))
+		request, err := o.backend.newRequest(http.MethodGet, o.key, headers, nil, s3EmptyPayload)
+		if err != nil {
+			return 0, err
+		}
+		response, err := o.backend.client.Do(request)
+		if err != nil {
+			return 0, err
+		}
+		switch response.StatusCode {
+		case http.StatusPartialContent:
+		case http.StatusOK:
+			// The server ignored Range and sent the whole object, so skip
+			// to the offset rather than returning its first bytes.
+			if _, err := io.CopyN(io.Discard, response.Body, o.offset); err != nil {
+				response.Body.Close()
+				return 0, fmt.Errorf("S3 GET %s: failed to skip to offset %d: %w", o.key, o.offset, err)
+			}
+		default:
+			response.Body.Close()
+			return 0, fmt.Errorf("S3 GET %s: unexpected status %d", o.key, response.StatusCode)
+		}
+		o.body = response.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	next := offset
+	switch whence {
+	case io.SeekCurrent:
+		next = o.offset + offset
+	case io.SeekEnd:
+		next = o.size + offset
+	}
+	if next < 0 {
+		return 0, errors.New("negative seek position")
+	}
+	if next != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = next
+	return next, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
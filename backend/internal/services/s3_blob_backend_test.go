@@ -0,0 +1,318 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal in-memory stand-in for MinIO that checks SigV4 header
+// signatures and payload hashes, and supports ranged GETs and presigned URLs.
+type fakeS3 struct {
+	t           *testing.T
+	signer      *s3BlobBackend
+	objects     map[string][]byte
+	ignoreRange bool
+	mutex       sync.Mutex
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("X-Amz-Signature") == "" && !f.validHeaderSignature(r) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != r.Header.Get("X-Amz-Content-Sha256") {
+			http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+	case http.MethodHead, http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if f.ignoreRange {
+			r.Header.Del("Range")
+		}
+		if disposition := r.URL.Query().Get("response-content-disposition"); disposition != "" {
+			w.Header().Set("Content-Disposition", disposition)
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(string(body)))
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) validHeaderSignature(r *http.Request) bool {
+	amzDate, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		canonicalS3Query(r.URL.Query()),
+		"host:" + r.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + r.Header.Get("X-Amz-Date") + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	expected := fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		s3Algorithm, f.signer.config.AccessKey, f.signer.credentialScope(amzDate), f.signer.signature(amzDate, canonicalRequest))
+	return r.Header.Get("Authorization") == expected
+}
+
+func newTestS3BlobStore(t *testing.T, presign bool) (*ContentBlobStore, *fakeS3) {
+	t.Helper()
+	config, fake := newFakeS3(t)
+	config.PresignDownloads = presign
+	blobs, err := NewS3BlobStore(config, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blobs, fake
+}
+
+// newFakeS3 starts a fakeS3 and returns the config of a store using it.
+func newFakeS3(t *testing.T) (S3Config, *fakeS3) {
+	t.Helper()
+	config := S3Config{
+		Region:    "us-east-1",
+		Bucket:    "clips",
+		AccessKey: "minio",
+		SecretKey: "minio-secret",
+		Prefix:    "blobs/",
+	}
+	fake := &fakeS3{t: t, signer: &s3BlobBackend{config: config}, objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	config.Endpoint = server.URL
+	return config, fake
+}
+
+// s3ObjectPath is the request path of the object holding hash in blobs.
+func s3ObjectPath(blobs *ContentBlobStore, hash string) string {
+	return "/clips/blobs/" + blobs.backend.(*s3BlobBackend).objectKey(blobKey(hash))
+}
+
+func TestS3BlobStoreStoresStreamsAndReleases(t *testing.T) {
+	blobs, fake := newTestS3BlobStore(t, false)
+	content := "0123456789abcdefghij"
+
+	first, _, err := blobs.Put(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := blobs.Put(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectPath := s3ObjectPath(blobs, first)
+	if first != second || len(fake.objects) != 1 || fake.objects[objectPath] == nil {
+		t.Fatalf("expected one deduplicated object at %s, got %d objects", objectPath, len(fake.objects))
+	}
+
+	reader, err := blobs.Open(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Seek(10, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(tail) != content[10:] {
+		t.Fatalf("ranged read returned %q, err=%v", tail, err)
+	}
+
+	if url, err := blobs.DownloadURL(first, "attachment", ""); err != nil || url != "" {
+		t.Fatalf("presigning should be off by default, got %q err=%v", url, err)
+	}
+
+	if err := blobs.Release(first); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.objects) != 1 {
+		t.Fatal("object removed while still referenced")
+	}
+	if err := blobs.Release(second); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.objects) != 0 {
+		t.Fatal("object should be deleted after the last release")
+	}
+}
+
+func TestS3BlobStorePresignsDownloads(t *testing.T) {
+	blobs, _ := newTestS3BlobStore(t, true)
+	hash, _, err := blobs.Put(strings.NewReader("presigned body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := blobs.DownloadURL(hash, `attachment; filename="report.txt"`, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(url, "X-Amz-Signature=") || !strings.Contains(url, "response-content-disposition=") {
+		t.Fatalf("unexpected presigned URL %s", url)
+	}
+
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(body) != "presigned body" {
+		t.Fatalf("presigned GET returned %d %q", response.StatusCode, body)
+	}
+	if got := response.Header.Get("Content-Disposition"); got != `attachment; filename="report.txt"` {
+		t.Fatalf("unexpected Content-Disposition %q", got)
+	}
+}
+
+func TestS3BlobStoreStoresEncryptedBlobs(t *testing.T) {
+	blobs, fake := newTestS3BlobStore(t, false)
+	keys, err := NewKeyRing(newTestMasterKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs.UseKeyRing(keys)
+
+	hash, size, err := blobs.Put(strings.NewReader("quarterly report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := fake.objects[s3ObjectPath(blobs, hash)]
+	if size != int64(len("quarterly report")) || len(stored) <= int(size) || strings.Contains(string(stored), "quarterly") {
+		t.Fatalf("expected the ciphertext in the bucket, got %d bytes for a %d byte upload", len(stored), size)
+	}
+
+	reader, err := blobs.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "quarterly report" {
+		t.Fatalf("expected the plaintext back, got %q", data)
+	}
+}
+
+func TestS3BlobStoresSharingABucketKeepSeparateObjects(t *testing.T) {
+	config, fake := newFakeS3(t)
+	first, err := NewS3BlobStore(config, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewS3BlobStore(config, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, _, err := first.Put(strings.NewReader("same bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := second.Put(strings.NewReader("same bytes")); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.objects) != 2 {
+		t.Fatalf("expected one object per store, got %d", len(fake.objects))
+	}
+
+	if err := first.Release(hash); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := second.Open(hash)
+	if err != nil {
+		t.Fatalf("releasing in one store removed the other's blob: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "same bytes" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func TestS3BlobStoreNeverDeletesObjectsStoredWithoutANamespace(t *testing.T) {
+	config, fake := newFakeS3(t)
+	dataDir := t.TempDir()
+	sum := sha256.Sum256([]byte("old upload"))
+	hash := hex.EncodeToString(sum[:])
+	legacyPath := "/clips/blobs/" + blobKey(hash)
+	fake.objects[legacyPath] = []byte("old upload")
+	if err := os.WriteFile(filepath.Join(dataDir, "blob-refs.json"), []byte(`{"`+hash+`":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := NewS3BlobStore(config, dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := blobs.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(data) != "old upload" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	// Another instance may still use the object, so only the count goes.
+	if err := blobs.Release(hash); err != nil {
+		t.Fatal(err)
+	}
+	if fake.objects[legacyPath] == nil {
+		t.Fatal("object stored without a namespace was deleted")
+	}
+
+	if _, _, err := blobs.Put(strings.NewReader("old upload")); err != nil {
+		t.Fatal(err)
+	}
+	if path := s3ObjectPath(blobs, hash); path == legacyPath || fake.objects[path] == nil {
+		t.Fatalf("expected a new upload under the store's namespace, got %s", path)
+	}
+}
+
+func TestS3BlobStoreSeeksWhenTheServerIgnoresRange(t *testing.T) {
+	blobs, fake := newTestS3BlobStore(t, false)
+	fake.ignoreRange = true
+	hash, _, err := blobs.Put(strings.NewReader("0123456789abcdefghij"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := blobs.Open(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if _, err := reader.Seek(10, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	tail, err := io.ReadAll(reader)
+	if err != nil || string(tail) != "abcdefghij" {
+		t.Fatalf("read after seek returned %q, err=%v", tail, err)
+	}
+}
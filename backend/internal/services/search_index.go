@@ -0,0 +1,160 @@
+package services
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// SearchIndex is an in-memory inverted index from words to the items that
+// contain them, over text content and file names. It is rebuilt from the
+// store at startup and kept current by IndexedClipboardStore.
+type SearchIndex struct {
+	postings map[string]map[string]struct{}   // key: token, then item ID
+	tokens   map[string][]string              // key: item ID, the tokens it was indexed under
+	access   map[string]*models.ClipboardItem // key: item ID; just the fields deciding who sees it and its order
+	mutex    sync.RWMutex
+}
+
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		postings: make(map[string]map[string]struct{}),
+		tokens:   make(map[string][]string),
+		access:   make(map[string]*models.ClipboardItem),
+	}
+}
+
+// Add indexes item, replacing what was indexed for it before. End-to-end
+// encrypted items hold only ciphertext and are left out.
+func (x *SearchIndex) Add(item *models.ClipboardItem) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.removeLocked(item.ID)
+	if models.IsSecretItemType(item.Type) {
+		return
+	}
+	x.access[item.ID] = &models.ClipboardItem{
+		ID:         item.ID,
+		Type:       item.Type,
+		UserID:     item.UserID,
+		Visibility: item.Visibility,
+		SharedWith: slices.Clone(item.SharedWith),
+		CreatedAt:  item.CreatedAt,
+		ExpiresAt:  item.ExpiresAt,
+		Pinned:     item.Pinned,
+	}
+	seen := make(map[string]struct{})
+	for _, token := range append(SearchTokens(item.Content), SearchTokens(item.FileName)...) {
+		if _, duplicate := seen[token]; duplicate {
+			continue
+		}
+		seen[token] = struct{}{}
+		ids := x.postings[token]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			x.postings[token] = ids
+		}
+		ids[item.ID] = struct{}{}
+		x.tokens[item.ID] = append(x.tokens[item.ID], token)
+	}
+}
+
+// Remove drops an item from the index.
+func (x *SearchIndex) Remove(id string) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.removeLocked(id)
+}
+
+func (x *SearchIndex) removeLocked(id string) {
+	for _, token := range x.tokens[id] {
+		delete(x.postings[token], id)
+		if len(x.postings[token]) == 0 {
+			delete(x.postings, token)
+		}
+	}
+	delete(x.tokens, id)
+	delete(x.access, id)
+}
+
+// Search returns the IDs of the unexpired items user may see that have,
+// for every token of query, an indexed token starting with it, newest
+// first. Tokens do not keep word order, so callers check the candidates
+// against the query themselves.
+func (x *SearchIndex) Search(query string, user *models.User, now time.Time) []string {
+	terms := SearchTokens(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	var candidates map[string]struct{}
+	for _, term := range terms {
+		matches := make(map[string]struct{})
+		for token, ids := range x.postings {
+			if !strings.HasPrefix(token, term) {
+				continue
+			}
+			for id := range ids {
+				if _, ok := candidates[id]; ok {
+					matches[id] = struct{}{}
+				} else if candidates == nil && x.visible(id, user, now) {
+					matches[id] = struct{}{}
+				}
+			}
+		}
+		candidates = matches
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return x.access[ids[i]].CreatedAt.After(x.access[ids[j]].CreatedAt)
+	})
+	return ids
+}
+
+func (x *SearchIndex) visible(id string, user *models.User, now time.Time) bool {
+	item := x.access[id]
+	return item != nil && !models.ClipboardItemExpired(item, now) && models.CanViewItem(item, user)
+}
+
+// SearchTokens splits text into lower-case words. Chinese, Japanese and
+// Korean text is not separated by spaces, so each of those characters is a
+// token of its own.
+func SearchTokens(text string) []string {
+	tokens := make([]string, 0)
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestSearchTokensSplitWordsAndCJKCharacters(t *testing.T) {
+	got := SearchTokens("SELECT users.email, 数据库 v2")
+	want := []string{"select", "users", "email", "数", "据", "库", "v2"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIndexedClipboardStoreKeepsTheIndexCurrent(t *testing.T) {
+	inner, err := NewFileClipboardStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	inner.Put(&models.ClipboardItem{ID: "old1", Type: "text", UserID: "user-1", Content: "existing query", CreatedAt: now})
+	index := NewSearchIndex()
+	store := NewIndexedClipboardStore(inner, index)
+
+	store.Put(&models.ClipboardItem{ID: "sql1", Type: "text", UserID: "user-1", Content: "SELECT email FROM users", CreatedAt: now})
+	store.Put(&models.ClipboardItem{ID: "doc1", Type: "file", UserID: "user-1", FileName: "Quarterly-Report.pdf", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)})
+	store.Put(&models.ClipboardItem{ID: "otp1", Type: "text", UserID: "user-1", Content: "query code 1234", MaxReads: 1, CreatedAt: now})
+	store.Put(&models.ClipboardItem{ID: "sec1", Type: "secret-text", UserID: "user-1", FileSize: 10, CreatedAt: now})
+
+	owner := &models.User{ID: "user-1", Role: "user"}
+	search := func(query string) []string {
+		// Searched just before doc1 expires, so it still shows.
+		ids := index.Search(query, owner, now.Add(-2*time.Minute))
+		slices.Sort(ids)
+		return ids
+	}
+	if got := search("quer"); !slices.Equal(got, []string{"old1", "otp1"}) {
+		t.Fatalf("expected prefix matches of existing and new items, got %v", got)
+	}
+	if got := search("users sel"); !slices.Equal(got, []string{"sql1"}) {
+		t.Fatalf("expected every word to be required, got %v", got)
+	}
+	if got := search("report"); !slices.Equal(got, []string{"doc1"}) {
+		t.Fatalf("expected file names to be indexed, got %v", got)
+	}
+
+	store.Update("sql1", func(item *models.ClipboardItem) error {
+		item.Content = "DELETE FROM sessions"
+		return nil
+	})
+	store.ConsumeRead("otp1")
+	store.Delete("old1")
+	store.ExpireBefore(now)
+	for _, query := range []string{"users", "query", "report"} {
+		if got := search(query); len(got) != 0 {
+			t.Fatalf("expected %q to match nothing after the changes, got %v", query, got)
+		}
+	}
+	if got := search("sessions"); !slices.Equal(got, []string{"sql1"}) {
+		t.Fatalf("expected edited content to be indexed, got %v", got)
+	}
+}
+
+func TestSearchIndexOnlyReturnsItemsTheUserMaySeeNewestFirst(t *testing.T) {
+	index := NewSearchIndex()
+	now := time.Now().UTC()
+	for i, item := range []*models.ClipboardItem{
+		{ID: "own1", UserID: "alice", Visibility: models.VisibilityPrivate},
+		{ID: "priv", UserID: "bob", Visibility: models.VisibilityPrivate},
+		{ID: "shar", UserID: "bob", Visibility: models.VisibilityUsers, SharedWith: []string{"alice"}},
+		{ID: "publ", UserID: "carol", Visibility: models.VisibilityEveryone},
+		{ID: "gone", UserID: "alice", ExpiresAt: now.Add(-time.Minute)},
+	} {
+		item.Type = "text"
+		item.Content = "release notes"
+		item.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		index.Add(item)
+	}
+
+	alice := &models.User{ID: "alice", Role: "user"}
+	if got := index.Search("notes", alice, now); !slices.Equal(got, []string{"publ", "shar", "own1"}) {
+		t.Fatalf("expected the visible items newest first, got %v", got)
+	}
+	admin := &models.User{ID: "root", Role: "admin"}
+	if got := index.Search("release", admin, now); !slices.Equal(got, []string{"publ", "shar", "priv", "own1"}) {
+		t.Fatalf("expected admins to find every unexpired item, got %v", got)
+	}
+}
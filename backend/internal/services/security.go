@@ -121,6 +121,12 @@ func (s *SecurityService) LogAccess(c interface{}, id, itemType string, success
 	log.Printf("[%s] %s accessed %s %s: %s", timestamp.Format(time.RFC3339), ip, itemType, id, status)
 }
 
+// RecordFailedAttempt counts a failure by the client behind c, such as a
+// wrong item password, towards blocking it.
+func (s *SecurityService) RecordFailedAttempt(c interface{}, reason string) {
+	s.recordFailedAttempt(s.GetClientIP(c), reason)
+}
+
 func (s *SecurityService) GetClientIP(c interface{}) string {
 	ctx, ok := c.(*gin.Context)
 	if !ok {
@@ -214,6 +220,10 @@ func (r *RateLimitService) IsAllowed(ipAddress, endpoint string) bool {
 		limit = 20
 	case "GET":
 		limit = 100
+	case models.ShareRateLimitEndpoint:
+		limit = 30
+	case models.ItemPasswordRateLimitEndpoint:
+		limit = 10
 	}
 
 	return r.ipLimits[key].Count <= limit
@@ -13,9 +13,17 @@ import (
 	"web-clipboard-go/backend/internal/models"
 )
 
+// settingsFile is the on-disk layout of settings.json. The schema version is
+// kept out of models.SystemSettings so it never appears in API responses.
+type settingsFile struct {
+	SchemaVersion int `json:"schemaVersion"`
+	models.SystemSettings
+}
+
 type SettingsService struct {
 	settings models.SystemSettings
 	filePath string
+	storage  *BoltStorage // when set, settings are persisted in the database instead of filePath
 	mutex    sync.RWMutex
 }
 
@@ -33,6 +41,18 @@ func NewSettingsService(dataDir string) (*SettingsService, error) {
 	return service, nil
 }
 
+// NewBoltSettingsService creates a settings service backed by the embedded database.
+func NewBoltSettingsService(storage *BoltStorage) (*SettingsService, error) {
+	service := &SettingsService{
+		settings: models.DefaultSystemSettings(),
+		storage:  storage,
+	}
+	if err := service.loadSettings(); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
 func (s *SettingsService) GetSettings() models.SystemSettings {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -63,17 +83,12 @@ func (s *SettingsService) SaveSettings(settings models.SystemSettings) error {
 }
 
 func (s *SettingsService) loadSettings() error {
-	data, err := os.ReadFile(s.filePath)
+	settings, found, err := s.readSettings()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return s.writeSettings(s.settings)
-		}
-		return fmt.Errorf("failed to read settings file: %w", err)
+		return err
 	}
-
-	settings := models.DefaultSystemSettings()
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return fmt.Errorf("failed to parse settings file: %w", err)
+	if !found {
+		return s.writeSettings(s.settings)
 	}
 	settings = normalizeLoadedSettings(settings)
 	if err := validateSettings(settings); err != nil {
@@ -86,12 +101,44 @@ func (s *SettingsService) loadSettings() error {
 	return nil
 }
 
+// readSettings returns the persisted settings and whether any were found.
+func (s *SettingsService) readSettings() (models.SystemSettings, bool, error) {
+	if s.storage != nil {
+		return s.storage.loadSettings()
+	}
+
+	file := settingsFile{SystemSettings: models.DefaultSystemSettings()}
+	data, migrated, err := readDataFile(s.filePath, settingsFileSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file.SystemSettings, false, nil
+		}
+		return file.SystemSettings, false, fmt.Errorf("failed to read settings file: %w", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file.SystemSettings, false, fmt.Errorf("failed to parse settings file: %w", err)
+	}
+	if migrated {
+		if err := s.writeSettings(file.SystemSettings); err != nil {
+			return file.SystemSettings, false, err
+		}
+	}
+	return file.SystemSettings, true, nil
+}
+
 func (s *SettingsService) writeSettings(settings models.SystemSettings) error {
-	data, err := json.MarshalIndent(settings, "", "  ")
+	if s.storage != nil {
+		return s.storage.saveSettings(settings)
+	}
+
+	data, err := json.MarshalIndent(settingsFile{
+		SchemaVersion:  settingsFileSchema.version,
+		SystemSettings: settings,
+	}, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal settings: %w", err)
 	}
-	if err := os.WriteFile(s.filePath, data, 0644); err != nil {
+	if err := writeFileAtomic(s.filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write settings file: %w", err)
 	}
 	return nil
@@ -139,6 +186,12 @@ func validateSettings(settings models.SystemSettings) error {
 	if err := validateClipboardSettings(settings.Clipboard); err != nil {
 		return err
 	}
+	if err := validateQuotaSettings(settings.Quotas); err != nil {
+		return err
+	}
+	if settings.Retention.Admin.MaxMinutes < 0 || settings.Retention.User.MaxMinutes < 0 {
+		return errors.New("retention limits cannot be negative")
+	}
 	if !hasAvailableLogin(settings.Auth) {
 		return errors.New("at least one login method must be available")
 	}
@@ -152,10 +205,24 @@ func validateClipboardSettings(settings models.ClipboardSettings) error {
 			return errors.New("clipboard expiration value must be greater than zero")
 		}
 	case models.ClipboardExpirationUnitNever:
-		return nil
 	default:
 		return errors.New("clipboard expiration unit is invalid")
 	}
+	if settings.MaxPinnedItems < 0 {
+		return errors.New("pinned item limit cannot be negative")
+	}
+	return nil
+}
+
+func validateQuotaSettings(settings models.QuotaSettings) error {
+	for _, limits := range []models.QuotaLimits{settings.Admin, settings.User} {
+		if limits.MaxBytes < 0 || limits.MaxItems < 0 {
+			return errors.New("quota limits cannot be negative")
+		}
+	}
+	if settings.GlobalMaxBytes < 0 {
+		return errors.New("global storage cap cannot be negative")
+	}
 	return nil
 }
 
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// shareTokenBytes is the entropy of a share token; links must not be
+// guessable the way four-character item IDs are.
+const shareTokenBytes = 32
+
+// FileShareStore keeps share links in memory and mirrors them to
+// shares.json in the data directory so they survive restarts.
+type FileShareStore struct {
+	shares   map[string]*models.Share // key: token
+	filePath string
+	mutex    sync.RWMutex
+}
+
+func NewFileShareStore(dataDir string) (*FileShareStore, error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	store := &FileShareStore{
+		shares:   make(map[string]*models.Share),
+		filePath: filepath.Join(dataDir, "shares.json"),
+	}
+	if err := store.loadShares(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Create assigns a random token and creation time and stores the share.
+func (s *FileShareStore) Create(share *models.Share) error {
+	tokenBytes := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return fmt.Errorf("failed to generate share token: %w", err)
+	}
+	share.Token = base64.RawURLEncoding.EncodeToString(tokenBytes)
+	share.CreatedAt = time.Now().UTC()
+	share.DownloadCount = 0
+	stored := *share
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.shares[stored.Token] = &stored
+	if err := s.saveSharesLocked(); err != nil {
+		delete(s.shares, stored.Token)
+		return err
+	}
+	return nil
+}
+
+// Get returns a copy of the share with the given token.
+func (s *FileShareStore) Get(token string) (*models.Share, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	share, exists := s.shares[token]
+	if !exists {
+		return nil, false
+	}
+	clone := *share
+	return &clone, true
+}
+
+// ListByItem returns copies of every share of the item.
+func (s *FileShareStore) ListByItem(itemID string) []*models.Share {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	shares := make([]*models.Share, 0)
+	for _, share := range s.shares {
+		if share.ItemID == itemID {
+			clone := *share
+			shares = append(shares, &clone)
+		}
+	}
+	return shares
+}
+
+// Delete removes a share.
+func (s *FileShareStore) Delete(token string) error {
+	return s.deleteWhere(func(share *models.Share) bool { return share.Token == token })
+}
+
+// DeleteByItem removes every share of the item.
+func (s *FileShareStore) DeleteByItem(itemID string) error {
+	return s.deleteWhere(func(share *models.Share) bool { return share.ItemID == itemID })
+}
+
+// ConsumeDownload counts one download of a share, deleting it when its
+// download limit is reached.
+func (s *FileShareStore) ConsumeDownload(token string) (*models.Share, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	share, exists := s.shares[token]
+	if !exists {
+		return nil, nil
+	}
+	if share.MaxDownloads <= 0 {
+		clone := *share
+		return &clone, nil
+	}
+
+	previous := *share
+	share.DownloadCount++
+	if share.DownloadCount >= share.MaxDownloads {
+		delete(s.shares, token)
+	}
+	if err := s.saveSharesLocked(); err != nil {
+		// Rollback
+		s.shares[token] = &previous
+		return nil, err
+	}
+	clone := *share
+	return &clone, nil
+}
+
+// ReturnDownload gives back one download counted by ConsumeDownload,
+// restoring the share if that download had used it up.
+func (s *FileShareStore) ReturnDownload(consumed *models.Share) error {
+	if consumed.MaxDownloads <= 0 {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	share, exists := s.shares[consumed.Token]
+	switch {
+	case exists:
+		previous := *share
+		share.DownloadCount = max(0, share.DownloadCount-1)
+		if err := s.saveSharesLocked(); err != nil {
+			// Rollback
+			*share = previous
+			return err
+		}
+	case consumed.DownloadCount >= consumed.MaxDownloads:
+		restored := *consumed
+		restored.DownloadCount--
+		s.shares[consumed.Token] = &restored
+		if err := s.saveSharesLocked(); err != nil {
+			// Rollback
+			delete(s.shares, consumed.Token)
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireBefore removes every share that has expired at now and returns how
+// many were removed.
+func (s *FileShareStore) ExpireBefore(now time.Time) (int, error) {
+	removed := 0
+	err := s.deleteWhere(func(share *models.Share) bool {
+		if models.ShareExpired(share, now) {
+			removed++
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// DeleteForMissingItems removes the shares of items that no longer exist,
+// which a crash between deleting an item and its shares leaves behind.
+func (s *FileShareStore) DeleteForMissingItems(exists func(itemID string) bool) (int, error) {
+	removed := 0
+	err := s.deleteWhere(func(share *models.Share) bool {
+		if !exists(share.ItemID) {
+			removed++
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+func (s *FileShareStore) deleteWhere(match func(share *models.Share) bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removed := make([]*models.Share, 0)
+	for token, share := range s.shares {
+		if match(share) {
+			removed = append(removed, share)
+			delete(s.shares, token)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := s.saveSharesLocked(); err != nil {
+		for _, share := range removed {
+			s.shares[share.Token] = share
+		}
+		return err
+	}
+	return nil
+}
+
+// loadShares loads shares from the JSON file.
+func (s *FileShareStore) loadShares() error {
+	data, migrated, err := readDataFile(s.filePath, sharesFileSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read shares file: %w", err)
+	}
+
+	var shareData models.ShareData
+	if err := json.Unmarshal(data, &shareData); err != nil {
+		return fmt.Errorf("failed to parse shares file: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range shareData.Shares {
+		share := &shareData.Shares[i]
+		s.shares[share.Token] = share
+	}
+	if migrated {
+		return s.saveSharesLocked()
+	}
+	return nil
+}
+
+// saveSharesLocked writes all shares to the JSON file. Callers must hold the
+// write lock.
+func (s *FileShareStore) saveSharesLocked() error {
+	sharesList := make([]models.Share, 0, len(s.shares))
+	for _, share := range s.shares {
+		sharesList = append(sharesList, *share)
+	}
+
+	data, err := json.MarshalIndent(models.ShareData{
+		SchemaVersion: sharesFileSchema.version,
+		Shares:        sharesList,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shares: %w", err)
+	}
+	if err := writeFileAtomic(s.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write shares file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestFileShareStoreRevokesLinksAtTheirDownloadLimit(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileShareStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := &models.Share{ItemID: "abcd", UserID: "user-1", MaxDownloads: 2}
+	if err := store.Create(limited); err != nil {
+		t.Fatal(err)
+	}
+	if len(limited.Token) < 40 {
+		t.Fatalf("expected a long random token, got %q", limited.Token)
+	}
+	expiring := &models.Share{ItemID: "abcd", UserID: "user-1", ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	if err := store.Create(expiring); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counts survive a restart.
+	if share, err := store.ConsumeDownload(limited.Token); err != nil || share == nil || share.DownloadCount != 1 {
+		t.Fatalf("expected the first download to be counted, got %#v, %v", share, err)
+	}
+	store, err = NewFileShareStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share, err := store.ConsumeDownload(limited.Token); err != nil || share == nil || share.DownloadCount != 2 {
+		t.Fatalf("expected the last download to be served, got %#v, %v", share, err)
+	}
+	if share, err := store.ConsumeDownload(limited.Token); err != nil || share != nil {
+		t.Fatalf("expected the used-up link to be gone, got %#v, %v", share, err)
+	}
+
+	if removed, err := store.ExpireBefore(time.Now().UTC().Add(2 * time.Hour)); err != nil || removed != 1 {
+		t.Fatalf("expected one expired link, got %d, %v", removed, err)
+	}
+	if shares := store.ListByItem("abcd"); len(shares) != 0 {
+		t.Fatalf("expected no links left, got %d", len(shares))
+	}
+}
+
+func TestFileShareStoreReturnsDownloadsThatServedNothing(t *testing.T) {
+	store, err := NewFileShareStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := &models.Share{ItemID: "abcd", UserID: "user-1", MaxDownloads: 1}
+	if err := store.Create(limited); err != nil {
+		t.Fatal(err)
+	}
+
+	consumed, err := store.ConsumeDownload(limited.Token)
+	if err != nil || consumed == nil {
+		t.Fatalf("expected the download to be counted, got %#v, %v", consumed, err)
+	}
+	if _, exists := store.Get(limited.Token); exists {
+		t.Fatal("expected the used-up link to be gone")
+	}
+	if err := store.ReturnDownload(consumed); err != nil {
+		t.Fatal(err)
+	}
+	if share, exists := store.Get(limited.Token); !exists || share.DownloadCount != 0 {
+		t.Fatalf("expected the link back with its download unused, got %#v", share)
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+// ResumableUploadTTL is how long an upload may sit idle before the cleanup
+// ticker discards it. Every appended chunk extends it.
+const ResumableUploadTTL = 24 * time.Hour
+
+// ResumableUploadStore keeps tus uploads in dir as an <id>.json info file
+// plus an <id>.part data file, so uploads survive a restart.
+type ResumableUploadStore struct {
+	dir      string
+	active   map[string]bool  // uploads with a write in progress
+	reserved map[string]int64 // key: user ID; lengths of unfinished uploads
+	mutex    sync.Mutex
+}
+
+func NewResumableUploadStore(dir string) (*ResumableUploadStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	store := &ResumableUploadStore{dir: dir, active: make(map[string]bool), reserved: make(map[string]int64)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uploads: %w", err)
+	}
+	for _, entry := range entries {
+		id, isInfo := strings.CutSuffix(entry.Name(), ".json")
+		if !isInfo || !validUploadID(id) {
+			continue
+		}
+		if upload, err := store.loadInfo(id); err == nil && upload.ItemID == "" {
+			store.reserve(upload.UserID, upload.Length)
+		}
+	}
+	return store, nil
+}
+
+// Create assigns an ID and expiry and creates the empty data file.
+func (s *ResumableUploadStore) Create(upload *models.Upload) error {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return fmt.Errorf("failed to generate upload id: %w", err)
+	}
+	upload.ID = hex.EncodeToString(idBytes)
+	upload.Offset = 0
+	upload.CreatedAt = time.Now().UTC()
+	upload.ExpiresAt = upload.CreatedAt.Add(ResumableUploadTTL)
+
+	file, err := os.OpenFile(s.dataPath(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create upload file: %w", err)
+	}
+	file.Close()
+	if err := s.saveInfo(upload); err != nil {
+		os.Remove(s.dataPath(upload.ID))
+		return err
+	}
+	s.reserve(upload.UserID, upload.Length)
+	return nil
+}
+
+// Get returns the upload with the given ID.
+func (s *ResumableUploadStore) Get(id string) (*models.Upload, bool) {
+	upload, err := s.loadInfo(id)
+	if err != nil {
+		return nil, false
+	}
+	return upload, true
+}
+
+// Append writes r to the upload at offset and records how many bytes made it
+// to disk, even when r fails part way through.
+func (s *ResumableUploadStore) Append(id string, offset int64, r io.Reader) (*models.Upload, error) {
+	if !s.acquire(id) {
+		return nil, models.ErrUploadLocked
+	}
+	defer s.release(id)
+
+	upload, err := s.loadInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset || upload.ItemID != "" {
+		return upload, models.ErrUploadOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.dataPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	// Drop anything past the recorded offset, e.g. from a crash mid-chunk.
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate upload file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek upload file: %w", err)
+	}
+	written, copyErr := io.Copy(file, io.LimitReader(r, upload.Length-offset))
+	if err := file.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if err := file.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+
+	upload.Offset += written
+	upload.ExpiresAt = time.Now().UTC().Add(ResumableUploadTTL)
+	if err := s.saveInfo(upload); err != nil {
+		return nil, err
+	}
+	return upload, copyErr
+}
+
+// Open returns the uploaded bytes for assembly.
+func (s *ResumableUploadStore) Open(id string) (io.ReadSeekCloser, error) {
+	if !validUploadID(id) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(s.dataPath(id))
+}
+
+// Complete records the clipboard item the upload became and removes the
+// data file. The info file stays until expiry so clients can look up the
+// item. Completing an upload twice fails with ErrUploadOffsetMismatch.
+func (s *ResumableUploadStore) Complete(id, itemID string) error {
+	if !s.acquire(id) {
+		return models.ErrUploadLocked
+	}
+	defer s.release(id)
+
+	upload, err := s.loadInfo(id)
+	if err != nil {
+		return err
+	}
+	if upload.ItemID != "" || upload.Offset != upload.Length {
+		return models.ErrUploadOffsetMismatch
+	}
+	upload.ItemID = itemID
+	if err := s.saveInfo(upload); err != nil {
+		return err
+	}
+	s.reserve(upload.UserID, -upload.Length)
+	if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove upload file: %w", err)
+	}
+	return nil
+}
+
+// Delete terminates an upload and discards its data.
+func (s *ResumableUploadStore) Delete(id string) error {
+	if !s.acquire(id) {
+		return models.ErrUploadLocked
+	}
+	defer s.release(id)
+	return s.remove(id)
+}
+
+// ExpireBefore removes uploads that have been idle past their expiry.
+func (s *ResumableUploadStore) ExpireBefore(now time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list uploads: %w", err)
+	}
+	removed := 0
+	for _, entry := range entries {
+		id, isInfo := strings.CutSuffix(entry.Name(), ".json")
+		if !isInfo || !validUploadID(id) || !s.acquire(id) {
+			continue
+		}
+		upload, err := s.loadInfo(id)
+		if err == nil && upload.ExpiresAt.Before(now) {
+			if err := s.remove(id); err == nil {
+				removed++
+			}
+		}
+		s.release(id)
+	}
+	return removed, nil
+}
+
+// Reserved returns the total length of the uploads by userID, or by
+// everyone when userID is empty, that have yet to become items, which is
+// the space they will take once they do. The totals are kept in memory, so
+// quota checks do not read the upload directory.
+func (s *ResumableUploadStore) Reserved(userID string) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if userID != "" {
+		return s.reserved[userID]
+	}
+	var total int64
+	for _, length := range s.reserved {
+		total += length
+	}
+	return total
+}
+
+func (s *ResumableUploadStore) reserve(userID string, length int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reserved[userID] += length
+	if s.reserved[userID] <= 0 {
+		delete(s.reserved, userID)
+	}
+}
+
+func (s *ResumableUploadStore) acquire(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.active[id] {
+		return false
+	}
+	s.active[id] = true
+	return true
+}
+
+func (s *ResumableUploadStore) release(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.active, id)
+}
+
+func (s *ResumableUploadStore) loadInfo(id string) (*models.Upload, error) {
+	if !validUploadID(id) {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var upload models.Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse upload info: %w", err)
+	}
+	return &upload, nil
+}
+
+func (s *ResumableUploadStore) saveInfo(upload *models.Upload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal upload info: %w", err)
+	}
+	if err := writeFileAtomic(s.infoPath(upload.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write upload info: %w", err)
+	}
+	return nil
+}
+
+func (s *ResumableUploadStore) remove(id string) error {
+	if !validUploadID(id) {
+		return os.ErrNotExist
+	}
+	// An upload whose info is missing or unreadable reserves nothing.
+	upload, _ := s.loadInfo(id)
+	for _, path := range []string{s.dataPath(id), s.infoPath(id), backupPath(s.infoPath(id))} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove upload: %w", err)
+		}
+	}
+	if upload != nil && upload.ItemID == "" {
+		s.reserve(upload.UserID, -upload.Length)
+	}
+	return nil
+}
+
+func (s *ResumableUploadStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".part")
+}
+
+func (s *ResumableUploadStore) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func validUploadID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
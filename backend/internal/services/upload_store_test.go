@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"web-clipboard-go/backend/internal/models"
+)
+
+func TestResumableUploadStoreResumesAfterInterruptedChunk(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewResumableUploadStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := &models.Upload{UserID: "user-1", Length: 10, FileName: "notes.txt"}
+	if err := store.Create(upload); err != nil {
+		t.Fatal(err)
+	}
+
+	// The connection drops after four bytes of the chunk arrived.
+	interrupted := io.MultiReader(strings.NewReader("0123"), iotest.ErrReader(errors.New("connection reset")))
+	progress, err := store.Append(upload.ID, 0, interrupted)
+	if err == nil || progress == nil || progress.Offset != 4 {
+		t.Fatalf("expected partial progress at offset 4, got %#v err=%v", progress, err)
+	}
+
+	if _, err := store.Append(upload.ID, 0, strings.NewReader("0123456789")); !errors.Is(err, models.ErrUploadOffsetMismatch) {
+		t.Fatalf("expected offset mismatch, got %v", err)
+	}
+
+	// A restarted server picks the upload up where it left off.
+	reopened, err := NewResumableUploadStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, err = reopened.Append(upload.ID, 4, strings.NewReader("456789"))
+	if err != nil || progress.Offset != 10 {
+		t.Fatalf("expected completed upload, got %#v err=%v", progress, err)
+	}
+	content, err := reopened.Open(upload.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "0123456789" {
+		t.Fatalf("unexpected assembled data %q", data)
+	}
+
+	if err := reopened.Complete(upload.ID, "abcd"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Complete(upload.ID, "efgh"); !errors.Is(err, models.ErrUploadOffsetMismatch) {
+		t.Fatalf("completing twice should fail, got %v", err)
+	}
+	if stored, _ := reopened.Get(upload.ID); stored.ItemID != "abcd" {
+		t.Fatalf("completed upload should remember its item, got %#v", stored)
+	}
+}
+
+func TestResumableUploadStoreExpiresIdleUploads(t *testing.T) {
+	store, err := NewResumableUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := &models.Upload{UserID: "user-1", Length: 5, FileName: "a.txt"}
+	if err := store.Create(upload); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, err := store.ExpireBefore(time.Now().UTC()); err != nil || removed != 0 {
+		t.Fatalf("fresh upload should survive cleanup, removed=%d err=%v", removed, err)
+	}
+	if removed, err := store.ExpireBefore(time.Now().UTC().Add(ResumableUploadTTL + time.Minute)); err != nil || removed != 1 {
+		t.Fatalf("idle upload should be removed, removed=%d err=%v", removed, err)
+	}
+	if _, exists := store.Get(upload.ID); exists {
+		t.Fatal("expired upload still present")
+	}
+}
+
+func TestResumableUploadStoreReservesUnfinishedUploads(t *testing.T) {
+	store, err := NewResumableUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := &models.Upload{UserID: "user-1", Length: 3, FileName: "a.txt"}
+	for _, upload := range []*models.Upload{
+		{UserID: "user-1", Length: 5, FileName: "b.txt"},
+		{UserID: "user-2", Length: 7, FileName: "c.txt"},
+		finished,
+	} {
+		if err := store.Create(upload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Append(finished.ID, 0, strings.NewReader("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(finished.ID, "abcd"); err != nil {
+		t.Fatal(err)
+	}
+
+	if reserved := store.Reserved("user-1"); reserved != 5 {
+		t.Fatalf("expected 5 bytes reserved for user-1, got %d", reserved)
+	}
+	if reserved := store.Reserved(""); reserved != 12 {
+		t.Fatalf("expected 12 bytes reserved in all, got %d", reserved)
+	}
+
+	// The totals are rebuilt from the info files on restart.
+	reopened, err := NewResumableUploadStore(store.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reserved := reopened.Reserved(""); reserved != 12 {
+		t.Fatalf("expected 12 bytes reserved after a restart, got %d", reserved)
+	}
+
+	if removed, err := reopened.ExpireBefore(time.Now().UTC().Add(ResumableUploadTTL + time.Minute)); err != nil || removed != 3 {
+		t.Fatalf("expected all uploads to expire, removed=%d err=%v", removed, err)
+	}
+	if reserved := reopened.Reserved(""); reserved != 0 {
+		t.Fatalf("expected expired uploads to reserve nothing, got %d", reserved)
+	}
+}
@@ -23,6 +23,7 @@ type ExternalIdentity = models.ExternalIdentity
 type UserManager struct {
 	users    map[string]*models.User // key: user ID
 	filePath string
+	storage  *BoltStorage // when set, users are persisted in the database instead of filePath
 	mutex    sync.RWMutex
 }
 
@@ -36,25 +37,56 @@ func NewUserManager(dataDir string) (*UserManager, error) {
 		users:    make(map[string]*models.User),
 		filePath: filepath.Join(dataDir, "users.json"),
 	}
+	if err := um.initialize(); err != nil {
+		return nil, err
+	}
+	return um, nil
+}
+
+// NewBoltUserManager creates a user manager backed by the embedded database.
+func NewBoltUserManager(storage *BoltStorage) (*UserManager, error) {
+	um := &UserManager{
+		users:   make(map[string]*models.User),
+		storage: storage,
+	}
+	if err := um.initialize(); err != nil {
+		return nil, err
+	}
+	return um, nil
+}
 
+func (um *UserManager) initialize() error {
 	// Load existing users or create default admin
 	if err := um.loadUsers(); err != nil {
-		return nil, err
+		return err
 	}
 
 	// Create default admin if no users exist
 	if len(um.users) == 0 {
 		if err := um.createDefaultAdmin(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return um, nil
+	return nil
 }
 
-// loadUsers loads users from JSON file
+// loadUsers loads users from the database or JSON file
 func (um *UserManager) loadUsers() error {
-	data, err := os.ReadFile(um.filePath)
+	if um.storage != nil {
+		users, err := um.storage.loadUsers()
+		if err != nil {
+			return err
+		}
+		um.mutex.Lock()
+		defer um.mutex.Unlock()
+		for i := range users {
+			um.users[users[i].ID] = &users[i]
+		}
+		return nil
+	}
+
+	data, migrated, err := readDataFile(um.filePath, usersFileSchema)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// File doesn't exist, start with empty users
@@ -69,13 +101,15 @@ func (um *UserManager) loadUsers() error {
 	}
 
 	um.mutex.Lock()
-	defer um.mutex.Unlock()
-
 	for i := range usersData.Users {
 		user := &usersData.Users[i]
 		um.users[user.ID] = user
 	}
+	um.mutex.Unlock()
 
+	if migrated {
+		return um.saveUsers()
+	}
 	return nil
 }
 
@@ -88,19 +122,39 @@ func (um *UserManager) saveUsers() error {
 	}
 	um.mutex.RUnlock()
 
-	usersData := models.UsersData{Users: usersList}
+	usersData := models.UsersData{SchemaVersion: usersFileSchema.version, Users: usersList}
 	data, err := json.MarshalIndent(usersData, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal users: %w", err)
 	}
 
-	if err := os.WriteFile(um.filePath, data, 0644); err != nil {
+	if err := writeFileAtomic(um.filePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write users file: %w", err)
 	}
 
 	return nil
 }
 
+// persistUser saves a changed user. The database backend writes just that
+// user in one transaction; the JSON backend rewrites users.json.
+func (um *UserManager) persistUser(user *models.User) error {
+	if um.storage == nil {
+		return um.saveUsers()
+	}
+	um.mutex.RLock()
+	snapshot := *user
+	um.mutex.RUnlock()
+	return um.storage.putUser(snapshot)
+}
+
+// persistUserDeletion removes a deleted user from the configured backend.
+func (um *UserManager) persistUserDeletion(id string) error {
+	if um.storage == nil {
+		return um.saveUsers()
+	}
+	return um.storage.deleteUser(id)
+}
+
 // createDefaultAdmin creates the default admin account
 func (um *UserManager) createDefaultAdmin() error {
 	initialPassword, err := generateInitialAdminPassword()
@@ -127,7 +181,7 @@ func (um *UserManager) createDefaultAdmin() error {
 	um.users[admin.ID] = admin
 	um.mutex.Unlock()
 
-	if err := um.saveUsers(); err != nil {
+	if err := um.persistUser(admin); err != nil {
 		return err
 	}
 
@@ -201,7 +255,7 @@ func (um *UserManager) CreateUser(username, password, email, role string) (*mode
 	um.users[user.ID] = user
 	um.mutex.Unlock()
 
-	if err := um.saveUsers(); err != nil {
+	if err := um.persistUser(user); err != nil {
 		// Rollback
 		um.mutex.Lock()
 		delete(um.users, user.ID)
@@ -247,7 +301,7 @@ func (um *UserManager) CreateExternalUser(identity ExternalIdentity, role string
 	um.users[user.ID] = user
 	um.mutex.Unlock()
 
-	if err := um.saveUsers(); err != nil {
+	if err := um.persistUser(user); err != nil {
 		um.mutex.Lock()
 		delete(um.users, user.ID)
 		um.mutex.Unlock()
@@ -286,7 +340,7 @@ func (um *UserManager) LinkExternalIdentity(userID string, identity ExternalIden
 	user.UpdatedAt = identity.LinkedAt
 	um.mutex.Unlock()
 
-	if err := um.saveUsers(); err != nil {
+	if err := um.persistUser(user); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -416,7 +470,7 @@ func (um *UserManager) UpdateUser(id string, email, role string, isActive *bool)
 	user.UpdatedAt = time.Now().UTC()
 	um.mutex.Unlock()
 
-	if err := um.saveUsers(); err != nil {
+	if err := um.persistUser(user); err != nil {
 		return nil, err
 	}
 
@@ -446,7 +500,7 @@ func (um *UserManager) ChangePassword(id, newPassword string) error {
 	user.UpdatedAt = time.Now().UTC()
 	um.mutex.Unlock()
 
-	return um.saveUsers()
+	return um.persistUser(user)
 }
 
 // DeleteUser deletes a user
@@ -475,7 +529,7 @@ func (um *UserManager) DeleteUser(id string) error {
 	delete(um.users, id)
 	um.mutex.Unlock()
 
-	return um.saveUsers()
+	return um.persistUserDeletion(id)
 }
 
 // ValidateCredentials validates username and password
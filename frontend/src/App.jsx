@@ -1,19 +1,50 @@
 import React, { useEffect, useMemo, useState } from 'react';
 import {
+    Clock,
     Copy,
     Download,
+    Eye,
     FileIcon,
     FileText,
+    Files,
+    Flame,
+    Folder,
     FolderOpen,
+    FolderUp,
+    History,
     Image as ImageIcon,
+    KeyRound,
+    Link as LinkIcon,
+    Lock,
+    Pencil,
+    Pin,
+    PinOff,
     Save,
+    Search,
+    Send,
+    Share2,
+    Tag,
     Upload,
+    Users,
     X
 } from 'lucide-react';
 import { AccountMenu } from './account.jsx';
 import { Auth } from './auth.js';
+import { BundleFilesModal, bundleZipUrl } from './bundle.jsx';
+import { CollectionsModal } from './collections.jsx';
+import { TextEditorModal } from './editor.jsx';
 import { i18n } from './i18n.js';
 import { IconLabel, StatusMessage, useMessage } from './shared.jsx';
+import { ShareModal } from './shares.jsx';
+import { RESUMABLE_UPLOAD_THRESHOLD, resumableUpload } from './upload.js';
+import {
+    forgetSecretLink,
+    openSecret,
+    parseSecretLink,
+    rememberSecretLink,
+    saveSecretFile,
+    saveSecretText
+} from './secret.js';
 import './styles.css';
 
 const e = React.createElement;
@@ -22,7 +53,52 @@ function isImageItem(item) {
     return item.contentType?.startsWith('image/');
 }
 
+// Expiration choices offered for new items and for changing existing ones.
+const EXPIRATION_CHOICES = ['1h', '1d', '7d', 'never'];
+const VISIBILITY_CHOICES = ['private', 'users', 'everyone'];
+
+// Items that never expire carry Go's zero time.
+function neverExpires(item) {
+    return !item.expiresAt || new Date(item.expiresAt).getUTCFullYear() <= 1;
+}
+
+// Pinned items stay until they are unpinned, whatever their expiry says.
+function itemExpired(item, now) {
+    return !item.pinned && !neverExpires(item) && new Date(item.expiresAt) <= now;
+}
+
+// sortPinnedFirst keeps the server's order otherwise, which is newest first.
+function sortPinnedFirst(items) {
+    return [...items.filter((item) => item.pinned), ...items.filter((item) => !item.pinned)];
+}
+
+function splitList(value) {
+    return value.split(',').map((name) => name.trim()).filter(Boolean);
+}
+
+function isSecretItem(item) {
+    return item.type === 'secret-text' || item.type === 'secret-file';
+}
+
 function RecentTypeIcon({ type, contentType }) {
+    if (type === 'secret-text' || type === 'secret-file') {
+        return e('span', {
+            className: 'inline-flex h-8 w-8 shrink-0 items-center justify-center rounded-full bg-purple-100 text-purple-700',
+            'aria-label': 'Encrypted item'
+        },
+            e(Lock, { size: 18, 'aria-hidden': true }),
+            e('span', { className: 'sr-only' }, 'Encrypted item')
+        );
+    }
+    if (type === 'bundle') {
+        return e('span', {
+            className: 'inline-flex h-8 w-8 shrink-0 items-center justify-center rounded-full bg-amber-100 text-amber-700',
+            'aria-label': 'Bundle item'
+        },
+            e(Files, { size: 18, 'aria-hidden': true }),
+            e('span', { className: 'sr-only' }, 'Bundle item')
+        );
+    }
     const image = contentType?.startsWith('image/');
     const Icon = type === 'text' ? FileText : image ? ImageIcon : FileIcon;
     return e('span', {
@@ -38,20 +114,68 @@ function RecentTypeIcon({ type, contentType }) {
     );
 }
 
+// ItemThumbnail shows an image item's thumbnail in place of its type icon.
+// It is fetched with the login token, so it cannot be a plain img src.
+function ItemThumbnail({ item, onClick }) {
+    const [url, setUrl] = useState(null);
+    const [failed, setFailed] = useState(false);
+
+    useEffect(() => {
+        let objectUrl = null;
+        let cancelled = false;
+        Auth.fetch(`/api/file/${item.id}/thumbnail`)
+            .then((response) => {
+                if (!response.ok) {
+                    throw new Error(response.statusText);
+                }
+                return response.blob();
+            })
+            .then((blob) => {
+                if (!cancelled) {
+                    objectUrl = URL.createObjectURL(blob);
+                    setUrl(objectUrl);
+                }
+            })
+            .catch(() => !cancelled && setFailed(true));
+        return () => {
+            cancelled = true;
+            if (objectUrl) {
+                URL.revokeObjectURL(objectUrl);
+            }
+        };
+    }, [item.id]);
+
+    if (failed || !url) {
+        return e(RecentTypeIcon, { type: item.type, contentType: item.contentType });
+    }
+    return e('button', {
+        className: 'h-12 w-12 shrink-0 overflow-hidden rounded border bg-white',
+        title: i18n.t('item-action-preview-image'),
+        onClick
+    }, e('img', { className: 'h-full w-full object-cover', src: url, alt: item.fileName || item.description }));
+}
+
 export function AppShell() {
     const [user, setUser] = useState(Auth.getCurrentUser());
     const [ready, setReady] = useState(false);
     const [language, setLanguage] = useState(i18n.getCurrentLanguage());
     const [message, showMessage] = useMessage();
+    const [secret, setSecret] = useState(null);
 
     useEffect(() => {
+        rememberSecretLink();
+        setSecret(parseSecretLink(window.location.hash));
+        const onHashChange = () => setSecret(parseSecretLink(window.location.hash));
+        window.addEventListener('hashchange', onHashChange);
         Auth.requireAuth().then((authenticated) => {
             if (!authenticated) {
                 return;
             }
+            forgetSecretLink();
             setUser(Auth.getCurrentUser());
             setReady(true);
         });
+        return () => window.removeEventListener('hashchange', onHashChange);
     }, []);
 
     function switchLanguage(lang) {
@@ -72,7 +196,9 @@ export function AppShell() {
         }),
         e('h1', { className: 'text-2xl sm:text-3xl font-bold text-center text-gray-800 mt-6 mb-3' }, i18n.t('title')),
         e('p', { className: 'text-center text-sm text-gray-600 mb-6' }, i18n.t('expiry-notice')),
-        e(ClipboardPanel, { showMessage }),
+        secret
+            ? e(SecretView, { secret, showMessage })
+            : e(ClipboardPanel, { showMessage }),
         message && e(StatusMessage, { message })
     );
 }
@@ -80,19 +206,43 @@ export function AppShell() {
 function ClipboardPanel({ showMessage }) {
     const [textContent, setTextContent] = useState('');
     const [selectedFile, setSelectedFile] = useState(null);
+    const [bundleFiles, setBundleFiles] = useState([]);
     const [dragActive, setDragActive] = useState(false);
     const [recentItems, setRecentItems] = useState([]);
+    const [receivedItems, setReceivedItems] = useState([]);
+    const [endToEnd, setEndToEnd] = useState(false);
+    const [burnAfterRead, setBurnAfterRead] = useState(false);
+    const [expiresIn, setExpiresIn] = useState('');
+    const [itemPassword, setItemPassword] = useState('');
+    const [visibility, setVisibility] = useState('');
+    const [sharedWith, setSharedWith] = useState('');
+    const [collections, setCollections] = useState([]);
+    const [itemCollection, setItemCollection] = useState('');
+    const [itemTags, setItemTags] = useState('');
+    const [secretLink, setSecretLink] = useState('');
 
     useEffect(() => {
         const timer = setInterval(() => {
             loadRecentItems(false);
+            loadReceivedItems();
             cleanupExpiredItems();
         }, 60000);
         loadRecentItems();
+        loadReceivedItems();
+        loadCollections();
         cleanupExpiredItems();
         return () => clearInterval(timer);
     }, []);
 
+    async function loadCollections() {
+        try {
+            const data = await Auth.json('/api/collections');
+            setCollections(data.collections || []);
+        } catch (error) {
+            showMessage(i18n.t('collections-load-failed', error.message), 'error');
+        }
+    }
+
     async function loadRecentItems(showErrors = true) {
         try {
             const data = await Auth.json('/api/items');
@@ -104,13 +254,22 @@ function ClipboardPanel({ showMessage }) {
         }
     }
 
+    async function loadReceivedItems() {
+        try {
+            const data = await Auth.json('/api/items/received');
+            setReceivedItems(data.items || []);
+        } catch (error) {
+            // The inbox refreshes every minute; a failed refresh keeps the old list.
+        }
+    }
+
     function setRecent(items) {
         setRecentItems(items);
     }
 
     function cleanupExpiredItems() {
         const now = new Date();
-        setRecentItems((currentItems) => currentItems.filter((item) => new Date(item.expiresAt) > now));
+        setRecentItems((currentItems) => currentItems.filter((item) => !itemExpired(item, now)));
     }
 
     function addToRecent(type, id, description, expiresAt, contentType) {
@@ -123,7 +282,7 @@ function ClipboardPanel({ showMessage }) {
             expiresAt
         };
         setRecentItems((currentItems) => {
-            const nextItems = [item, ...currentItems.filter((current) => current.id !== id)].slice(0, 10);
+            const nextItems = sortPinnedFirst([item, ...currentItems.filter((current) => current.id !== id)]).slice(0, 10);
             return nextItems;
         });
     }
@@ -135,11 +294,23 @@ function ClipboardPanel({ showMessage }) {
             return;
         }
 
+        if (endToEnd) {
+            try {
+                const data = await saveSecretText(content, itemOptions());
+                addToRecent(data.type, data.id, i18n.t('secret-item'), data.expiresAt);
+                await shareSecretLink(data.link);
+                loadRecentItems();
+            } catch (error) {
+                showMessage(i18n.t('error-saving-text', error.message), 'error');
+            }
+            return;
+        }
+
         try {
             const response = await Auth.fetch('/api/text', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ content })
+                body: JSON.stringify({ content, ...itemOptions() })
             });
             if (!response.ok) {
                 throw new Error(i18n.t('failed-save-text'));
@@ -153,6 +324,42 @@ function ClipboardPanel({ showMessage }) {
         }
     }
 
+    function itemOptions() {
+        const options = {};
+        if (burnAfterRead) {
+            options.burnAfterRead = true;
+        }
+        if (expiresIn) {
+            options.expiresIn = expiresIn;
+        }
+        // End-to-end encrypted items are already locked by their link key.
+        if (itemPassword && !endToEnd) {
+            options.password = itemPassword;
+        }
+        if (visibility === 'users') {
+            options.sharedWith = splitList(sharedWith);
+        } else if (visibility) {
+            options.visibility = visibility;
+        }
+        if (itemCollection) {
+            options.collection = itemCollection;
+        }
+        if (itemTags.trim()) {
+            options.tags = splitList(itemTags);
+        }
+        return options;
+    }
+
+    async function shareSecretLink(link) {
+        setSecretLink(link);
+        try {
+            await navigator.clipboard.writeText(link);
+            showMessage(i18n.t('secret-link-copied'));
+        } catch (error) {
+            showMessage(i18n.t('secret-saved'));
+        }
+    }
+
     async function copyCurrentText() {
         if (!textContent) {
             showMessage(i18n.t('no-text-to-copy'), 'error');
@@ -166,13 +373,78 @@ function ClipboardPanel({ showMessage }) {
         }
     }
 
+    // selectFiles keeps a single file as a file item; several files, or any
+    // picked from a folder, are uploaded together as a bundle.
+    function selectFiles(fileList) {
+        const files = Array.from(fileList || []);
+        if (files.length === 1 && !files[0].webkitRelativePath) {
+            setSelectedFile(files[0]);
+            setBundleFiles([]);
+        } else {
+            setSelectedFile(null);
+            setBundleFiles(files);
+        }
+    }
+
+    async function uploadBundle() {
+        if (endToEnd) {
+            showMessage(i18n.t('bundle-end-to-end-unsupported'), 'error');
+            return;
+        }
+        const formData = new FormData();
+        Object.entries(itemOptions()).forEach(([key, value]) => formData.append(key, String(value)));
+        bundleFiles.forEach((file) => formData.append('file', file, file.webkitRelativePath || file.name));
+        try {
+            const response = await Auth.fetch('/api/bundle', {
+                method: 'POST',
+                body: formData
+            });
+            const data = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(data.error || i18n.t('failed-upload-file'));
+            }
+            addToRecent('bundle', data.id, data.name, data.expiresAt);
+            loadRecentItems();
+            showMessage(i18n.t('bundle-uploaded', data.files.length));
+        } catch (error) {
+            showMessage(i18n.t('error-uploading-file', error.message), 'error');
+        }
+    }
+
     async function uploadFile() {
+        if (bundleFiles.length > 0) {
+            return uploadBundle();
+        }
         if (!selectedFile) {
             showMessage(i18n.t('please-select-file'), 'error');
             return;
         }
 
+        if (endToEnd) {
+            try {
+                const data = await saveSecretFile(selectedFile, itemOptions());
+                addToRecent(data.type, data.id, i18n.t('secret-item'), data.expiresAt);
+                await shareSecretLink(data.link);
+                loadRecentItems();
+            } catch (error) {
+                showMessage(i18n.t('error-uploading-file', error.message), 'error');
+            }
+            return;
+        }
+
+        if (selectedFile.size > RESUMABLE_UPLOAD_THRESHOLD) {
+            try {
+                await resumableUpload(selectedFile, undefined, itemOptions());
+                loadRecentItems();
+                showMessage(i18n.t('file-uploaded'));
+            } catch (error) {
+                showMessage(i18n.t('error-uploading-file', error.message), 'error');
+            }
+            return;
+        }
+
         const formData = new FormData();
+        Object.entries(itemOptions()).forEach(([key, value]) => formData.append(key, String(value)));
         formData.append('file', selectedFile);
         try {
             const response = await Auth.fetch('/api/file', {
@@ -194,13 +466,110 @@ function ClipboardPanel({ showMessage }) {
     function handleDroppedFile(event) {
         event.preventDefault();
         setDragActive(false);
-        const file = event.dataTransfer.files[0];
-        if (file) {
-            setSelectedFile(file);
+        if (event.dataTransfer.files.length > 0) {
+            selectFiles(event.dataTransfer.files);
         }
     }
 
     return e(React.Fragment, null,
+        e('div', { className: 'flex flex-col sm:flex-row items-center justify-center gap-2 sm:gap-6 mb-4 text-sm text-gray-700' },
+            e('label', { className: 'flex items-center gap-2' },
+                e('input', {
+                    type: 'checkbox',
+                    checked: endToEnd,
+                    onChange: (event) => setEndToEnd(event.target.checked)
+                }),
+                e(Lock, { size: 16, 'aria-hidden': true }),
+                e('span', null, i18n.t('end-to-end-encrypt'))
+            ),
+            e('label', { className: 'flex items-center gap-2' },
+                e('input', {
+                    type: 'checkbox',
+                    checked: burnAfterRead,
+                    onChange: (event) => setBurnAfterRead(event.target.checked)
+                }),
+                e(Flame, { size: 16, 'aria-hidden': true }),
+                e('span', null, i18n.t('burn-after-read'))
+            ),
+            e('label', { className: 'flex items-center gap-2' },
+                e(Clock, { size: 16, 'aria-hidden': true }),
+                e('span', null, i18n.t('expires-in')),
+                e('select', {
+                    className: 'p-1 border border-gray-300 rounded text-sm',
+                    value: expiresIn,
+                    onChange: (event) => setExpiresIn(event.target.value)
+                },
+                e('option', { value: '' }, i18n.t('expiration-default')),
+                EXPIRATION_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`expiration-${choice}`)))
+                )
+            ),
+            e('label', { className: 'flex items-center gap-2' },
+                e(KeyRound, { size: 16, 'aria-hidden': true }),
+                e('input', {
+                    type: 'password',
+                    autoComplete: 'new-password',
+                    className: 'p-1 border border-gray-300 rounded text-sm disabled:opacity-50',
+                    placeholder: i18n.t('item-password'),
+                    'aria-label': i18n.t('item-password'),
+                    disabled: endToEnd,
+                    value: itemPassword,
+                    onChange: (event) => setItemPassword(event.target.value)
+                })
+            ),
+            e('label', { className: 'flex items-center gap-2' },
+                e(Users, { size: 16, 'aria-hidden': true }),
+                e('span', null, i18n.t('visibility')),
+                e('select', {
+                    className: 'p-1 border border-gray-300 rounded text-sm',
+                    value: visibility,
+                    onChange: (event) => setVisibility(event.target.value)
+                },
+                e('option', { value: '' }, i18n.t('visibility-default')),
+                VISIBILITY_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`visibility-${choice}`)))
+                )
+            ),
+            visibility === 'users' && e('input', {
+                type: 'text',
+                className: 'p-1 border border-gray-300 rounded text-sm',
+                placeholder: i18n.t('visibility-usernames'),
+                'aria-label': i18n.t('visibility-usernames'),
+                value: sharedWith,
+                onChange: (event) => setSharedWith(event.target.value)
+            }),
+            collections.length > 0 && e('label', { className: 'flex items-center gap-2' },
+                e(Folder, { size: 16, 'aria-hidden': true }),
+                e('select', {
+                    className: 'p-1 border border-gray-300 rounded text-sm',
+                    'aria-label': i18n.t('collection'),
+                    value: itemCollection,
+                    onChange: (event) => setItemCollection(event.target.value)
+                },
+                e('option', { value: '' }, i18n.t('collection-none')),
+                collections.map((collection) => e('option', { key: collection.id, value: collection.id }, collection.name))
+                )
+            ),
+            e('label', { className: 'flex items-center gap-2' },
+                e(Tag, { size: 16, 'aria-hidden': true }),
+                e('input', {
+                    type: 'text',
+                    className: 'p-1 border border-gray-300 rounded text-sm',
+                    placeholder: i18n.t('tags-placeholder'),
+                    'aria-label': i18n.t('tags'),
+                    value: itemTags,
+                    onChange: (event) => setItemTags(event.target.value)
+                })
+            )
+        ),
+        secretLink && e('div', { className: 'mb-4 bg-purple-50 border border-purple-200 rounded-lg p-3 text-sm' },
+            e('p', { className: 'text-purple-800 mb-2' }, i18n.t('secret-link-hint')),
+            e('div', { className: 'flex gap-2' },
+                e('input', { className: 'flex-1 min-w-0 p-2 border rounded font-mono text-xs', readOnly: true, value: secretLink, onFocus: (event) => event.target.select() }),
+                e('button', {
+                    className: 'px-3 py-2 bg-purple-500 hover:bg-purple-600 text-white rounded text-xs',
+                    onClick: () => shareSecretLink(secretLink)
+                }, e(IconLabel, { icon: LinkIcon, label: i18n.t('copy-secret-link') }))
+            )
+        ),
         e('section', { className: 'grid grid-cols-1 lg:grid-cols-2 gap-4 sm:gap-6' },
             e('div', { className: 'bg-white rounded-lg shadow-md p-4 sm:p-6' },
                 e('h2', { className: 'text-lg sm:text-xl font-semibold mb-4 text-gray-700' }, i18n.t('text-clipboard')),
@@ -221,7 +590,15 @@ function ClipboardPanel({ showMessage }) {
                     type: 'file',
                     id: 'fileInput',
                     className: 'hidden',
-                    onChange: (event) => setSelectedFile(event.target.files[0] || null)
+                    multiple: true,
+                    onChange: (event) => selectFiles(event.target.files)
+                }),
+                e('input', {
+                    type: 'file',
+                    id: 'folderInput',
+                    className: 'hidden',
+                    webkitdirectory: '',
+                    onChange: (event) => selectFiles(event.target.files)
                 }),
                 e('button', {
                     className: `w-full p-4 border-2 border-dashed rounded-lg text-sm ${dragActive ? 'border-blue-500 text-blue-500 bg-blue-50' : 'border-gray-300 text-gray-600 hover:border-blue-500 hover:text-blue-500'}`,
@@ -233,25 +610,210 @@ function ClipboardPanel({ showMessage }) {
                     onDragLeave: () => setDragActive(false),
                     onDrop: handleDroppedFile
                 }, e(IconLabel, { icon: FolderOpen, label: i18n.t('select-file') })),
+                e('button', {
+                    className: 'mt-2 text-xs text-blue-600 hover:underline',
+                    onClick: () => document.getElementById('folderInput').click()
+                }, e(IconLabel, { icon: FolderUp, label: i18n.t('select-folder') })),
                 selectedFile && e('div', { className: 'mt-2 text-sm text-gray-600' },
                     i18n.t('selected-file', selectedFile.name, (selectedFile.size / 1024 / 1024).toFixed(2))
                 ),
+                bundleFiles.length > 0 && e('div', { className: 'mt-2 text-sm text-gray-600' },
+                    i18n.t('selected-files', bundleFiles.length, (bundleFiles.reduce((total, file) => total + file.size, 0) / 1024 / 1024).toFixed(2))
+                ),
                 e('button', {
                     className: 'w-full mt-4 bg-blue-500 hover:bg-blue-600 disabled:opacity-50 text-white py-2 px-4 rounded-lg font-medium text-sm',
-                    disabled: !selectedFile,
+                    disabled: !selectedFile && bundleFiles.length === 0,
                     onClick: uploadFile
                 }, e(IconLabel, { icon: Upload, label: i18n.t('upload-file') }))
             )
         ),
-        e(RecentItems, { items: recentItems, setRecent, showMessage })
+        e(SearchPanel, { showMessage, collections }),
+        e(RecentItems, { items: recentItems, setRecent, showMessage, collections }),
+        receivedItems.length > 0 && e(RecentItems, { items: receivedItems, setRecent: setReceivedItems, showMessage, received: true }),
+        e(HistoryPanel, { showMessage, collections, reloadCollections: loadCollections })
+    );
+}
+
+// SearchPanel searches the text and file names of every item the user can
+// see. Results show where the words matched.
+function SearchPanel({ showMessage, collections }) {
+    const [query, setQuery] = useState('');
+    const [results, setResults] = useState(null);
+
+    async function search(event) {
+        event.preventDefault();
+        const q = query.trim();
+        if (!q) {
+            setResults(null);
+            return;
+        }
+        try {
+            const data = await Auth.json(`/api/search?${new URLSearchParams({ q })}`);
+            setResults(data.results);
+        } catch (error) {
+            showMessage(i18n.t('search-failed', error.message), 'error');
+        }
+    }
+
+    const form = e('form', { className: 'flex gap-2 mb-4', onSubmit: search },
+        e('input', {
+            type: 'search',
+            className: 'flex-1 p-2 border border-gray-300 rounded-lg text-sm',
+            placeholder: i18n.t('search-placeholder'),
+            'aria-label': i18n.t('search'),
+            value: query,
+            onChange: (event) => setQuery(event.target.value)
+        }),
+        e('button', { type: 'submit', className: 'px-4 py-2 rounded-lg bg-blue-500 hover:bg-blue-600 text-white text-sm' },
+            e(IconLabel, { icon: Search, label: i18n.t('search') })
+        )
     );
+    if (results === null) {
+        return e('section', { className: 'mt-6 sm:mt-8 bg-white rounded-lg shadow-md p-4 sm:p-6' }, form);
+    }
+    return e(RecentItems, {
+        items: results,
+        setRecent: setResults,
+        showMessage,
+        collections,
+        title: i18n.t('search-results', results.length),
+        toolbar: form,
+        detail: (item) => e('p', { className: 'text-xs text-gray-600 mt-1 break-words' },
+            (item.snippet || []).map((part, index) => (part.match
+                ? e('mark', { key: index, className: 'bg-yellow-200 rounded px-0.5' }, part.text)
+                : e('span', { key: index }, part.text)))
+        )
+    });
 }
 
-function RecentItems({ items, setRecent, showMessage }) {
+const HISTORY_TYPES = ['text', 'file', 'secret-text,secret-file'];
+const HISTORY_SORTS = ['newest', 'oldest', 'largest', 'smallest', 'expiring'];
+
+// HistoryPanel pages through all of the user's items, loaded on demand so
+// the recent list stays the quick view.
+function HistoryPanel({ showMessage, collections, reloadCollections }) {
+    const [open, setOpen] = useState(false);
+    const [filters, setFilters] = useState({ type: '', sort: 'newest', pinned: false, collection: '', tag: '' });
+    const [tagInput, setTagInput] = useState('');
+    const [managingCollections, setManagingCollections] = useState(false);
+    const [items, setItems] = useState([]);
+    const [cursor, setCursor] = useState('');
+
+    useEffect(() => {
+        if (open) {
+            loadHistory('');
+        }
+    }, [open, filters]);
+
+    async function loadHistory(after) {
+        const params = new URLSearchParams({ sort: filters.sort });
+        if (filters.type) {
+            params.set('type', filters.type);
+        }
+        if (filters.pinned) {
+            params.set('pinned', 'true');
+        }
+        if (filters.collection) {
+            params.set('collection', filters.collection);
+        }
+        if (filters.tag) {
+            params.set('tag', filters.tag);
+        }
+        if (after) {
+            params.set('cursor', after);
+        }
+        try {
+            const data = await Auth.json(`/api/items/history?${params}`);
+            setItems((current) => (after ? [...current, ...data.items] : data.items));
+            setCursor(data.nextCursor || '');
+        } catch (error) {
+            showMessage(i18n.t('history-load-failed', error.message), 'error');
+        }
+    }
+
+    function updateFilter(name, value) {
+        setFilters((current) => ({ ...current, [name]: value }));
+    }
+
+    if (!open) {
+        return e('div', { className: 'mt-6 text-center' },
+            e('button', { className: 'px-4 py-2 rounded bg-gray-100 hover:bg-gray-200 text-gray-700 text-sm', onClick: () => setOpen(true) },
+                e(IconLabel, { icon: History, label: i18n.t('show-history') })
+            )
+        );
+    }
+
+    function collectionsChanged() {
+        reloadCollections();
+        if (filters.collection) {
+            updateFilter('collection', '');
+        } else {
+            loadHistory('');
+        }
+    }
+
+    const filterClass = 'p-1 border border-gray-300 rounded text-xs text-gray-600';
+    return e(RecentItems, {
+        items,
+        setRecent: setItems,
+        showMessage,
+        collections,
+        title: i18n.t('history'),
+        toolbar: e('div', { className: 'flex flex-wrap items-center gap-2 mb-4' },
+            e('select', { className: filterClass, 'aria-label': i18n.t('history-type'), value: filters.type, onChange: (event) => updateFilter('type', event.target.value) },
+                e('option', { value: '' }, i18n.t('history-type-all')),
+                HISTORY_TYPES.map((type) => e('option', { key: type, value: type }, i18n.t(`history-type-${type.split(',')[0]}`)))
+            ),
+            e('select', { className: filterClass, 'aria-label': i18n.t('history-sort'), value: filters.sort, onChange: (event) => updateFilter('sort', event.target.value) },
+                HISTORY_SORTS.map((sort) => e('option', { key: sort, value: sort }, i18n.t(`history-sort-${sort}`)))
+            ),
+            e('label', { className: 'inline-flex items-center gap-1 text-xs text-gray-600' },
+                e('input', { type: 'checkbox', checked: filters.pinned, onChange: (event) => updateFilter('pinned', event.target.checked) }),
+                i18n.t('history-pinned-only')
+            ),
+            e('select', { className: filterClass, 'aria-label': i18n.t('collection'), value: filters.collection, onChange: (event) => updateFilter('collection', event.target.value) },
+                e('option', { value: '' }, i18n.t('collection-all')),
+                collections.map((collection) => e('option', { key: collection.id, value: collection.id }, collection.name))
+            ),
+            e('form', { onSubmit: (event) => { event.preventDefault(); updateFilter('tag', tagInput.trim()); } },
+                e('input', {
+                    type: 'text',
+                    className: filterClass,
+                    placeholder: i18n.t('history-tag'),
+                    'aria-label': i18n.t('history-tag'),
+                    value: tagInput,
+                    onChange: (event) => setTagInput(event.target.value),
+                    onBlur: () => updateFilter('tag', tagInput.trim())
+                })
+            ),
+            e('button', { className: 'px-2 py-1 rounded bg-gray-100 hover:bg-gray-200 text-gray-700 text-xs', onClick: () => setManagingCollections(true) },
+                e(IconLabel, { icon: Folder, label: i18n.t('collections') })
+            ),
+            managingCollections && e(CollectionsModal, {
+                collections,
+                onClose: () => setManagingCollections(false),
+                onChanged: collectionsChanged,
+                showMessage
+            })
+        ),
+        footer: cursor && e('div', { className: 'mt-4 text-center' },
+            e('button', { className: 'px-4 py-2 rounded bg-gray-100 hover:bg-gray-200 text-gray-700 text-sm', onClick: () => loadHistory(cursor) }, i18n.t('history-load-more'))
+        )
+    });
+}
+
+// RecentItems lists the user's own items, or with received set the items
+// other users sent them, which they can open but not change. The history
+// and search views pass their own title, controls as toolbar, paging as
+// footer and, for search, a detail line per item.
+function RecentItems({ items, setRecent, showMessage, received = false, collections = [], title, toolbar = null, footer = null, detail = null }) {
     const [imagePreview, setImagePreview] = useState(null);
+    const [sharingItem, setSharingItem] = useState(null);
+    const [bundleItem, setBundleItem] = useState(null);
+    const [editingItem, setEditingItem] = useState(null);
     const validItems = useMemo(() => {
         const now = new Date();
-        return items.filter((item) => new Date(item.expiresAt) > now);
+        return items.filter((item) => !itemExpired(item, now));
     }, [items]);
 
     async function copyTextItem(id) {
@@ -266,15 +828,20 @@ function RecentItems({ items, setRecent, showMessage }) {
             }
             const data = await response.json();
             await navigator.clipboard.writeText(data.content);
+            if (data.lastView) {
+                forgetItem(id);
+                showMessage(i18n.t('last-view-text-copied'));
+                return;
+            }
             showMessage(i18n.t('text-copied'));
         } catch (error) {
             showMessage(i18n.t('failed-copy-text'), 'error');
         }
     }
 
-    async function downloadFile(id) {
+    async function downloadFile(id, url = `/api/file/${id}`) {
         try {
-            const response = await Auth.fetch(`/api/file/${id}`);
+            const response = await Auth.fetch(url);
             if (response.status === 404) {
                 showMessage(i18n.t('file-not-found'), 'error');
                 return;
@@ -291,6 +858,11 @@ function RecentItems({ items, setRecent, showMessage }) {
             link.click();
             document.body.removeChild(link);
             window.URL.revokeObjectURL(url);
+            if (response.headers.get('Clipboard-Last-View') === 'true') {
+                forgetItem(id);
+                showMessage(i18n.t('last-view-file-downloaded'));
+                return;
+            }
             showMessage(i18n.t('file-downloaded'));
         } catch (error) {
             showMessage(i18n.t('error-downloading-file', error.message), 'error');
@@ -323,6 +895,35 @@ function RecentItems({ items, setRecent, showMessage }) {
         }
     }
 
+    // viewFile opens a file the server agreed to show inline in a new tab.
+    // The tab is opened before the request so popup blockers allow it.
+    async function viewFile(item) {
+        const viewer = window.open('', '_blank');
+        try {
+            const response = await Auth.fetch(`/api/file/${item.id}?inline=true`);
+            if (response.status === 404) {
+                throw new Error(i18n.t('file-not-found'));
+            }
+            if (!response.ok) {
+                throw new Error(i18n.t('failed-download-file'));
+            }
+            if (!(response.headers.get('content-disposition') || '').startsWith('inline')) {
+                throw new Error(i18n.t('view-not-supported'));
+            }
+            const blob = await response.blob();
+            const url = URL.createObjectURL(blob);
+            viewer.location.href = url;
+            setTimeout(() => URL.revokeObjectURL(url), 60000);
+            if (response.headers.get('Clipboard-Last-View') === 'true') {
+                forgetItem(item.id);
+                showMessage(i18n.t('last-view-file-downloaded'));
+            }
+        } catch (error) {
+            viewer?.close();
+            showMessage(i18n.t('error-viewing-file', error.message), 'error');
+        }
+    }
+
     function closeImagePreview() {
         setImagePreview((current) => {
             if (current?.url) {
@@ -332,10 +933,132 @@ function RecentItems({ items, setRecent, showMessage }) {
         });
     }
 
+    function forgetItem(id) {
+        setRecent(items.filter((item) => item.id !== id));
+    }
+
+    async function changeExpiration(id, expiresIn) {
+        try {
+            const updated = await Auth.json(`/api/items/${id}`, {
+                method: 'PATCH',
+                body: JSON.stringify({ expiration: { expiresIn } })
+            });
+            setRecent(items.map((item) => (item.id === id ? { ...item, expiresAt: updated.expiresAt } : item)));
+            showMessage(i18n.t('expiration-updated'));
+        } catch (error) {
+            showMessage(i18n.t('expiration-update-failed', error.message), 'error');
+        }
+    }
+
+    async function togglePinned(item) {
+        try {
+            const updated = await Auth.json(`/api/items/${item.id}`, {
+                method: 'PATCH',
+                body: JSON.stringify({ pinned: !item.pinned })
+            });
+            const changed = items.map((current) => (current.id === item.id
+                ? { ...current, pinned: updated.pinned, expiresAt: updated.expiresAt }
+                : current));
+            setRecent(sortPinnedFirst(changed));
+            showMessage(i18n.t(updated.pinned ? 'item-pinned' : 'item-unpinned'));
+        } catch (error) {
+            showMessage(i18n.t('pin-update-failed', error.message), 'error');
+        }
+    }
+
+    async function updateItem(item, changes, done, failed) {
+        try {
+            const updated = await Auth.json(`/api/items/${item.id}`, {
+                method: 'PATCH',
+                body: JSON.stringify(changes)
+            });
+            setRecent(items.map((current) => (current.id === item.id
+                ? { ...current, tags: updated.tags, collection: updated.collection }
+                : current)));
+            showMessage(i18n.t(done));
+        } catch (error) {
+            showMessage(i18n.t(failed, error.message), 'error');
+        }
+    }
+
+    function editTags(item) {
+        const tags = window.prompt(i18n.t('tags-placeholder'), (item.tags || []).join(', '));
+        if (tags !== null) {
+            updateItem(item, { tags: splitList(tags) }, 'tags-updated', 'tags-update-failed');
+        }
+    }
+
+    function collectionName(id) {
+        return collections.find((collection) => collection.id === id)?.name || '';
+    }
+
+    async function changeVisibility(item, visibility) {
+        let body = { visibility };
+        if (visibility === 'users') {
+            const usernames = window.prompt(i18n.t('visibility-usernames'), (item.sharedWith || []).join(', '));
+            if (usernames === null) {
+                return;
+            }
+            body = { sharedWith: splitList(usernames) };
+        }
+        try {
+            const updated = await Auth.json(`/api/items/${item.id}`, {
+                method: 'PATCH',
+                body: JSON.stringify(body)
+            });
+            setRecent(items.map((current) => (current.id === item.id
+                ? { ...current, visibility: updated.visibility, sharedWith: updated.sharedWith }
+                : current)));
+            showMessage(i18n.t('visibility-updated'));
+        } catch (error) {
+            showMessage(i18n.t('visibility-update-failed', error.message), 'error');
+        }
+    }
+
+    async function sendItem(item) {
+        const usernames = window.prompt(i18n.t('send-item-usernames'));
+        if (!usernames) {
+            return;
+        }
+        try {
+            const updated = await Auth.json(`/api/items/${item.id}/recipients`, {
+                method: 'POST',
+                body: JSON.stringify({ usernames: splitList(usernames) })
+            });
+            setRecent(items.map((current) => (current.id === item.id
+                ? { ...current, visibility: updated.visibility, sharedWith: updated.sharedWith }
+                : current)));
+            showMessage(i18n.t('item-sent', (updated.sharedWith || []).join(', ')));
+        } catch (error) {
+            showMessage(i18n.t('send-item-failed', error.message), 'error');
+        }
+    }
+
+    function textEdited(id, content) {
+        const description = content.length > 50 ? `${content.slice(0, 50)}...` : content;
+        setRecent(items.map((item) => (item.id === id ? { ...item, description } : item)));
+    }
+
+    // Items other users own can be opened but not changed.
+    function othersItem(item) {
+        return received || Boolean(item.from);
+    }
+
+    function openedLabel(item) {
+        if (othersItem(item)) {
+            return item.openedAt ? i18n.t('opened-at', new Date(item.openedAt).toLocaleString()) : i18n.t('not-opened');
+        }
+        const opened = Object.keys(item.openedBy || {});
+        return opened.length > 0 ? i18n.t('opened-by', opened.join(', ')) : '';
+    }
+
     function loadItem(type, id) {
         if (type === 'text') {
             return copyTextItem(id);
         }
+        if (type === 'bundle') {
+            return downloadFile(id, bundleZipUrl(id));
+        }
         return downloadFile(id);
     }
 
@@ -346,19 +1069,81 @@ function RecentItems({ items, setRecent, showMessage }) {
     }, [validItems.length]);
 
     return e('section', { className: 'mt-6 sm:mt-8 bg-white rounded-lg shadow-md p-4 sm:p-6' },
-        e('h2', { className: 'text-lg sm:text-xl font-semibold mb-4 text-gray-700' }, i18n.t('recent-items')),
+        e('h2', { className: 'text-lg sm:text-xl font-semibold mb-4 text-gray-700' }, title || i18n.t(received ? 'received-items' : 'recent-items')),
+        toolbar,
         validItems.length === 0
             ? e('p', { className: 'text-gray-500 text-center text-sm' }, i18n.t('no-recent-items'))
             : e('div', { className: 'space-y-2' }, validItems.map((item) =>
                 e('div', { key: item.id, className: 'flex items-center justify-between p-3 bg-gray-50 rounded border' },
                     e('div', { className: 'flex-1 min-w-0' },
                         e('div', { className: 'flex items-center gap-2' },
-                            e(RecentTypeIcon, { type: item.type, contentType: item.contentType }),
-                            e('span', { className: 'font-medium text-sm truncate' }, item.description)
+                            item.thumbnail
+                                ? e(ItemThumbnail, { item, onClick: () => previewImage(item) })
+                                : e(RecentTypeIcon, { type: item.type, contentType: item.contentType }),
+                            item.pinned && e(Pin, { size: 14, className: 'shrink-0 text-amber-600', 'aria-label': i18n.t('pinned') }),
+                            e('span', { className: 'font-medium text-sm truncate' }, isSecretItem(item) ? i18n.t('secret-item') : item.description)
                         ),
-                        e('div', { className: 'text-xs text-gray-500 mt-1' }, i18n.t('created', new Date(item.createdAt).toLocaleString()))
+                        e('div', { className: 'text-xs text-gray-500 mt-1' },
+                            i18n.t('created', new Date(item.createdAt).toLocaleString()),
+                            item.width > 0 && item.height > 0 && e('span', { className: 'ml-2' }, i18n.t('image-dimensions', item.width, item.height)),
+                            item.metadataStripped && e('span', { className: 'ml-2 text-green-700' }, i18n.t('metadata-stripped')),
+                            e('span', { className: 'ml-2' }, neverExpires(item) || item.pinned
+                                ? i18n.t('never')
+                                : i18n.t('expires', new Date(item.expiresAt).toLocaleString())),
+                            item.maxReads > 0 && e('span', { className: 'ml-2 text-orange-600' }, i18n.t('reads-left', item.maxReads - (item.readCount || 0))),
+                            item.passwordProtected && e('span', { className: 'ml-2 text-gray-700' }, i18n.t('password-protected')),
+                            othersItem(item)
+                                ? e('span', { className: 'ml-2 text-gray-700' }, i18n.t('received-from', item.from || '?'))
+                                : e('span', { className: 'ml-2 text-gray-700' }, item.visibility === 'users'
+                                    ? i18n.t('shared-with', (item.sharedWith || []).join(', '))
+                                    : i18n.t(`visibility-${item.visibility || 'private'}`)),
+                            openedLabel(item) && e('span', { className: 'ml-2 text-green-700' }, openedLabel(item)),
+                            item.collection && collectionName(item.collection) && e('span', { className: 'ml-2 text-gray-700' }, i18n.t('in-collection', collectionName(item.collection))),
+                            (item.tags || []).map((tag) => e('span', { key: tag, className: 'ml-2 px-1.5 py-0.5 rounded bg-gray-200 text-gray-700' }, `#${tag}`))
+                        ),
+                        detail && detail(item)
                     ),
                     e('div', { className: 'flex shrink-0 items-center gap-2' },
+                        !othersItem(item) && e('select', {
+                            className: 'p-1 border border-gray-300 rounded text-xs text-gray-600',
+                            title: i18n.t('change-expiration'),
+                            'aria-label': i18n.t('change-expiration'),
+                            value: '',
+                            onChange: (event) => event.target.value && changeExpiration(item.id, event.target.value)
+                        },
+                        e('option', { value: '' }, i18n.t('change-expiration')),
+                        EXPIRATION_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`expiration-${choice}`)))
+                        ),
+                        !othersItem(item) && e('select', {
+                            className: 'p-1 border border-gray-300 rounded text-xs text-gray-600',
+                            title: i18n.t('change-visibility'),
+                            'aria-label': i18n.t('change-visibility'),
+                            value: '',
+                            onChange: (event) => event.target.value && changeVisibility(item, event.target.value)
+                        },
+                        e('option', { value: '' }, i18n.t('change-visibility')),
+                        VISIBILITY_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`visibility-${choice}`)))
+                        ),
+                        !othersItem(item) && collections.length > 0 && e('select', {
+                            className: 'p-1 border border-gray-300 rounded text-xs text-gray-600',
+                            title: i18n.t('move-to-collection'),
+                            'aria-label': i18n.t('move-to-collection'),
+                            value: item.collection || '',
+                            onChange: (event) => updateItem(item, { collection: event.target.value }, 'collection-updated', 'collection-update-failed')
+                        },
+                        e('option', { value: '' }, i18n.t('collection-none')),
+                        collections.map((collection) => e('option', { key: collection.id, value: collection.id }, collection.name))
+                        ),
+                        !othersItem(item) && e('button', {
+                            className: 'px-3 py-2 bg-gray-100 hover:bg-gray-200 text-gray-700 rounded text-xs',
+                            title: i18n.t('edit-tags'),
+                            onClick: () => editTags(item)
+                        }, e(IconLabel, { icon: Tag, label: i18n.t('edit-tags') })),
+                        !othersItem(item) && e('button', {
+                            className: 'px-3 py-2 bg-amber-100 hover:bg-amber-200 text-amber-700 rounded text-xs',
+                            title: i18n.t(item.pinned ? 'unpin-item' : 'pin-item'),
+                            onClick: () => togglePinned(item)
+                        }, e(IconLabel, { icon: item.pinned ? PinOff : Pin, label: i18n.t(item.pinned ? 'unpin-item' : 'pin-item') })),
                         isImageItem(item) && e('button', {
                             className: 'px-3 py-2 bg-blue-100 hover:bg-blue-200 text-blue-700 rounded text-xs',
                             title: i18n.t('item-action-preview-image'),
@@ -367,7 +1152,32 @@ function RecentItems({ items, setRecent, showMessage }) {
                             icon: ImageIcon,
                             label: i18n.t('item-action-preview-image')
                         })),
-                        e('button', {
+                        item.viewable && !isImageItem(item) && e('button', {
+                            className: 'px-3 py-2 bg-blue-100 hover:bg-blue-200 text-blue-700 rounded text-xs',
+                            title: i18n.t('view-file'),
+                            onClick: () => viewFile(item)
+                        }, e(IconLabel, { icon: Eye, label: i18n.t('view-file') })),
+                        !othersItem(item) && item.type === 'text' && !(item.maxReads > 0) && e('button', {
+                            className: 'px-3 py-2 bg-blue-100 hover:bg-blue-200 text-blue-700 rounded text-xs',
+                            title: i18n.t('edit-text'),
+                            onClick: () => setEditingItem(item)
+                        }, e(IconLabel, { icon: Pencil, label: i18n.t('edit-text') })),
+                        !othersItem(item) && !isSecretItem(item) && e('button', {
+                            className: 'px-3 py-2 bg-purple-100 hover:bg-purple-200 text-purple-700 rounded text-xs',
+                            title: i18n.t('send-item'),
+                            onClick: () => sendItem(item)
+                        }, e(IconLabel, { icon: Send, label: i18n.t('send-item') })),
+                        !othersItem(item) && !isSecretItem(item) && e('button', {
+                            className: 'px-3 py-2 bg-purple-100 hover:bg-purple-200 text-purple-700 rounded text-xs',
+                            title: i18n.t('share-item'),
+                            onClick: () => setSharingItem(item)
+                        }, e(IconLabel, { icon: Share2, label: i18n.t('share-item') })),
+                        item.type === 'bundle' && e('button', {
+                            className: 'px-3 py-2 bg-blue-100 hover:bg-blue-200 text-blue-700 rounded text-xs',
+                            title: i18n.t('bundle-files'),
+                            onClick: () => setBundleItem(item)
+                        }, e(IconLabel, { icon: Files, label: i18n.t('bundle-files') })),
+                        !isSecretItem(item) && e('button', {
                             className: 'px-3 py-2 bg-green-100 hover:bg-green-200 text-green-700 rounded text-xs',
                             title: item.type === 'text' ? i18n.t('item-action-copy-text') : i18n.t('item-action-download-file'),
                             onClick: () => loadItem(item.type, item.id)
@@ -378,6 +1188,20 @@ function RecentItems({ items, setRecent, showMessage }) {
                     )
                 )
             )),
+        footer,
+        sharingItem && e(ShareModal, { item: sharingItem, onClose: () => setSharingItem(null), showMessage }),
+        bundleItem && e(BundleFilesModal, {
+            item: bundleItem,
+            onClose: () => setBundleItem(null),
+            onDownload: (url) => downloadFile(bundleItem.id, url),
+            showMessage
+        }),
+        editingItem && e(TextEditorModal, {
+            item: editingItem,
+            onClose: () => setEditingItem(null),
+            onSaved: (content) => textEdited(editingItem.id, content),
+            showMessage
+        }),
         imagePreview && e('div', { className: 'fixed inset-0 z-50 flex items-center justify-center bg-black bg-opacity-70 p-4', role: 'dialog', 'aria-modal': 'true', 'aria-label': i18n.t('image-preview-title') },
             e('div', { className: 'w-full max-w-4xl rounded-lg bg-white p-3 shadow-xl' },
                 e('div', { className: 'mb-3 flex items-center justify-between gap-3' },
@@ -398,6 +1222,77 @@ function RecentItems({ items, setRecent, showMessage }) {
     );
 }
 
+// SecretView decrypts a secret clip opened from its link. The key comes
+// from the URL fragment and is never sent to the server.
+function SecretView({ secret, showMessage }) {
+    const [state, setState] = useState({ status: 'loading' });
+
+    useEffect(() => {
+        let cancelled = false;
+        setState({ status: 'loading' });
+        openSecret(secret.id, secret.key)
+            .then((result) => !cancelled && setState({ status: 'ready', ...result }))
+            .catch((error) => {
+                if (cancelled) {
+                    return;
+                }
+                setState({
+                    status: 'error',
+                    text: error.status === 404 ? i18n.t('secret-not-found') : i18n.t('secret-decrypt-failed')
+                });
+            });
+        return () => {
+            cancelled = true;
+        };
+    }, [secret.id, secret.key]);
+
+    async function copySecretText() {
+        try {
+            await navigator.clipboard.writeText(state.text);
+            showMessage(i18n.t('text-copied'));
+        } catch (error) {
+            showMessage(i18n.t('failed-copy-text'), 'error');
+        }
+    }
+
+    function downloadSecretFile() {
+        const url = URL.createObjectURL(state.blob);
+        const link = document.createElement('a');
+        link.href = url;
+        link.download = state.name;
+        document.body.appendChild(link);
+        link.click();
+        document.body.removeChild(link);
+        URL.revokeObjectURL(url);
+        showMessage(i18n.t('file-downloaded'));
+    }
+
+    return e('section', { className: 'bg-white rounded-lg shadow-md p-4 sm:p-6' },
+        e('h2', { className: 'text-lg sm:text-xl font-semibold mb-2 text-gray-700 flex items-center gap-2' },
+            e(Lock, { size: 20, 'aria-hidden': true }),
+            i18n.t('secret-title')
+        ),
+        e('p', { className: 'text-sm text-gray-600 mb-4' }, i18n.t('secret-notice')),
+        state.status === 'loading' && e('p', { className: 'text-gray-500 text-sm' }, i18n.t('secret-decrypting')),
+        state.status === 'error' && e('p', { className: 'text-red-600 text-sm' }, state.text),
+        state.status === 'ready' && state.lastView && e('p', { className: 'text-orange-600 text-sm mb-4' }, i18n.t('secret-last-view')),
+        state.status === 'ready' && state.type === 'text' && e(React.Fragment, null,
+            e('textarea', {
+                className: 'w-full h-40 p-3 border border-gray-300 rounded-lg resize-none font-mono text-sm',
+                readOnly: true,
+                value: state.text
+            }),
+            e('button', { className: 'mt-4 bg-green-500 hover:bg-green-600 text-white py-2 px-4 rounded-lg font-medium text-sm', onClick: copySecretText },
+                e(IconLabel, { icon: Copy, label: i18n.t('copy-text') }))
+        ),
+        state.status === 'ready' && state.type === 'file' && e('button', {
+            className: 'bg-blue-500 hover:bg-blue-600 text-white py-2 px-4 rounded-lg font-medium text-sm',
+            onClick: downloadSecretFile
+        }, e(IconLabel, { icon: Download, label: state.name })),
+        e('a', { className: 'block mt-6 text-sm text-blue-600 hover:underline', href: '/' }, i18n.t('back-to-clipboard'))
+    );
+}
+
 function getDownloadFilename(contentDisposition) {
     if (!contentDisposition) {
         return 'download';
@@ -0,0 +1,71 @@
+import React, { useEffect, useState } from 'react';
+import { Download } from 'lucide-react';
+import { Auth } from './auth.js';
+import { i18n } from './i18n.js';
+import { IconLabel, Modal } from './shared.jsx';
+
+const e = React.createElement;
+
+export function bundleZipUrl(id) {
+    return `/api/bundle/${id}/zip`;
+}
+
+// bundleFileUrl keeps the slashes of a file's path, which the server reads
+// as one wildcard segment.
+function bundleFileUrl(id, path) {
+    return `/api/bundle/${id}/files/${path.split('/').map(encodeURIComponent).join('/')}`;
+}
+
+function formatSize(bytes) {
+    if (bytes < 1024) {
+        return `${bytes} B`;
+    }
+    if (bytes < 1024 * 1024) {
+        return `${(bytes / 1024).toFixed(1)} KB`;
+    }
+    return `${(bytes / 1024 / 1024).toFixed(2)} MB`;
+}
+
+// BundleFilesModal lists the files of a bundle item. Each file downloads on
+// its own, or the whole bundle as one zip. Listing does not use up a read
+// of a read-limited bundle; downloads do.
+export function BundleFilesModal({ item, onClose, onDownload, showMessage }) {
+    const [bundle, setBundle] = useState(null);
+
+    useEffect(() => {
+        Auth.json(`/api/bundle/${item.id}`)
+            .then(setBundle)
+            .catch((error) => {
+                showMessage(i18n.t('bundle-load-failed', error.message), 'error');
+                onClose();
+            });
+    }, [item.id]);
+
+    return e(Modal, { title: item.fileName || item.description, onClose },
+        !bundle
+            ? e('p', { className: 'text-sm text-gray-500' }, i18n.t('bundle-loading'))
+            : e(React.Fragment, null,
+                e('div', { className: 'space-y-1 max-h-80 overflow-y-auto mb-4' }, bundle.files.map((file) =>
+                    e('div', { key: file.path, className: 'flex items-center justify-between gap-2 p-2 bg-gray-50 rounded border text-sm' },
+                        e('span', { className: 'min-w-0 truncate font-mono text-xs text-gray-800', title: file.path }, file.path),
+                        e('span', { className: 'flex shrink-0 items-center gap-2' },
+                            e('span', { className: 'text-xs text-gray-500' }, formatSize(file.size)),
+                            e('button', {
+                                className: 'p-2 rounded bg-green-100 hover:bg-green-200 text-green-700',
+                                title: i18n.t('item-action-download-file'),
+                                'aria-label': i18n.t('item-action-download-file'),
+                                onClick: () => onDownload(bundleFileUrl(item.id, file.path))
+                            }, e(Download, { size: 14, 'aria-hidden': true }))
+                        )
+                    )
+                )),
+                e('div', { className: 'flex justify-between items-center' },
+                    e('span', { className: 'text-xs text-gray-500' }, i18n.t('bundle-summary', bundle.files.length, formatSize(bundle.size))),
+                    e('button', {
+                        className: 'px-4 py-2 rounded bg-blue-500 hover:bg-blue-600 text-white text-sm',
+                        onClick: () => onDownload(bundleZipUrl(item.id))
+                    }, e(IconLabel, { icon: Download, label: i18n.t('bundle-download-zip') }))
+                )
+            )
+    );
+}
@@ -0,0 +1,90 @@
+import React, { useState } from 'react';
+import { FolderPlus, Trash2 } from 'lucide-react';
+import { Auth } from './auth.js';
+import { i18n } from './i18n.js';
+import { IconLabel, Modal } from './shared.jsx';
+
+const e = React.createElement;
+
+const COLLECTION_EXPIRATION_CHOICES = ['1h', '1d', '7d', 'never'];
+
+// CollectionsModal creates and deletes the user's collections. Deleting a
+// collection keeps its items; they are just no longer filed anywhere.
+export function CollectionsModal({ collections, onClose, onChanged, showMessage }) {
+    const [name, setName] = useState('');
+    const [expiresIn, setExpiresIn] = useState('');
+
+    async function createCollection(event) {
+        event.preventDefault();
+        try {
+            await Auth.json('/api/collections', {
+                method: 'POST',
+                body: JSON.stringify({ name, expiresIn })
+            });
+            setName('');
+            setExpiresIn('');
+            onChanged();
+            showMessage(i18n.t('collection-created'));
+        } catch (error) {
+            showMessage(i18n.t('collection-create-failed', error.message), 'error');
+        }
+    }
+
+    async function deleteCollection(collection) {
+        if (!window.confirm(i18n.t('collection-delete-confirm', collection.name))) {
+            return;
+        }
+        try {
+            await Auth.json(`/api/collections/${collection.id}`, { method: 'DELETE' });
+            onChanged();
+            showMessage(i18n.t('collection-deleted'));
+        } catch (error) {
+            showMessage(i18n.t('collection-delete-failed', error.message), 'error');
+        }
+    }
+
+    return e(Modal, { title: i18n.t('collections'), onClose },
+        e('form', { className: 'flex flex-wrap items-center gap-2 mb-5', onSubmit: createCollection },
+            e('input', {
+                type: 'text',
+                className: 'flex-1 min-w-0 p-2 border border-gray-300 rounded text-sm',
+                placeholder: i18n.t('collection-name'),
+                'aria-label': i18n.t('collection-name'),
+                required: true,
+                maxLength: 64,
+                value: name,
+                onChange: (event) => setName(event.target.value)
+            }),
+            e('select', {
+                className: 'p-2 border border-gray-300 rounded text-sm',
+                'aria-label': i18n.t('collection-expiration'),
+                value: expiresIn,
+                onChange: (event) => setExpiresIn(event.target.value)
+            },
+            e('option', { value: '' }, i18n.t('expiration-default')),
+            COLLECTION_EXPIRATION_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`expiration-${choice}`)))
+            ),
+            e('button', { type: 'submit', className: 'px-4 py-2 rounded bg-blue-500 text-white text-sm' },
+                e(IconLabel, { icon: FolderPlus, label: i18n.t('collection-create') })
+            )
+        ),
+        collections.length === 0
+            ? e('p', { className: 'text-sm text-gray-500' }, i18n.t('no-collections'))
+            : e('div', { className: 'space-y-2 max-h-64 overflow-y-auto' }, collections.map((collection) =>
+                e('div', { key: collection.id, className: 'flex items-center justify-between gap-2 p-2 bg-gray-50 rounded border text-sm' },
+                    e('div', { className: 'min-w-0' },
+                        e('span', { className: 'font-medium text-gray-800' }, collection.name),
+                        e('span', { className: 'ml-2 text-xs text-gray-500' }, i18n.t('collection-items', collection.items)),
+                        collection.expiresIn && e('span', { className: 'ml-2 text-xs text-gray-500' }, i18n.t('collection-default-expiration', collection.expiresIn))
+                    ),
+                    e('button', {
+                        type: 'button',
+                        className: 'p-2 rounded bg-red-100 hover:bg-red-200 text-red-700',
+                        title: i18n.t('collection-delete'),
+                        'aria-label': i18n.t('collection-delete'),
+                        onClick: () => deleteCollection(collection)
+                    }, e(Trash2, { size: 14, 'aria-hidden': true }))
+                )
+            ))
+    );
+}
@@ -0,0 +1,120 @@
+import React, { useEffect, useState } from 'react';
+import { History, RefreshCw, Save } from 'lucide-react';
+import { Auth } from './auth.js';
+import { i18n } from './i18n.js';
+import { IconLabel, Modal } from './shared.jsx';
+
+const e = React.createElement;
+
+// TextEditorModal edits a text item in place. Saving sends the ETag the text
+// was loaded with, so an edit made meanwhile on another device is reported
+// as a conflict instead of being overwritten. Earlier revisions can be
+// loaded back into the editor and saved again to restore them.
+export function TextEditorModal({ item, onClose, onSaved, showMessage }) {
+    const [content, setContent] = useState('');
+    const [etag, setEtag] = useState('');
+    const [revisions, setRevisions] = useState([]);
+    const [conflict, setConflict] = useState(false);
+
+    useEffect(() => {
+        loadLatest();
+    }, [item.id]);
+
+    async function loadLatest() {
+        try {
+            const response = await Auth.fetch(`/api/text/${item.id}`);
+            const data = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            setContent(data.content);
+            setEtag(response.headers.get('ETag') || '');
+            setConflict(false);
+            loadRevisions();
+        } catch (error) {
+            showMessage(i18n.t('edit-load-failed', error.message), 'error');
+        }
+    }
+
+    async function loadRevisions() {
+        try {
+            const data = await Auth.json(`/api/text/${item.id}/revisions`);
+            setRevisions(data.revisions || []);
+        } catch (error) {
+            showMessage(i18n.t('revisions-load-failed', error.message), 'error');
+        }
+    }
+
+    async function loadRevision(revision) {
+        try {
+            const data = await Auth.json(`/api/text/${item.id}/revisions/${revision}`);
+            setContent(data.content);
+        } catch (error) {
+            showMessage(i18n.t('revisions-load-failed', error.message), 'error');
+        }
+    }
+
+    async function saveText(event) {
+        event.preventDefault();
+        try {
+            const response = await Auth.fetch(`/api/text/${item.id}`, {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json', 'If-Match': etag },
+                body: JSON.stringify({ content })
+            });
+            const data = await response.json().catch(() => ({}));
+            if (response.status === 412) {
+                setConflict(true);
+                showMessage(i18n.t('edit-conflict'), 'error');
+                return;
+            }
+            if (!response.ok) {
+                throw new Error(data.error || `HTTP ${response.status}`);
+            }
+            setEtag(response.headers.get('ETag') || '');
+            onSaved(content);
+            showMessage(i18n.t('text-updated', data.revision));
+            loadRevisions();
+        } catch (error) {
+            showMessage(i18n.t('text-update-failed', error.message), 'error');
+        }
+    }
+
+    return e(Modal, { title: i18n.t('edit-text-title'), onClose },
+        e('form', { className: 'space-y-3 mb-5', onSubmit: saveText },
+            e('textarea', {
+                className: 'w-full h-48 p-2 border rounded font-mono text-sm resize-none',
+                value: content,
+                required: true,
+                onChange: (event) => setContent(event.target.value)
+            }),
+            conflict && e('p', { className: 'text-sm text-red-600' }, i18n.t('edit-conflict')),
+            e('div', { className: 'flex justify-end gap-2' },
+                conflict && e('button', { type: 'button', className: 'px-4 py-2 rounded bg-gray-100 text-gray-700 inline-flex items-center gap-2', onClick: loadLatest },
+                    e(IconLabel, { icon: RefreshCw, label: i18n.t('edit-load-latest') })
+                ),
+                e('button', { type: 'submit', className: 'px-4 py-2 rounded bg-blue-500 text-white inline-flex items-center gap-2', disabled: !etag },
+                    e(IconLabel, { icon: Save, label: i18n.t('save') })
+                )
+            )
+        ),
+        e('h4', { className: 'text-sm font-semibold text-gray-700 mb-2' }, i18n.t('revisions')),
+        e('div', { className: 'space-y-2 max-h-48 overflow-y-auto' }, revisions.map((revision) =>
+            e('div', { key: revision.revision, className: 'flex items-center justify-between gap-2 p-2 bg-gray-50 rounded border text-xs' },
+                e('div', { className: 'min-w-0 text-gray-600' },
+                    e('span', { className: 'font-medium text-gray-800' }, i18n.t('revision-number', revision.revision)),
+                    e('span', { className: 'ml-2' }, new Date(revision.createdAt).toLocaleString()),
+                    revision.editor && e('span', { className: 'ml-2' }, revision.editor),
+                    revision.current && e('span', { className: 'ml-2 text-green-700' }, i18n.t('revision-current'))
+                ),
+                !revision.current && e('button', {
+                    type: 'button',
+                    className: 'p-2 rounded bg-blue-100 hover:bg-blue-200 text-blue-700',
+                    title: i18n.t('revision-load'),
+                    'aria-label': i18n.t('revision-load'),
+                    onClick: () => loadRevision(revision.revision)
+                }, e(History, { size: 14, 'aria-hidden': true }))
+            )
+        ))
+    );
+}
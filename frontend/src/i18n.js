@@ -44,6 +44,15 @@ class I18n {
                 'hours': 'Hours',
                 'days': 'Days',
                 'never': 'Never expires',
+                'storage-quotas': 'Storage quotas (0 = unlimited)',
+                'quota-user': 'Each user',
+                'quota-admin': 'Each administrator',
+                'quota-max-mb': 'Max storage (MB)',
+                'quota-max-items': 'Max items',
+                'quota-global-mb': 'Server-wide storage cap (MB)',
+                'retention-limits': 'Longest item lifetime uploaders may choose (0 = no limit, never allowed)',
+                'retention-user-hours': 'Each user (hours)',
+                'retention-admin-hours': 'Each administrator (hours)',
                 'save-system-settings': 'Save System Settings',
                 'saving': 'Saving...',
                 'settings-saved': 'Settings saved',
@@ -77,8 +86,154 @@ class I18n {
                 'failed-load-text': 'Failed to load text',
                 'error-loading-text': 'Error loading text: {0}',
                 'selected-file': 'Selected: {0} ({1} MB)',
+                'select-folder': 'Select a folder',
+                'selected-files': 'Selected: {0} files ({1} MB), uploaded as one bundle',
+                'bundle-uploaded': 'Bundle of {0} files uploaded',
+                'bundle-end-to-end-unsupported': 'Several files cannot be end-to-end encrypted; upload them one at a time',
+                'bundle-files': 'Files',
+                'bundle-loading': 'Loading...',
+                'bundle-load-failed': 'Failed to list bundle files: {0}',
+                'bundle-summary': '{0} files, {1}',
+                'bundle-download-zip': 'Download all as zip',
+                'image-dimensions': '{0} × {1} px',
+                'metadata-stripped': 'Metadata removed',
+                'view-file': 'View',
+                'view-not-supported': 'This file can only be downloaded',
+                'error-viewing-file': 'Could not open file: {0}',
                 'please-select-file': 'Please select a file',
                 'file-uploaded': 'File uploaded. Use Recent Items to download it.',
+                'end-to-end-encrypt': 'End-to-end encrypt (the server never sees the content)',
+                'secret-item': 'Encrypted item',
+                'secret-saved': 'Encrypted item saved. Share the link below; it holds the only copy of the key.',
+                'secret-link-copied': 'Encrypted item saved and link copied. The link holds the only copy of the key.',
+                'secret-link-hint': 'Anyone signed in with this link can decrypt the item. The key is not stored anywhere else.',
+                'copy-secret-link': 'Copy link',
+                'secret-title': 'Encrypted item',
+                'secret-notice': 'Decrypted in your browser with the key from the link.',
+                'secret-decrypting': 'Decrypting...',
+                'secret-not-found': 'Item not found or expired',
+                'secret-decrypt-failed': 'Could not decrypt this item. The link may be incomplete.',
+                'back-to-clipboard': 'Back to clipboard',
+                'burn-after-read': 'Delete after the first view',
+                'reads-left': 'Views left: {0}',
+                'expires-in': 'Expires',
+                'expiration-default': 'Default',
+                'expiration-1h': 'In 1 hour',
+                'expiration-1d': 'In 1 day',
+                'expiration-7d': 'In 7 days',
+                'expiration-never': 'Never',
+                'expires': 'Expires: {0}',
+                'change-expiration': 'Change expiry',
+                'expiration-updated': 'Expiration updated',
+                'expiration-update-failed': 'Failed to change expiration: {0}',
+                'item-password': 'Password (optional)',
+                'password-protected': 'Password protected',
+                'visibility': 'Visible to',
+                'visibility-default': 'Default',
+                'visibility-private': 'Only me',
+                'visibility-users': 'Chosen users',
+                'visibility-everyone': 'Everyone signed in',
+                'visibility-usernames': 'Usernames, separated by commas',
+                'shared-with': 'Shared with: {0}',
+                'change-visibility': 'Change visibility',
+                'visibility-updated': 'Visibility updated',
+                'visibility-update-failed': 'Failed to change visibility: {0}',
+                'send-item': 'Send',
+                'send-item-usernames': 'Send to (usernames, separated by commas)',
+                'item-sent': 'Sent to {0}',
+                'send-item-failed': 'Failed to send item: {0}',
+                'received-items': 'Received',
+                'received-from': 'From: {0}',
+                'opened-at': 'Opened: {0}',
+                'not-opened': 'Not opened yet',
+                'opened-by': 'Opened by: {0}',
+                'search': 'Search',
+                'search-placeholder': 'Search your text and file names',
+                'search-results': 'Search results ({0})',
+                'search-failed': 'Search failed: {0}',
+                'collections': 'Collections',
+                'collection': 'Collection',
+                'collection-none': 'No collection',
+                'collection-all': 'All collections',
+                'collections-load-failed': 'Failed to load collections: {0}',
+                'collection-name': 'Collection name',
+                'collection-expiration': 'Default expiration for items saved into it',
+                'collection-create': 'Create',
+                'collection-created': 'Collection created',
+                'collection-create-failed': 'Failed to create collection: {0}',
+                'collection-delete': 'Delete collection',
+                'collection-delete-confirm': 'Delete collection "{0}"? Its items are kept.',
+                'collection-deleted': 'Collection deleted',
+                'collection-delete-failed': 'Failed to delete collection: {0}',
+                'collection-items': '{0} items',
+                'collection-default-expiration': 'Items expire after {0}',
+                'no-collections': 'No collections yet',
+                'in-collection': 'In {0}',
+                'move-to-collection': 'Move to collection',
+                'collection-updated': 'Item moved',
+                'collection-update-failed': 'Failed to move item: {0}',
+                'tags': 'Tags',
+                'tags-placeholder': 'Tags, separated by commas',
+                'edit-tags': 'Tags',
+                'tags-updated': 'Tags updated',
+                'tags-update-failed': 'Failed to update tags: {0}',
+                'history-tag': 'Tag',
+                'history': 'History',
+                'show-history': 'Show full history',
+                'history-load-failed': 'Failed to load history: {0}',
+                'history-load-more': 'Load more',
+                'history-type': 'Type',
+                'history-type-all': 'All types',
+                'history-type-text': 'Text',
+                'history-type-file': 'Files',
+                'history-type-secret-text': 'End-to-end encrypted',
+                'history-sort': 'Sort',
+                'history-sort-newest': 'Newest first',
+                'history-sort-oldest': 'Oldest first',
+                'history-sort-largest': 'Largest first',
+                'history-sort-smallest': 'Smallest first',
+                'history-sort-expiring': 'Expiring soonest',
+                'history-pinned-only': 'Pinned only',
+                'pinned': 'Pinned',
+                'pin-item': 'Pin',
+                'unpin-item': 'Unpin',
+                'item-pinned': 'Item pinned, it will not expire until unpinned',
+                'item-unpinned': 'Item unpinned',
+                'pin-update-failed': 'Failed to update pin: {0}',
+                'max-pinned-items': 'Max pinned items per user (0 = no limit)',
+                'strip-image-metadata': 'Remove location and other metadata from uploaded images',
+                'edit-text': 'Edit',
+                'edit-text-title': 'Edit text',
+                'edit-load-failed': 'Failed to load text: {0}',
+                'edit-conflict': 'This text was changed on another device. Load the latest version before saving.',
+                'edit-load-latest': 'Load latest',
+                'text-updated': 'Text saved as revision {0}',
+                'text-update-failed': 'Failed to save text: {0}',
+                'revisions': 'Revisions',
+                'revisions-load-failed': 'Failed to load revisions: {0}',
+                'revision-number': 'Revision {0}',
+                'revision-current': 'Current',
+                'revision-load': 'Load into editor',
+                'share-item': 'Share',
+                'share-title': 'Public links',
+                'share-expires': 'Link expires',
+                'share-expires-with-item': 'When the item expires',
+                'share-max-downloads': 'Max downloads (0 = unlimited)',
+                'share-create': 'Create link',
+                'share-active': 'Active links',
+                'share-none': 'No active links',
+                'share-downloads': 'Downloads: {0}/{1}',
+                'share-copy-address': 'Copy public link',
+                'share-address-copied': 'Public link copied to clipboard',
+                'share-address-copy-failed': 'Failed to copy the public link',
+                'share-revoke': 'Revoke',
+                'share-revoked': 'Link revoked',
+                'share-load-failed': 'Failed to load links: {0}',
+                'share-create-failed': 'Failed to create link: {0}',
+                'share-revoke-failed': 'Failed to revoke link: {0}',
+                'last-view-text-copied': 'Text copied. That was its last view, so it has been deleted.',
+                'last-view-file-downloaded': 'File downloaded. That was its last download, so it has been deleted.',
+                'secret-last-view': 'This was the last view; the item has been deleted from the server. Save what you need now.',
                 'failed-upload-file': 'Failed to upload file',
                 'error-uploading-file': 'Error uploading file: {0}',
                 'file-downloaded': 'File downloaded successfully!',
@@ -156,12 +311,141 @@ class I18n {
                 'hours': '小时',
                 'days': '天',
                 'never': '永不过期',
+                'storage-quotas': '存储配额（0 表示不限制）',
+                'quota-user': '每个普通用户',
+                'quota-admin': '每个管理员',
+                'quota-max-mb': '最大存储（MB）',
+                'quota-max-items': '最大条目数',
+                'quota-global-mb': '全站存储上限（MB）',
+                'retention-limits': '上传者可选择的最长有效期（0 表示不限制，可选永不过期）',
+                'retention-user-hours': '每个普通用户（小时）',
+                'retention-admin-hours': '每个管理员（小时）',
                 'save-system-settings': '保存系统设置',
                 'saving': '保存中...',
                 'settings-saved': '设置已保存',
                 'load-settings-failed': '加载设置失败：{0}',
                 'save-settings-failed': '保存设置失败：{0}',
                 'back-to-clipboard': '返回剪贴板',
+                'burn-after-read': '查看一次后删除',
+                'reads-left': '剩余查看次数：{0}',
+                'expires-in': '有效期',
+                'expiration-default': '默认',
+                'expiration-1h': '1 小时',
+                'expiration-1d': '1 天',
+                'expiration-7d': '7 天',
+                'expiration-never': '永不过期',
+                'expires': '过期时间：{0}',
+                'change-expiration': '修改有效期',
+                'expiration-updated': '有效期已更新',
+                'expiration-update-failed': '修改有效期失败：{0}',
+                'item-password': '访问密码（可选）',
+                'password-protected': '已设置密码',
+                'visibility': '可见范围',
+                'visibility-default': '默认',
+                'visibility-private': '仅自己',
+                'visibility-users': '指定用户',
+                'visibility-everyone': '所有登录用户',
+                'visibility-usernames': '用户名，多个用逗号分隔',
+                'shared-with': '已分享给：{0}',
+                'change-visibility': '修改可见范围',
+                'visibility-updated': '可见范围已更新',
+                'visibility-update-failed': '修改可见范围失败：{0}',
+                'send-item': '发送',
+                'send-item-usernames': '发送给（用户名，多个用逗号分隔）',
+                'item-sent': '已发送给 {0}',
+                'send-item-failed': '发送失败：{0}',
+                'received-items': '收到的条目',
+                'received-from': '来自：{0}',
+                'opened-at': '首次打开：{0}',
+                'not-opened': '尚未打开',
+                'opened-by': '已打开：{0}',
+                'search': '搜索',
+                'search-placeholder': '搜索文本内容和文件名',
+                'search-results': '搜索结果（{0}）',
+                'search-failed': '搜索失败：{0}',
+                'collections': '集合',
+                'collection': '集合',
+                'collection-none': '不放入集合',
+                'collection-all': '全部集合',
+                'collections-load-failed': '加载集合失败：{0}',
+                'collection-name': '集合名称',
+                'collection-expiration': '放入其中的条目默认有效期',
+                'collection-create': '新建',
+                'collection-created': '集合已创建',
+                'collection-create-failed': '创建集合失败：{0}',
+                'collection-delete': '删除集合',
+                'collection-delete-confirm': '删除集合“{0}”？其中的条目会保留。',
+                'collection-deleted': '集合已删除',
+                'collection-delete-failed': '删除集合失败：{0}',
+                'collection-items': '{0} 个条目',
+                'collection-default-expiration': '条目 {0} 后过期',
+                'no-collections': '还没有集合',
+                'in-collection': '位于：{0}',
+                'move-to-collection': '移动到集合',
+                'collection-updated': '条目已移动',
+                'collection-update-failed': '移动条目失败：{0}',
+                'tags': '标签',
+                'tags-placeholder': '标签，用逗号分隔',
+                'edit-tags': '标签',
+                'tags-updated': '标签已更新',
+                'tags-update-failed': '更新标签失败：{0}',
+                'history-tag': '标签',
+                'history': '历史记录',
+                'show-history': '查看全部历史',
+                'history-load-failed': '加载历史记录失败：{0}',
+                'history-load-more': '加载更多',
+                'history-type': '类型',
+                'history-type-all': '全部类型',
+                'history-type-text': '文本',
+                'history-type-file': '文件',
+                'history-type-secret-text': '端到端加密',
+                'history-sort': '排序',
+                'history-sort-newest': '最新在前',
+                'history-sort-oldest': '最早在前',
+                'history-sort-largest': '最大在前',
+                'history-sort-smallest': '最小在前',
+                'history-sort-expiring': '最快过期在前',
+                'history-pinned-only': '仅置顶',
+                'pinned': '已置顶',
+                'pin-item': '置顶',
+                'unpin-item': '取消置顶',
+                'item-pinned': '已置顶，取消置顶前不会过期',
+                'item-unpinned': '已取消置顶',
+                'pin-update-failed': '更新置顶失败：{0}',
+                'max-pinned-items': '每个用户最多置顶条目数（0 表示不限）',
+                'strip-image-metadata': '去除上传图片中的位置等元数据',
+                'edit-text': '编辑',
+                'edit-text-title': '编辑文本',
+                'edit-load-failed': '加载文本失败：{0}',
+                'edit-conflict': '这段文本已在其他设备上修改，请先加载最新版本再保存。',
+                'edit-load-latest': '加载最新版本',
+                'text-updated': '文本已保存为第 {0} 版',
+                'text-update-failed': '保存文本失败：{0}',
+                'revisions': '历史版本',
+                'revisions-load-failed': '加载历史版本失败：{0}',
+                'revision-number': '第 {0} 版',
+                'revision-current': '当前版本',
+                'revision-load': '载入编辑器',
+                'share-item': '分享',
+                'share-title': '公开链接',
+                'share-expires': '链接有效期',
+                'share-expires-with-item': '与条目同时过期',
+                'share-max-downloads': '最多下载次数（0 表示不限）',
+                'share-create': '创建链接',
+                'share-active': '有效的链接',
+                'share-none': '暂无有效链接',
+                'share-downloads': '下载次数：{0}/{1}',
+                'share-copy-address': '复制公开链接',
+                'share-address-copied': '公开链接已复制到剪贴板',
+                'share-address-copy-failed': '复制公开链接失败',
+                'share-revoke': '撤销',
+                'share-revoked': '链接已撤销',
+                'share-load-failed': '加载链接失败：{0}',
+                'share-create-failed': '创建链接失败：{0}',
+                'share-revoke-failed': '撤销链接失败：{0}',
+                'last-view-text-copied': '文本已复制。这是最后一次查看，条目已删除。',
+                'last-view-file-downloaded': '文件已下载。这是最后一次下载，条目已删除。',
+                'secret-last-view': '这是最后一次查看，条目已从服务器删除，请立即保存需要的内容。',
                 'logout': '退出登录',
                 'username': '用户名',
                 'password': '密码',
@@ -189,8 +473,34 @@ class I18n {
                 'failed-load-text': '加载文本失败',
                 'error-loading-text': '加载文本时出错：{0}',
                 'selected-file': '已选择：{0} ({1} MB)',
+                'select-folder': '选择文件夹',
+                'selected-files': '已选择 {0} 个文件（{1} MB），将作为一个文件包上传',
+                'bundle-uploaded': '已上传包含 {0} 个文件的文件包',
+                'bundle-end-to-end-unsupported': '多个文件不能端到端加密，请逐个上传',
+                'bundle-files': '文件列表',
+                'bundle-loading': '加载中...',
+                'bundle-load-failed': '获取文件包内容失败：{0}',
+                'bundle-summary': '{0} 个文件，共 {1}',
+                'bundle-download-zip': '打包下载 zip',
+                'image-dimensions': '{0} × {1} 像素',
+                'metadata-stripped': '已去除元数据',
+                'view-file': '查看',
+                'view-not-supported': '此文件只能下载',
+                'error-viewing-file': '无法打开文件：{0}',
                 'please-select-file': '请选择一个文件',
                 'file-uploaded': '文件上传成功，可在最近项目中下载。',
+                'end-to-end-encrypt': '端到端加密（服务器无法看到内容）',
+                'secret-item': '加密条目',
+                'secret-saved': '加密条目已保存。请分享下方链接，密钥只保存在链接中。',
+                'secret-link-copied': '加密条目已保存，链接已复制。密钥只保存在链接中。',
+                'secret-link-hint': '已登录的用户持有此链接即可解密，密钥没有保存在其他任何地方。',
+                'copy-secret-link': '复制链接',
+                'secret-title': '加密条目',
+                'secret-notice': '使用链接中的密钥在浏览器内解密。',
+                'secret-decrypting': '正在解密...',
+                'secret-not-found': '条目未找到或已过期',
+                'secret-decrypt-failed': '无法解密此条目，链接可能不完整。',
+                'back-to-clipboard': '返回剪贴板',
                 'failed-upload-file': '上传文件失败',
                 'error-uploading-file': '上传文件时出错：{0}',
                 'file-downloaded': '文件下载成功！',
@@ -0,0 +1,128 @@
+import { Auth } from './auth.js';
+
+// Secret clips are encrypted here with AES-256-GCM before upload. The key
+// only ever appears in the link's fragment, which browsers do not send to
+// the server, so the server stores nothing it can decrypt.
+
+const IV_LENGTH = 12;
+const PENDING_SECRET_KEY = 'pending-secret';
+
+function toBase64Url(bytes) {
+    let binary = '';
+    bytes.forEach((byte) => {
+        binary += String.fromCharCode(byte);
+    });
+    return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+function fromBase64Url(value) {
+    const binary = atob(value.replace(/-/g, '+').replace(/_/g, '/'));
+    return Uint8Array.from(binary, (char) => char.charCodeAt(0));
+}
+
+async function encrypt(plaintext) {
+    const key = await crypto.subtle.generateKey({ name: 'AES-GCM', length: 256 }, true, ['encrypt']);
+    const iv = crypto.getRandomValues(new Uint8Array(IV_LENGTH));
+    const sealed = new Uint8Array(await crypto.subtle.encrypt({ name: 'AES-GCM', iv }, key, plaintext));
+    const body = new Uint8Array(IV_LENGTH + sealed.length);
+    body.set(iv);
+    body.set(sealed, IV_LENGTH);
+    const rawKey = new Uint8Array(await crypto.subtle.exportKey('raw', key));
+    return { body, key: toBase64Url(rawKey) };
+}
+
+async function decrypt(body, encodedKey) {
+    const key = await crypto.subtle.importKey('raw', fromBase64Url(encodedKey), 'AES-GCM', false, ['decrypt']);
+    const bytes = new Uint8Array(body);
+    return crypto.subtle.decrypt({ name: 'AES-GCM', iv: bytes.slice(0, IV_LENGTH) }, key, bytes.slice(IV_LENGTH));
+}
+
+// Files carry their name and type inside the ciphertext: a 4-byte
+// big-endian header length, the JSON header, then the file bytes.
+function packFile(file, bytes) {
+    const header = new TextEncoder().encode(JSON.stringify({ name: file.name, type: file.type || '' }));
+    const packed = new Uint8Array(4 + header.length + bytes.byteLength);
+    new DataView(packed.buffer).setUint32(0, header.length);
+    packed.set(header, 4);
+    packed.set(new Uint8Array(bytes), 4 + header.length);
+    return packed;
+}
+
+function unpackFile(buffer) {
+    const length = new DataView(buffer).getUint32(0);
+    const header = JSON.parse(new TextDecoder().decode(new Uint8Array(buffer, 4, length)));
+    return {
+        name: header.name || 'download',
+        contentType: header.type || 'application/octet-stream',
+        blob: new Blob([new Uint8Array(buffer, 4 + length)], { type: header.type || 'application/octet-stream' })
+    };
+}
+
+async function upload(type, plaintext, options) {
+    const { body, key } = await encrypt(plaintext);
+    const query = new URLSearchParams({ type, ...options });
+    const response = await Auth.fetch(`/api/secret?${query}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/octet-stream' },
+        body
+    });
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+        throw new Error(data.error || `HTTP ${response.status}`);
+    }
+    return { ...data, link: secretLink(data.id, key) };
+}
+
+export function secretLink(id, key) {
+    return `${window.location.origin}/#secret/${id}/${key}`;
+}
+
+export function parseSecretLink(hash) {
+    const match = /^#secret\/([a-z0-9]+)\/([A-Za-z0-9_-]+)$/.exec(hash || '');
+    return match ? { id: match[1], key: match[2] } : null;
+}
+
+// rememberSecretLink keeps the fragment across the login redirect, which
+// would otherwise drop it.
+export function rememberSecretLink() {
+    if (parseSecretLink(window.location.hash)) {
+        sessionStorage.setItem(PENDING_SECRET_KEY, window.location.hash);
+        return;
+    }
+    const pending = sessionStorage.getItem(PENDING_SECRET_KEY);
+    sessionStorage.removeItem(PENDING_SECRET_KEY);
+    if (pending && !window.location.hash) {
+        window.history.replaceState(null, '', pending);
+    }
+}
+
+export function forgetSecretLink() {
+    sessionStorage.removeItem(PENDING_SECRET_KEY);
+}
+
+export function saveSecretText(text, options = {}) {
+    return upload('text', new TextEncoder().encode(text), options);
+}
+
+export async function saveSecretFile(file, options = {}) {
+    return upload('file', packFile(file, await file.arrayBuffer()), options);
+}
+
+// openSecret fetches and decrypts a secret clip. A wrong key or tampered
+// ciphertext fails with a decryption error.
+export async function openSecret(id, key) {
+    const response = await Auth.fetch(`/api/secret/${id}`);
+    if (!response.ok) {
+        const data = await response.json().catch(() => ({}));
+        const error = new Error(data.error || `HTTP ${response.status}`);
+        error.status = response.status;
+        throw error;
+    }
+    const type = response.headers.get('Clipboard-Item-Type');
+    const lastView = response.headers.get('Clipboard-Last-View') === 'true';
+    const plaintext = await decrypt(await response.arrayBuffer(), key);
+    if (type === 'file') {
+        return { type, lastView, ...unpackFile(plaintext) };
+    }
+    return { type: 'text', lastView, text: new TextDecoder().decode(plaintext) };
+}
@@ -231,7 +231,54 @@ function SystemSettings({ settings, onSubmit }) {
                             e('option', { value: 'day' }, i18n.t('days')),
                             e('option', { value: 'never' }, i18n.t('never'))
                         )
-                    )
+                    ),
+                    e(NumberField, {
+                        label: i18n.t('max-pinned-items'),
+                        value: form.clipboard.maxPinnedItems || 0,
+                        onChange: (value) => update(['clipboard', 'maxPinnedItems'], Math.max(0, Math.floor(value)))
+                    }),
+                    e(ToggleField, {
+                        label: i18n.t('strip-image-metadata'),
+                        checked: !!form.clipboard.stripImageMetadata,
+                        onChange: (checked) => update(['clipboard', 'stripImageMetadata'], checked)
+                    })
+                )
+            ),
+            e('div', { className: 'border-t pt-4' },
+                e('h3', { className: 'text-base font-semibold text-gray-700 mb-3' }, i18n.t('storage-quotas')),
+                e('div', { className: 'grid grid-cols-1 sm:grid-cols-2 gap-4' },
+                    e(QuotaLimitsFields, {
+                        title: i18n.t('quota-user'),
+                        limits: form.quotas.user,
+                        onChange: (limits) => update(['quotas', 'user'], limits)
+                    }),
+                    e(QuotaLimitsFields, {
+                        title: i18n.t('quota-admin'),
+                        limits: form.quotas.admin,
+                        onChange: (limits) => update(['quotas', 'admin'], limits)
+                    })
+                ),
+                e('div', { className: 'mt-4' },
+                    e(NumberField, {
+                        label: i18n.t('quota-global-mb'),
+                        value: bytesToMegabytes(form.quotas.globalMaxBytes),
+                        onChange: (value) => update(['quotas', 'globalMaxBytes'], megabytesToBytes(value))
+                    })
+                )
+            ),
+            e('div', { className: 'border-t pt-4' },
+                e('h3', { className: 'text-base font-semibold text-gray-700 mb-3' }, i18n.t('retention-limits')),
+                e('div', { className: 'grid grid-cols-1 sm:grid-cols-2 gap-4' },
+                    e(NumberField, {
+                        label: i18n.t('retention-user-hours'),
+                        value: minutesToHours(form.retention.user.maxMinutes),
+                        onChange: (value) => update(['retention', 'user', 'maxMinutes'], hoursToMinutes(value))
+                    }),
+                    e(NumberField, {
+                        label: i18n.t('retention-admin-hours'),
+                        value: minutesToHours(form.retention.admin.maxMinutes),
+                        onChange: (value) => update(['retention', 'admin', 'maxMinutes'], hoursToMinutes(value))
+                    })
                 )
             ),
             e('div', { className: 'flex justify-end' },
@@ -245,6 +292,51 @@ function SystemSettings({ settings, onSubmit }) {
     );
 }
 
+function QuotaLimitsFields({ title, limits, onChange }) {
+    return e('fieldset', { className: 'border border-gray-200 rounded-lg p-4 space-y-3' },
+        e('legend', { className: 'px-1 text-sm font-semibold text-gray-700' }, title),
+        e(NumberField, {
+            label: i18n.t('quota-max-mb'),
+            value: bytesToMegabytes(limits.maxBytes),
+            onChange: (value) => onChange({ ...limits, maxBytes: megabytesToBytes(value) })
+        }),
+        e(NumberField, {
+            label: i18n.t('quota-max-items'),
+            value: limits.maxItems || 0,
+            onChange: (value) => onChange({ ...limits, maxItems: Math.max(0, Math.floor(value)) })
+        })
+    );
+}
+
+function NumberField({ label, value, onChange }) {
+    return e('label', { className: 'block' },
+        e('span', { className: 'block text-sm font-medium text-gray-700 mb-1' }, label),
+        e('input', {
+            type: 'number',
+            min: 0,
+            className: 'w-full p-3 border border-gray-300 rounded-lg',
+            value,
+            onChange: (event) => onChange(Number(event.target.value) || 0)
+        })
+    );
+}
+
+function bytesToMegabytes(bytes) {
+    return Math.round((bytes || 0) / (1024 * 1024));
+}
+
+function megabytesToBytes(megabytes) {
+    return Math.max(0, Math.floor(megabytes)) * 1024 * 1024;
+}
+
+function minutesToHours(minutes) {
+    return Math.round((minutes || 0) / 60);
+}
+
+function hoursToMinutes(hours) {
+    return Math.max(0, Math.floor(hours)) * 60;
+}
+
 function ToggleField({ label, checked, onChange }) {
     return e('label', { className: 'flex items-center justify-between gap-3 rounded-lg border border-gray-200 p-3 text-sm text-gray-700' },
         e('span', { className: 'font-medium' }, label),
@@ -0,0 +1,131 @@
+import React, { useEffect, useState } from 'react';
+import { Link as LinkIcon, Plus, Trash2 } from 'lucide-react';
+import { Auth } from './auth.js';
+import { i18n } from './i18n.js';
+import { IconLabel, Modal, PasswordField } from './shared.jsx';
+
+const e = React.createElement;
+
+const SHARE_EXPIRATION_CHOICES = ['1h', '1d', '7d'];
+
+function shareAddress(share) {
+    return `${window.location.origin}${share.path}`;
+}
+
+// ShareModal lists an item's public links and mints new ones. Anyone with a
+// link can open the item without an account until it expires, runs out of
+// downloads or is revoked here.
+export function ShareModal({ item, onClose, showMessage }) {
+    const [shares, setShares] = useState([]);
+    const [expiresIn, setExpiresIn] = useState('1d');
+    const [maxDownloads, setMaxDownloads] = useState(0);
+    const [password, setPassword] = useState('');
+
+    useEffect(() => {
+        loadShares();
+    }, [item.id]);
+
+    async function loadShares() {
+        try {
+            const data = await Auth.json(`/api/items/${item.id}/shares`);
+            setShares(data.shares || []);
+        } catch (error) {
+            showMessage(i18n.t('share-load-failed', error.message), 'error');
+        }
+    }
+
+    async function mintShare(event) {
+        event.preventDefault();
+        try {
+            const share = await Auth.json(`/api/items/${item.id}/shares`, {
+                method: 'POST',
+                body: JSON.stringify({ expiresIn, maxDownloads, password })
+            });
+            setShares((current) => [share, ...current]);
+            setPassword('');
+            await copyShareAddress(share);
+        } catch (error) {
+            showMessage(i18n.t('share-create-failed', error.message), 'error');
+        }
+    }
+
+    async function copyShareAddress(share) {
+        try {
+            await navigator.clipboard.writeText(shareAddress(share));
+            showMessage(i18n.t('share-address-copied'));
+        } catch (error) {
+            showMessage(i18n.t('share-address-copy-failed'), 'error');
+        }
+    }
+
+    async function revokeShare(token) {
+        try {
+            await Auth.json(`/api/items/${item.id}/shares/${token}`, { method: 'DELETE' });
+            setShares((current) => current.filter((share) => share.token !== token));
+            showMessage(i18n.t('share-revoked'));
+        } catch (error) {
+            showMessage(i18n.t('share-revoke-failed', error.message), 'error');
+        }
+    }
+
+    return e(Modal, { title: i18n.t('share-title'), onClose },
+        e('form', { className: 'space-y-3 mb-5', onSubmit: mintShare },
+            e('div', { className: 'grid grid-cols-2 gap-3' },
+                e('label', { className: 'block' },
+                    e('span', { className: 'block text-sm font-medium text-gray-700 mb-1' }, i18n.t('share-expires')),
+                    e('select', { className: 'w-full p-2 border rounded', value: expiresIn, onChange: (event) => setExpiresIn(event.target.value) },
+                        SHARE_EXPIRATION_CHOICES.map((choice) => e('option', { key: choice, value: choice }, i18n.t(`expiration-${choice}`))),
+                        e('option', { value: 'never' }, i18n.t('share-expires-with-item'))
+                    )
+                ),
+                e('label', { className: 'block' },
+                    e('span', { className: 'block text-sm font-medium text-gray-700 mb-1' }, i18n.t('share-max-downloads')),
+                    e('input', {
+                        type: 'number',
+                        min: 0,
+                        className: 'w-full p-2 border rounded',
+                        value: maxDownloads,
+                        onChange: (event) => setMaxDownloads(Math.max(0, Math.floor(Number(event.target.value) || 0)))
+                    })
+                )
+            ),
+            e(PasswordField, { label: i18n.t('item-password'), value: password, onChange: setPassword, required: false }),
+            e('div', { className: 'flex justify-end' },
+                e('button', { type: 'submit', className: 'px-4 py-2 rounded bg-blue-500 text-white inline-flex items-center gap-2' },
+                    e(IconLabel, { icon: Plus, label: i18n.t('share-create') })
+                )
+            )
+        ),
+        e('h4', { className: 'text-sm font-semibold text-gray-700 mb-2' }, i18n.t('share-active')),
+        shares.length === 0
+            ? e('p', { className: 'text-sm text-gray-500' }, i18n.t('share-none'))
+            : e('div', { className: 'space-y-2 max-h-64 overflow-y-auto' }, shares.map((share) =>
+                e('div', { key: share.token, className: 'flex items-center justify-between gap-2 p-2 bg-gray-50 rounded border text-xs' },
+                    e('div', { className: 'min-w-0' },
+                        e('div', { className: 'font-mono truncate' }, shareAddress(share)),
+                        e('div', { className: 'text-gray-500 mt-1' },
+                            new Date(share.expiresAt).getUTCFullYear() <= 1
+                                ? i18n.t('share-expires-with-item')
+                                : i18n.t('expires', new Date(share.expiresAt).toLocaleString()),
+                            share.maxDownloads > 0 && e('span', { className: 'ml-2' }, i18n.t('share-downloads', share.downloadCount || 0, share.maxDownloads)),
+                            share.passwordProtected && e('span', { className: 'ml-2' }, i18n.t('password-protected'))
+                        )
+                    ),
+                    e('div', { className: 'flex shrink-0 gap-1' },
+                        e('button', {
+                            className: 'p-2 rounded bg-blue-100 hover:bg-blue-200 text-blue-700',
+                            title: i18n.t('share-copy-address'),
+                            'aria-label': i18n.t('share-copy-address'),
+                            onClick: () => copyShareAddress(share)
+                        }, e(LinkIcon, { size: 14, 'aria-hidden': true })),
+                        e('button', {
+                            className: 'p-2 rounded bg-red-100 hover:bg-red-200 text-red-700',
+                            title: i18n.t('share-revoke'),
+                            'aria-label': i18n.t('share-revoke'),
+                            onClick: () => revokeShare(share.token)
+                        }, e(Trash2, { size: 14, 'aria-hidden': true }))
+                    )
+                )
+            ))
+    );
+}
@@ -0,0 +1,101 @@
+import { Auth } from './auth.js';
+
+const TUS_VERSION = '1.0.0';
+const CHUNK_SIZE = 2 * 1024 * 1024;
+const MAX_RETRIES = 5;
+
+// Files above this size go through the resumable tus endpoint so a dropped
+// connection only costs the current chunk.
+export const RESUMABLE_UPLOAD_THRESHOLD = 4 * 1024 * 1024;
+
+function encodeMetadata(value) {
+    const bytes = new TextEncoder().encode(value);
+    let binary = '';
+    bytes.forEach((byte) => {
+        binary += String.fromCharCode(byte);
+    });
+    return btoa(binary);
+}
+
+async function tusFetch(url, options = {}) {
+    return Auth.fetch(url, {
+        ...options,
+        headers: {
+            'Tus-Resumable': TUS_VERSION,
+            ...(options.headers || {})
+        }
+    });
+}
+
+async function responseError(response) {
+    const data = await response.json().catch(() => ({}));
+    return new Error(data.error || `HTTP ${response.status}`);
+}
+
+async function currentOffset(location) {
+    const response = await tusFetch(location, { method: 'HEAD' });
+    if (!response.ok) {
+        throw new Error(`HTTP ${response.status}`);
+    }
+    return Number(response.headers.get('Upload-Offset'));
+}
+
+function wait(ms) {
+    return new Promise((resolve) => setTimeout(resolve, ms));
+}
+
+// resumableUpload sends file in chunks and returns the clipboard item ID it
+// became. Network failures resume from the server's offset. options are
+// item options such as burnAfterRead, sent as upload metadata.
+export async function resumableUpload(file, onProgress = () => {}, options = {}) {
+    const metadata = [`filename ${encodeMetadata(file.name)}`, `filetype ${encodeMetadata(file.type || '')}`];
+    Object.entries(options).forEach(([key, value]) => {
+        metadata.push(`${key} ${encodeMetadata(String(value))}`);
+    });
+    const created = await tusFetch('/api/uploads', {
+        method: 'POST',
+        headers: {
+            'Upload-Length': String(file.size),
+            'Upload-Metadata': metadata.join(',')
+        }
+    });
+    if (!created.ok) {
+        throw await responseError(created);
+    }
+    const location = created.headers.get('Location');
+    let itemId = created.headers.get('Clipboard-Item-Id');
+    let offset = 0;
+    let retries = 0;
+
+    while (!itemId) {
+        try {
+            const response = await tusFetch(location, {
+                method: 'PATCH',
+                headers: {
+                    'Content-Type': 'application/offset+octet-stream',
+                    'Upload-Offset': String(offset)
+                },
+                body: file.slice(offset, offset + CHUNK_SIZE)
+            });
+            if (response.status === 409) {
+                offset = await currentOffset(location);
+                continue;
+            }
+            if (!response.ok) {
+                throw await responseError(response);
+            }
+            offset = Number(response.headers.get('Upload-Offset'));
+            itemId = response.headers.get('Clipboard-Item-Id');
+            retries = 0;
+            onProgress(offset / file.size);
+        } catch (error) {
+            if (!(error instanceof TypeError) || retries >= MAX_RETRIES) {
+                throw error;
+            }
+            retries++;
+            await wait(1000 * retries);
+            offset = await currentOffset(location).catch(() => offset);
+        }
+    }
+    return itemId;
+}
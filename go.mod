@@ -5,7 +5,9 @@ go 1.24.4
 require (
 	github.com/coreos/go-oidc/v3 v3.14.1
 	github.com/gin-gonic/gin v1.10.1
+	go.etcd.io/bbolt v1.4.3
 	golang.org/x/crypto v0.36.0
+	golang.org/x/image v0.25.0
 	golang.org/x/oauth2 v0.30.0
 )
 